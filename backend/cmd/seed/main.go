@@ -8,6 +8,7 @@ import (
 	"template-fullstack/backend/internal/pkg/db"
 	"template-fullstack/backend/internal/pkg/logger"
 	"template-fullstack/backend/internal/repository"
+	"template-fullstack/backend/internal/service"
 
 	"github.com/google/uuid"
 )
@@ -32,31 +33,52 @@ func main() {
 	userRepo := repository.NewUserRepository(database)
 	todoRepo := repository.NewTodoRepository(database)
 
+	// Users go through the service so passwords are hashed exactly as on signup
+	revocationStore := service.NewTokenRevocationStore(repository.NewRevokedTokenRepository(database), userRepo, cfg.JWT.RevocationCacheSize)
+	userService := service.NewUserService(userRepo, service.NewPasswordHasher(cfg.Password), revocationStore)
+
 	ctx := context.Background()
 
 	// Seed users
-	users := []domain.CreateUserRequest{
+	users := []struct {
+		domain.CreateUserRequest
+		Role domain.Role
+	}{
 		{
-			Email:    "admin@example.com",
-			Name:     "Admin User",
-			Password: "admin123",
+			CreateUserRequest: domain.CreateUserRequest{
+				Email:    "admin@example.com",
+				Name:     "Admin User",
+				Password: "admin123",
+			},
+			Role: domain.RoleAdmin,
 		},
 		{
-			Email:    "user@example.com",
-			Name:     "Regular User",
-			Password: "user123",
+			CreateUserRequest: domain.CreateUserRequest{
+				Email:    "user@example.com",
+				Name:     "Regular User",
+				Password: "user123",
+			},
+			Role: domain.RoleUser,
 		},
 	}
 
 	var userIDs []uuid.UUID
 	for _, userReq := range users {
-		user, err := userRepo.Create(ctx, userReq)
+		user, err := userService.Create(ctx, userReq.CreateUserRequest)
 		if err != nil {
 			log.Error().Err(err).Str("email", userReq.Email).Msg("Failed to create user")
 			continue
 		}
+
+		if userReq.Role != user.Role {
+			if user, err = userService.SetRole(ctx, user.ID, userReq.Role); err != nil {
+				log.Error().Err(err).Str("email", userReq.Email).Msg("Failed to set user role")
+				continue
+			}
+		}
+
 		userIDs = append(userIDs, user.ID)
-		log.Info().Str("email", user.Email).Msg("Created user")
+		log.Info().Str("email", user.Email).Str("role", string(user.Role)).Msg("Created user")
 	}
 
 	// Seed todos
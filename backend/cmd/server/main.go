@@ -8,11 +8,16 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	// Recurring todos are evaluated in their time zone; the runtime image
+	// has no zoneinfo of its own
+	_ "time/tzdata"
 
 	"template-fullstack/backend/internal/config"
 	"template-fullstack/backend/internal/http/router"
 	"template-fullstack/backend/internal/pkg/db"
 	"template-fullstack/backend/internal/pkg/logger"
+	"template-fullstack/backend/internal/pkg/storage"
+	"template-fullstack/backend/internal/repository"
 
 	_ "template-fullstack/backend/docs"
 )
@@ -54,8 +59,95 @@ func main() {
 	}
 	defer database.Close()
 
+	// Initialize attachment storage
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize storage")
+	}
+	if urlTTL, err := time.ParseDuration(cfg.Attachment.URLTTL); err != nil || urlTTL <= 0 {
+		log.Fatal().Err(err).Msg("Invalid ATTACHMENT_URL_TTL")
+	}
+	if cfg.Attachment.MaxSize < 1 {
+		log.Fatal().Int("value", cfg.Attachment.MaxSize).Msg("Invalid ATTACHMENT_MAX_SIZE")
+	}
+
 	// Initialize router
-	r := router.New(cfg, database, log)
+	r := router.New(cfg, database, store, log)
+
+	// Background jobs run until shutdown
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	todoRepo := repository.NewTodoRepository(database)
+
+	// Purge todos that have been in the trash longer than the retention
+	// period, and revocations of tokens that have expired
+	retention, err := time.ParseDuration(cfg.Trash.Retention)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid TRASH_RETENTION")
+	}
+	purgeInterval, err := time.ParseDuration(cfg.Trash.PurgeInterval)
+	if err != nil || purgeInterval <= 0 {
+		log.Fatal().Err(err).Msg("Invalid TRASH_PURGE_INTERVAL")
+	}
+
+	purger := &trashPurger{
+		todoRepo:         todoRepo,
+		revokedTokenRepo: repository.NewRevokedTokenRepository(database),
+		retention:        retention,
+		interval:         purgeInterval,
+		log:              log,
+	}
+	go purger.run(jobsCtx)
+
+	// Remind users of todos shortly before they are due
+	reminderLead, err := time.ParseDuration(cfg.Reminder.LeadTime)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid REMINDER_LEAD_TIME")
+	}
+	reminderInterval, err := time.ParseDuration(cfg.Reminder.Interval)
+	if err != nil || reminderInterval <= 0 {
+		log.Fatal().Err(err).Msg("Invalid REMINDER_INTERVAL")
+	}
+
+	reminders := &reminderScheduler{
+		todoRepo: todoRepo,
+		notifier: logReminderNotifier{log: log},
+		leadTime: reminderLead,
+		interval: reminderInterval,
+		log:      log,
+	}
+	go reminders.run(jobsCtx)
+
+	// Shorten position keys that have grown long from repeated moves
+	rebalanceInterval, err := time.ParseDuration(cfg.Position.RebalanceInterval)
+	if err != nil || rebalanceInterval <= 0 {
+		log.Fatal().Err(err).Msg("Invalid POSITION_REBALANCE_INTERVAL")
+	}
+	if cfg.Position.MaxKeyLength < 1 {
+		log.Fatal().Int("value", cfg.Position.MaxKeyLength).Msg("Invalid POSITION_MAX_KEY_LENGTH")
+	}
+
+	rebalancer := &positionRebalancer{
+		todoRepo:     todoRepo,
+		maxKeyLength: cfg.Position.MaxKeyLength,
+		interval:     rebalanceInterval,
+		log:          log,
+	}
+	go rebalancer.run(jobsCtx)
+
+	// Delete the stored files of deleted attachments
+	sweepInterval, err := time.ParseDuration(cfg.Attachment.SweepInterval)
+	if err != nil || sweepInterval <= 0 {
+		log.Fatal().Err(err).Msg("Invalid ATTACHMENT_SWEEP_INTERVAL")
+	}
+
+	sweeper := &blobSweeper{
+		attachmentRepo: repository.NewAttachmentRepository(database),
+		store:          store,
+		interval:       sweepInterval,
+		log:            log,
+	}
+	go sweeper.run(jobsCtx)
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -80,6 +172,7 @@ func main() {
 	<-quit
 
 	log.Info().Msg("Shutting down server...")
+	stopJobs()
 
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"template-fullstack/backend/internal/repository"
+
+	"github.com/rs/zerolog"
+)
+
+// trashPurger permanently deletes todos that have been in the trash for
+// longer than the retention period, and the revocations of access tokens
+// that have expired anyway.
+type trashPurger struct {
+	todoRepo         repository.TodoRepository
+	revokedTokenRepo repository.RevokedTokenRepository
+	retention        time.Duration
+	interval         time.Duration
+	log              zerolog.Logger
+}
+
+// run purges once immediately and then every interval until ctx is done
+func (p *trashPurger) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *trashPurger) purge(ctx context.Context) {
+	purged, err := p.todoRepo.PurgeDeleted(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			p.log.Error().Err(err).Msg("Failed to purge trashed todos")
+		}
+	} else if purged > 0 {
+		p.log.Info().Int64("count", purged).Msg("Purged trashed todos")
+	}
+
+	pruned, err := p.revokedTokenRepo.DeleteExpired(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.log.Error().Err(err).Msg("Failed to prune expired token revocations")
+		}
+	} else if pruned > 0 {
+		p.log.Info().Int64("count", pruned).Msg("Pruned expired token revocations")
+	}
+}
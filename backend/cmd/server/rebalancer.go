@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"template-fullstack/backend/internal/repository"
+
+	"github.com/rs/zerolog"
+)
+
+// positionRebalancer keeps the position keys used for manual ordering short.
+// Repeatedly moving todos into the same gap makes keys grow; once a user has
+// a key longer than maxKeyLength, all of their keys are rewritten.
+type positionRebalancer struct {
+	todoRepo     repository.TodoRepository
+	maxKeyLength int
+	interval     time.Duration
+	log          zerolog.Logger
+}
+
+// run rebalances once immediately and then every interval until ctx is done
+func (b *positionRebalancer) run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		b.rebalance(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *positionRebalancer) rebalance(ctx context.Context) {
+	users, err := b.todoRepo.RebalancePositions(ctx, b.maxKeyLength)
+	if err != nil {
+		if ctx.Err() == nil {
+			b.log.Error().Err(err).Msg("Failed to rebalance todo positions")
+		}
+		return
+	}
+
+	if users > 0 {
+		b.log.Info().Int("users", users).Msg("Rebalanced todo positions")
+	}
+}
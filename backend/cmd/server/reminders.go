@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/repository"
+
+	"github.com/rs/zerolog"
+)
+
+// reminderBatchSize caps how many reminders are claimed per query
+const reminderBatchSize = 100
+
+// reminderNotifier delivers a reminder that a todo is about to be due
+type reminderNotifier interface {
+	Remind(ctx context.Context, todo domain.Todo) error
+}
+
+// logReminderNotifier emits reminders as structured log events, for log
+// based alerting or until a push/email channel is plugged in.
+type logReminderNotifier struct {
+	log zerolog.Logger
+}
+
+func (n logReminderNotifier) Remind(ctx context.Context, todo domain.Todo) error {
+	n.log.Info().
+		Str("event", "todo.reminder").
+		Str("todo_id", todo.ID.String()).
+		Str("user_id", todo.UserID.String()).
+		Str("title", todo.Title).
+		Time("due_at", *todo.DueAt).
+		Msg("Todo is due soon")
+	return nil
+}
+
+// reminderScheduler periodically claims todos that become due within the
+// lead time and hands them to the notifier. Reminders are delivered at most
+// once: a todo is marked as reminded before the notifier is called.
+type reminderScheduler struct {
+	todoRepo repository.TodoRepository
+	notifier reminderNotifier
+	leadTime time.Duration
+	interval time.Duration
+	log      zerolog.Logger
+}
+
+// run checks once immediately and then every interval until ctx is done
+func (s *reminderScheduler) run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.remind(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *reminderScheduler) remind(ctx context.Context) {
+	for {
+		todos, err := s.todoRepo.ClaimDueReminders(ctx, time.Now().Add(s.leadTime), reminderBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.Error().Err(err).Msg("Failed to claim todo reminders")
+			}
+			return
+		}
+
+		for _, todo := range todos {
+			if err := s.notifier.Remind(ctx, todo); err != nil {
+				s.log.Error().Err(err).Str("todo_id", todo.ID.String()).Msg("Failed to send todo reminder")
+			}
+		}
+
+		if len(todos) < reminderBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"template-fullstack/backend/internal/pkg/storage"
+	"template-fullstack/backend/internal/repository"
+
+	"github.com/rs/zerolog"
+)
+
+// blobSweeperBatch is how many orphaned blobs are deleted per pass
+const blobSweeperBatch = 100
+
+// blobSweeper deletes the stored contents of attachments that no longer
+// exist, whether they were deleted themselves or along with their todo.
+type blobSweeper struct {
+	attachmentRepo repository.AttachmentRepository
+	store          storage.BlobStore
+	interval       time.Duration
+	log            zerolog.Logger
+}
+
+// run sweeps once immediately and then every interval until ctx is done
+func (s *blobSweeper) run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweep deletes orphaned blobs in batches until none are left. A blob that
+// fails to delete is skipped and stays queued for the next run.
+func (s *blobSweeper) sweep(ctx context.Context) {
+	deleted, failed := 0, 0
+	defer func() {
+		if deleted > 0 {
+			s.log.Info().Int("count", deleted).Msg("Deleted orphaned attachment blobs")
+		}
+	}()
+
+	for {
+		// Blobs that failed stay at the head of the queue, so page past them
+		keys, err := s.attachmentRepo.OrphanedBlobs(ctx, blobSweeperBatch, failed)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.log.Error().Err(err).Msg("Failed to get orphaned attachment blobs")
+			}
+			return
+		}
+
+		for _, key := range keys {
+			if err := s.store.Delete(ctx, key); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				s.log.Error().Err(err).Str("key", key).Msg("Failed to delete orphaned attachment blob")
+				failed++
+				continue
+			}
+			if err := s.attachmentRepo.ForgetOrphanedBlob(ctx, key); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				s.log.Error().Err(err).Str("key", key).Msg("Failed to forget orphaned attachment blob")
+				failed++
+				continue
+			}
+			deleted++
+		}
+
+		if len(keys) < blobSweeperBatch {
+			return
+		}
+	}
+}
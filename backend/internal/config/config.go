@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+	"net"
 	"os"
 	"strconv"
 
@@ -8,17 +10,30 @@ import (
 )
 
 type Config struct {
-	App      AppConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	CORS     CORSConfig
-	Log      LogConfig
+	App        AppConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	Password   PasswordConfig
+	Pagination PaginationConfig
+	Trash      TrashConfig
+	Bulk       BulkConfig
+	Reminder   ReminderConfig
+	Position   PositionConfig
+	Invitation InvitationConfig
+	Attachment AttachmentConfig
+	Storage    StorageConfig
+	CORS       CORSConfig
+	Log        LogConfig
 }
 
 type AppConfig struct {
 	Name string
 	Env  string
 	Port string
+	// TrustedProxies are the addresses or CIDR ranges of proxies whose
+	// X-Forwarded-For header is believed. With none, the client IP is the
+	// address of the connection.
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -31,8 +46,84 @@ type DatabaseConfig struct {
 }
 
 type JWTConfig struct {
-	Secret string
-	Expiry string
+	Secret              string
+	AccessExpiry        string
+	RefreshExpiry       string
+	RevocationCacheSize int
+}
+
+// PasswordConfig selects the algorithm used for new password hashes and its
+// cost parameters. Hashes produced with other algorithms or older parameters
+// are still accepted and upgraded on the next successful login.
+type PasswordConfig struct {
+	Algorithm     string
+	BcryptCost    int
+	Argon2Time    int
+	Argon2Memory  int // in KiB
+	Argon2Threads int
+}
+
+// PaginationConfig holds the key used to sign pagination cursors
+type PaginationConfig struct {
+	CursorSecret string
+}
+
+// TrashConfig controls how long deleted todos can be restored before they
+// are purged, and how often the purger runs
+type TrashConfig struct {
+	Retention     string
+	PurgeInterval string
+}
+
+// BulkConfig limits the number of operations in one bulk request
+type BulkConfig struct {
+	MaxOperations int
+}
+
+// ReminderConfig controls how long before a todo's due date its reminder is
+// sent, and how often the scheduler checks
+type ReminderConfig struct {
+	LeadTime string
+	Interval string
+}
+
+// PositionConfig controls when the position keys used for manual ordering
+// are considered too long, and how often they are checked and rebalanced
+type PositionConfig struct {
+	MaxKeyLength      int
+	RebalanceInterval string
+}
+
+// InvitationConfig controls how long a project invitation can be accepted
+type InvitationConfig struct {
+	TTL string
+}
+
+// AttachmentConfig limits the files that can be attached to todos and
+// controls their download links. URLSecret signs links served by the API;
+// SweepInterval is how often blobs of deleted attachments are removed.
+type AttachmentConfig struct {
+	MaxSize       int // in bytes
+	AllowedTypes  []string
+	URLTTL        string
+	URLSecret     string
+	SweepInterval string
+}
+
+// StorageConfig selects where attachment contents are kept: "local" keeps
+// them below LocalDir, "s3" in an S3-compatible bucket
+type StorageConfig struct {
+	Driver     string
+	LocalDir   string
+	S3Endpoint string
+	// S3PublicEndpoint is where browsers reach the store, if not at
+	// S3Endpoint; presigned URLs use it
+	S3PublicEndpoint string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKey      string
+	S3SecretKey      string
+	S3PathStyle      bool
 }
 
 type CORSConfig struct {
@@ -48,11 +139,13 @@ func Load() (*Config, error) {
 	// Load .env file if it exists
 	_ = godotenv.Load()
 
-	return &Config{
+	cfg := &Config{
 		App: AppConfig{
 			Name: getEnv("APP_NAME", "fullstack-template"),
 			Env:  getEnv("APP_ENV", "development"),
 			Port: getEnv("APP_PORT", "8080"),
+
+			TrustedProxies: getEnvSlice("TRUSTED_PROXIES", nil),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -63,8 +156,56 @@ func Load() (*Config, error) {
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "your-secret-key"),
-			Expiry: getEnv("JWT_EXPIRY", "24h"),
+			Secret:              getEnv("JWT_SECRET", "your-secret-key"),
+			AccessExpiry:        getEnv("JWT_ACCESS_EXPIRY", getEnv("JWT_EXPIRY", "15m")), // JWT_EXPIRY kept for older .env files
+			RefreshExpiry:       getEnv("JWT_REFRESH_EXPIRY", "720h"),
+			RevocationCacheSize: getEnvInt("JWT_REVOCATION_CACHE_SIZE", 10000),
+		},
+		Password: PasswordConfig{
+			Algorithm:     getEnv("PASSWORD_HASH_ALGORITHM", "bcrypt"),
+			BcryptCost:    getEnvInt("BCRYPT_COST", 12),
+			Argon2Time:    getEnvInt("ARGON2_TIME", 3),
+			Argon2Memory:  getEnvInt("ARGON2_MEMORY", 64*1024),
+			Argon2Threads: getEnvInt("ARGON2_THREADS", 2),
+		},
+		Pagination: PaginationConfig{
+			CursorSecret: getEnv("CURSOR_SECRET", getEnv("JWT_SECRET", "your-secret-key")),
+		},
+		Trash: TrashConfig{
+			Retention:     getEnv("TRASH_RETENTION", "720h"),
+			PurgeInterval: getEnv("TRASH_PURGE_INTERVAL", "1h"),
+		},
+		Bulk: BulkConfig{
+			MaxOperations: getEnvInt("BULK_MAX_OPERATIONS", 100),
+		},
+		Reminder: ReminderConfig{
+			LeadTime: getEnv("REMINDER_LEAD_TIME", "15m"),
+			Interval: getEnv("REMINDER_INTERVAL", "1m"),
+		},
+		Position: PositionConfig{
+			MaxKeyLength:      getEnvInt("POSITION_MAX_KEY_LENGTH", 32),
+			RebalanceInterval: getEnv("POSITION_REBALANCE_INTERVAL", "1h"),
+		},
+		Invitation: InvitationConfig{
+			TTL: getEnv("INVITATION_TTL", "168h"),
+		},
+		Attachment: AttachmentConfig{
+			MaxSize:       getEnvInt("ATTACHMENT_MAX_SIZE", 10<<20),
+			AllowedTypes:  getEnvSlice("ATTACHMENT_ALLOWED_TYPES", []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"}),
+			URLTTL:        getEnv("ATTACHMENT_URL_TTL", "15m"),
+			URLSecret:     getEnv("ATTACHMENT_URL_SECRET", getEnv("JWT_SECRET", "your-secret-key")),
+			SweepInterval: getEnv("ATTACHMENT_SWEEP_INTERVAL", "5m"),
+		},
+		Storage: StorageConfig{
+			Driver:           getEnv("STORAGE_DRIVER", "local"),
+			LocalDir:         getEnv("STORAGE_LOCAL_DIR", "./data/attachments"),
+			S3Endpoint:       getEnv("S3_ENDPOINT", ""),
+			S3PublicEndpoint: getEnv("S3_PUBLIC_ENDPOINT", ""),
+			S3Region:         getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:         getEnv("S3_BUCKET", ""),
+			S3AccessKey:      getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey:      getEnv("S3_SECRET_KEY", ""),
+			S3PathStyle:      getEnvBool("S3_PATH_STYLE", false),
 		},
 		CORS: CORSConfig{
 			Origins: getEnvSlice("CORS_ORIGINS", []string{"*"}),
@@ -73,7 +214,62 @@ func Load() (*Config, error) {
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
 		},
-	}, nil
+	}
+
+	if err := cfg.App.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Password.validate(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Storage.validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c AppConfig) validate() error {
+	for _, proxy := range c.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return fmt.Errorf("invalid TRUSTED_PROXIES entry %q (expected an IP address or CIDR range)", proxy)
+			}
+		}
+	}
+	return nil
+}
+
+func (c PasswordConfig) validate() error {
+	switch c.Algorithm {
+	case "bcrypt":
+		if c.BcryptCost < 4 || c.BcryptCost > 31 {
+			return fmt.Errorf("BCRYPT_COST must be between 4 and 31, got %d", c.BcryptCost)
+		}
+	case "argon2id":
+		if c.Argon2Time < 1 || c.Argon2Memory < 8*c.Argon2Threads || c.Argon2Threads < 1 || c.Argon2Threads > 255 {
+			return fmt.Errorf("invalid argon2id parameters: time=%d memory=%d threads=%d", c.Argon2Time, c.Argon2Memory, c.Argon2Threads)
+		}
+	default:
+		return fmt.Errorf("unsupported PASSWORD_HASH_ALGORITHM %q (expected bcrypt or argon2id)", c.Algorithm)
+	}
+	return nil
+}
+
+func (c StorageConfig) validate() error {
+	switch c.Driver {
+	case "local":
+		if c.LocalDir == "" {
+			return fmt.Errorf("STORAGE_LOCAL_DIR is required for the local storage driver")
+		}
+	case "s3":
+		if c.S3Endpoint == "" || c.S3Bucket == "" {
+			return fmt.Errorf("S3_ENDPOINT and S3_BUCKET are required for the s3 storage driver")
+		}
+	default:
+		return fmt.Errorf("unsupported STORAGE_DRIVER %q (expected local or s3)", c.Driver)
+	}
+	return nil
 }
 
 func getEnv(key, defaultValue string) string {
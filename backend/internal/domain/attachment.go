@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment is a file attached to a todo. Its contents live in blob
+// storage and are downloaded through URL, which expires at URLExpiresAt.
+type Attachment struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	TodoID uuid.UUID `json:"todo_id" db:"todo_id"`
+	// UserID is the uploader
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	Filename    string    `json:"filename" db:"filename"`
+	ContentType string    `json:"content_type" db:"content_type"`
+	Size        int64     `json:"size" db:"size"`
+	// Checksum is the hex encoded SHA-256 of the contents
+	Checksum     string    `json:"checksum" db:"checksum"`
+	StorageKey   string    `json:"-" db:"storage_key"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	URL          string    `json:"url" db:"-"`
+	URLExpiresAt time.Time `json:"url_expires_at" db:"-"`
+}
+
+// AttachmentUpload is a file received in a multipart upload
+type AttachmentUpload struct {
+	Filename string
+	Size     int64
+	Content  io.Reader
+}
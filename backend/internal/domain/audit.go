@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEntity is the kind of record an audit event is about
+type AuditEntity string
+
+const (
+	AuditEntityTodo AuditEntity = "todo"
+	AuditEntityUser AuditEntity = "user"
+)
+
+// AuditAction is the kind of change an audit event records
+type AuditAction string
+
+const (
+	AuditActionCreate  AuditAction = "create"
+	AuditActionUpdate  AuditAction = "update"
+	AuditActionDelete  AuditAction = "delete"
+	AuditActionRestore AuditAction = "restore"
+)
+
+// AuditEvent records one change to a todo or user, written in the same
+// transaction as the change. Before and After hold only the fields that
+// changed, in the entity's JSON representation: a create has no Before and a
+// delete no After.
+type AuditEvent struct {
+	ID uuid.UUID `json:"id" db:"id"`
+	// ActorID is who made the change, nil when nobody was signed in
+	ActorID    *uuid.UUID      `json:"actor_id" db:"actor_id"`
+	EntityType AuditEntity     `json:"entity_type" db:"entity_type" enums:"todo,user"`
+	EntityID   uuid.UUID       `json:"entity_id" db:"entity_id"`
+	Action     AuditAction     `json:"action" db:"action" enums:"create,update,delete,restore"`
+	Before     json.RawMessage `json:"before" db:"before" swaggertype:"object"`
+	After      json.RawMessage `json:"after" db:"after" swaggertype:"object"`
+	RequestID  string          `json:"request_id" db:"request_id"`
+	IP         string          `json:"ip" db:"ip"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
+// AuditFilter narrows an audit search. It is bound from the query string,
+// e.g. ?entity_type=todo&action=delete&from=2024-01-01T00:00:00Z.
+type AuditFilter struct {
+	EntityType AuditEntity `form:"entity_type" binding:"omitempty,oneof=todo user"`
+	EntityID   string      `form:"entity_id" binding:"omitempty,uuid"`
+	ActorID    string      `form:"actor_id" binding:"omitempty,uuid"`
+	Action     AuditAction `form:"action" binding:"omitempty,oneof=create update delete restore"`
+	From       *time.Time  `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         *time.Time  `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// Validate rejects an empty time range
+func (f AuditFilter) Validate() error {
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return NewValidationError("invalid date range", FieldError{
+			Field: "to",
+			Rule:  "gtfield",
+			Param: "from",
+		})
+	}
+	return nil
+}
+
+// RequestInfo describes the request a change is made in, for the audit log.
+// Middleware stores it in the request context.
+type RequestInfo struct {
+	// ActorID is the signed-in user, set once the request is authenticated
+	ActorID   *uuid.UUID
+	RequestID string
+	IP        string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a copy of ctx carrying info
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFromContext returns the RequestInfo stored in ctx, or the zero
+// value outside of a request
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Comment is a message in a todo's discussion thread. Deleted comments are
+// kept in the database but no longer returned.
+type Comment struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	TodoID uuid.UUID `json:"todo_id" db:"todo_id"`
+	// UserID is the author
+	UserID     uuid.UUID `json:"user_id" db:"user_id"`
+	AuthorName string    `json:"author_name" db:"author_name"`
+	// Body is Markdown, stored as written. Clients must sanitize the HTML
+	// they render from it.
+	Body string `json:"body" db:"body"`
+	// EditedAt is set once the author has changed the body
+	EditedAt  *time.Time `json:"edited_at" db:"edited_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+type CreateCommentRequest struct {
+	Body   string    `json:"body" binding:"required,max=10000"`
+	TodoID uuid.UUID `json:"-"`
+	UserID uuid.UUID `json:"-"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body" binding:"required,max=10000"`
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Error kinds. Every *Error wraps one of these, so callers can test the
+// category with errors.Is(err, domain.ErrNotFound) regardless of the entity.
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrValidation         = errors.New("validation failed")
+	ErrForbidden          = errors.New("forbidden")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Error is a domain error carrying the API error code and a client-safe
+// message. Repositories translate driver errors into these so handlers can
+// pick a response without knowing about pgx.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	// Details lists offending fields of a validation error. The HTTP layer
+	// fills in a localized Message for details that don't carry one.
+	Details []FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func NewNotFoundError(code, message string) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+func NewConflictError(code, message string) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: message}
+}
+
+func NewValidationError(message string, details ...FieldError) *Error {
+	return &Error{Kind: ErrValidation, Code: ErrCodeInvalidRequest, Message: message, Details: details}
+}
+
+func NewForbiddenError(message string) *Error {
+	return &Error{Kind: ErrForbidden, Code: ErrCodeForbidden, Message: message}
+}
+
+func NewUnauthorizedError(code, message string) *Error {
+	return &Error{Kind: ErrUnauthorized, Code: code, Message: message}
+}
+
+func NewPreconditionFailedError(message string) *Error {
+	return &Error{Kind: ErrPreconditionFailed, Code: ErrCodePreconditionFailed, Message: message}
+}
+
+// NewAttachmentTooLargeError reports an uploaded file over maxSize bytes
+func NewAttachmentTooLargeError(maxSize int64) *Error {
+	return NewValidationError("file is too large",
+		FieldError{Field: "file", Rule: "filesize", Param: strconv.FormatInt(maxSize, 10)})
+}
+
+// Specific errors shared across layers
+var (
+	ErrTodoNotFound          = NewNotFoundError(ErrCodeTodoNotFound, "Todo not found")
+	ErrUserNotFound          = NewNotFoundError(ErrCodeUserNotFound, "User not found")
+	ErrEmailExists           = NewConflictError(ErrCodeEmailExists, "Email is already registered")
+	ErrInvalidCredentials    = NewUnauthorizedError(ErrCodeInvalidCredentials, "Invalid email or password")
+	ErrInvalidRefreshToken   = NewUnauthorizedError(ErrCodeUnauthorized, "Invalid refresh token")
+	ErrRefreshTokenReused    = NewUnauthorizedError(ErrCodeUnauthorized, "Invalid refresh token")
+	ErrTodoVersionMismatch   = NewPreconditionFailedError("Todo has been modified since it was fetched")
+	ErrTodoParentTrashed     = NewConflictError(ErrCodeConflict, "The parent todo is in the trash; restore it first")
+	ErrTagNotFound           = NewNotFoundError(ErrCodeTagNotFound, "Tag not found")
+	ErrProjectNotFound       = NewNotFoundError(ErrCodeProjectNotFound, "Project not found")
+	ErrTagExists             = NewConflictError(ErrCodeTagExists, "A tag with this name already exists")
+	ErrBulkAborted           = NewConflictError(ErrCodeBulkAborted, "Not applied because another operation in the batch failed")
+	ErrTodoProjectOwner      = NewForbiddenError("Only the todo's owner can move it to another project")
+	ErrProjectReadOnly       = NewForbiddenError("You can only view this project")
+	ErrProjectNotManager     = NewForbiddenError("Only project owners can do this")
+	ErrProjectCreatorRole    = NewConflictError(ErrCodeConflict, "The project's creator must stay an owner")
+	ErrProjectMemberExists   = NewConflictError(ErrCodeProjectMemberExists, "The user is already a member of this project")
+	ErrProjectMemberNotFound = NewNotFoundError(ErrCodeProjectMemberNotFound, "Project member not found")
+	ErrInvitationNotFound    = NewNotFoundError(ErrCodeInvitationNotFound, "Invitation not found")
+	ErrCommentNotFound       = NewNotFoundError(ErrCodeCommentNotFound, "Comment not found")
+	ErrCommentNotAuthor      = NewForbiddenError("Only the author can change a comment")
+	ErrAttachmentNotFound    = NewNotFoundError(ErrCodeAttachmentNotFound, "Attachment not found")
+	ErrDownloadLinkInvalid   = NewForbiddenError("The download link is invalid or has expired")
+)
@@ -1,30 +1,120 @@
 package domain
 
 import (
+	"math"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Role is a user's authorization level
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
+// Priority is a todo's importance. The database enum orders the values from
+// low to high, so sorting by priority sorts by importance.
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityMedium Priority = "medium"
+	PriorityHigh   Priority = "high"
+)
+
+// Actor identifies who performs an operation, for authorization checks in services
+type Actor struct {
+	UserID uuid.UUID
+	Role   Role
+}
+
+// CanAccess reports whether the actor may act on a resource owned by ownerID.
+// Admins may act on any resource.
+func (a Actor) CanAccess(ownerID uuid.UUID) bool {
+	return a.Role == RoleAdmin || a.UserID == ownerID
+}
+
 // User represents a user in the system
 type User struct {
 	ID        uuid.UUID `json:"id" db:"id"`
 	Email     string    `json:"email" db:"email"`
 	Name      string    `json:"name" db:"name"`
+	Role      Role      `json:"role" db:"role"`
 	Password  string    `json:"-" db:"password_hash"` // Don't expose password in JSON
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	// TokenVersion is embedded in access tokens; bumping it invalidates them all
+	TokenVersion int `json:"-" db:"token_version"`
 }
 
 // Todo represents a todo item
 type Todo struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	Title       string    `json:"title" db:"title"`
-	Description string    `json:"description" db:"description"`
-	Completed   bool      `json:"completed" db:"completed"`
-	UserID      uuid.UUID `json:"user_id" db:"user_id"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          uuid.UUID  `json:"id" db:"id"`
+	Title       string     `json:"title" db:"title"`
+	Description string     `json:"description" db:"description"`
+	Completed   bool       `json:"completed" db:"completed"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	ProjectID   *uuid.UUID `json:"project_id" db:"project_id"`
+	// ParentID is set on subtasks
+	ParentID *uuid.UUID `json:"parent_id" db:"parent_id"`
+	Priority Priority   `json:"priority" db:"priority"`
+	DueAt    *time.Time `json:"due_at" db:"due_at"`
+	// Recurrence is an RFC 5545 RRULE, empty for one-off todos. Completing a
+	// recurring todo creates the next occurrence, which takes over the rule.
+	Recurrence string `json:"recurrence" db:"recurrence" example:"FREQ=WEEKLY;BYDAY=MO"`
+	// Timezone is the IANA time zone Recurrence is evaluated in, so that
+	// occurrences keep the due date's local time and weekday there
+	Timezone string `json:"timezone" db:"timezone" example:"Europe/Berlin"`
+	// CompletedAt is set when the todo is marked completed and cleared when
+	// it is reopened
+	CompletedAt *time.Time `json:"completed_at" db:"completed_at"`
+	// Position orders the owner's todos when sorting by position. It is an
+	// opaque key that changes when the todo is moved.
+	Position string `json:"position" db:"position"`
+	// Version is incremented on every write and served as the ETag
+	Version   int       `json:"version" db:"version"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	// DeletedAt is set while the todo is in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	// Tags are ordered by name
+	Tags []Tag `json:"tags"`
+	// Progress counts the todo's direct subtasks
+	Progress TodoProgress `json:"progress"`
+}
+
+// MaxTodoDepth is how deeply subtasks may nest; a top-level todo has depth 1
+const MaxTodoDepth = 5
+
+// TodoProgress reports how many of a todo's subtasks are completed
+type TodoProgress struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+// TodoChange is a todo as it was before and after a write to another todo
+// cascaded to it, such as completing or trashing its parent
+type TodoChange struct {
+	Before Todo
+	After  Todo
+}
+
+// RefreshToken is a single-use, opaque refresh token. Only the SHA-256 hash of
+// the token is stored; every rotation stays in the same family so reuse of an
+// already rotated token can revoke the whole chain.
+type RefreshToken struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     uuid.UUID  `json:"user_id" db:"user_id"`
+	FamilyID   uuid.UUID  `json:"family_id" db:"family_id"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	ReplacedBy *uuid.UUID `json:"replaced_by,omitempty" db:"replaced_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
 }
 
 // DTOs for requests and responses
@@ -40,26 +130,118 @@ type UpdateUserRequest struct {
 	Email string `json:"email,omitempty" binding:"omitempty,email"`
 }
 
+type UpdateRoleRequest struct {
+	Role Role `json:"role" binding:"required,oneof=user admin"`
+}
+
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	User             User      `json:"user"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 type CreateTodoRequest struct {
-	Title       string    `json:"title" binding:"required,min=1"`
-	Description string    `json:"description"`
-	UserID      uuid.UUID `json:"user_id"`
+	Title       string      `json:"title" binding:"required,min=1,max=255"`
+	Description string      `json:"description"`
+	Priority    Priority    `json:"priority" binding:"omitempty,oneof=low medium high" enums:"low,medium,high" default:"medium"`
+	DueAt       *time.Time  `json:"due_at"`
+	Recurrence  string      `json:"recurrence" example:"FREQ=WEEKLY;BYDAY=MO"`
+	Timezone    string      `json:"timezone" example:"Europe/Berlin" default:"UTC"`
+	TagIDs      []uuid.UUID `json:"tag_ids"`
+	ProjectID   *uuid.UUID  `json:"project_id"`
+	ParentID    *uuid.UUID  `json:"parent_id"`
+	UserID      uuid.UUID   `json:"user_id"`
 }
 
+// UpdateTodoRequest is a JSON Merge Patch of a todo: absent fields are left
+// untouched and null resets a field to its empty value (medium for priority).
+// Title cannot be null; a null Timezone is UTC. TagIDs replaces the todo's
+// whole tag set. Clearing Recurrence while completing a todo stops the
+// series instead of creating the next occurrence.
 type UpdateTodoRequest struct {
-	Title       string `json:"title,omitempty" binding:"omitempty,min=1"`
-	Description string `json:"description,omitempty"`
-	Completed   *bool  `json:"completed,omitempty"`
+	Title       Patch[string]      `json:"title" swaggertype:"string"`
+	Description Patch[string]      `json:"description" swaggertype:"string"`
+	Completed   Patch[bool]        `json:"completed" swaggertype:"boolean"`
+	Priority    Patch[Priority]    `json:"priority" swaggertype:"string" enums:"low,medium,high"`
+	DueAt       Patch[time.Time]   `json:"due_at" swaggertype:"string" format:"date-time"`
+	Recurrence  Patch[string]      `json:"recurrence" swaggertype:"string"`
+	Timezone    Patch[string]      `json:"timezone" swaggertype:"string"`
+	TagIDs      Patch[[]uuid.UUID] `json:"tag_ids" swaggertype:"array,string"`
+	ProjectID   Patch[uuid.UUID]   `json:"project_id" swaggertype:"string" format:"uuid"`
+	ParentID    Patch[uuid.UUID]   `json:"parent_id" swaggertype:"string" format:"uuid"`
+}
+
+// MoveTodoRequest places a todo in position order. Before is the todo that
+// should end up immediately before it and After the one immediately after
+// it; at least one of them is required.
+type MoveTodoRequest struct {
+	Before *uuid.UUID `json:"before"`
+	After  *uuid.UUID `json:"after"`
+}
+
+// ReplaceTodoRequest is the full representation of a todo accepted by PUT
+type ReplaceTodoRequest struct {
+	Title       string      `json:"title" binding:"required,min=1,max=255"`
+	Description string      `json:"description"`
+	Completed   bool        `json:"completed"`
+	Priority    Priority    `json:"priority" binding:"omitempty,oneof=low medium high" enums:"low,medium,high" default:"medium"`
+	DueAt       *time.Time  `json:"due_at"`
+	Recurrence  string      `json:"recurrence"`
+	Timezone    string      `json:"timezone"`
+	TagIDs      []uuid.UUID `json:"tag_ids"`
+	ProjectID   *uuid.UUID  `json:"project_id"`
+	ParentID    *uuid.UUID  `json:"parent_id"`
+}
+
+// ToUpdate expresses the replacement as a patch that sets every field
+func (r ReplaceTodoRequest) ToUpdate() UpdateTodoRequest {
+	update := UpdateTodoRequest{
+		Title:       PatchValue(r.Title),
+		Description: PatchValue(r.Description),
+		Completed:   PatchValue(r.Completed),
+		Priority:    PatchValue(r.Priority),
+		DueAt:       PatchNull[time.Time](),
+		Recurrence:  PatchValue(r.Recurrence),
+		Timezone:    PatchValue(r.Timezone),
+		TagIDs:      PatchValue(r.TagIDs),
+		ProjectID:   PatchNull[uuid.UUID](),
+		ParentID:    PatchNull[uuid.UUID](),
+	}
+	if r.Priority == "" {
+		update.Priority = PatchNull[Priority]()
+	}
+	if r.DueAt != nil {
+		update.DueAt = PatchValue(*r.DueAt)
+	}
+	if r.ProjectID != nil {
+		update.ProjectID = PatchValue(*r.ProjectID)
+	}
+	if r.ParentID != nil {
+		update.ParentID = PatchValue(*r.ParentID)
+	}
+	return update
+}
+
+// OccurrenceQuery asks for the upcoming occurrences of a recurring todo up
+// to and including Until
+type OccurrenceQuery struct {
+	Until time.Time `form:"until" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit int       `form:"limit,default=10" binding:"min=1,max=100"`
 }
 
 // API Response wrappers
@@ -70,7 +252,16 @@ type APIResponse struct {
 }
 
 type APIError struct {
-	Code    string `json:"code"`
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+// FieldError describes why a single request field failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
 	Message string `json:"message"`
 }
 
@@ -92,16 +283,73 @@ type Pagination struct {
 	TotalPages int   `json:"total_pages"`
 }
 
+// NewPaginatedResponse wraps one page of data out of total items
+func NewPaginatedResponse(data interface{}, total int64, query PaginationQuery) *PaginatedResponse {
+	return &PaginatedResponse{
+		Data: data,
+		Pagination: Pagination{
+			Page:       query.Page,
+			PageSize:   query.PageSize,
+			Total:      total,
+			TotalPages: int(math.Ceil(float64(total) / float64(query.PageSize))),
+		},
+	}
+}
+
+// CursorQuery requests a page of a keyset-paginated listing. Cursor is empty
+// for the first page and otherwise one of the cursors of a previous response.
+type CursorQuery struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit,default=10" binding:"min=1,max=100"`
+}
+
+// KeysetPosition is a row's position in (created_at, id) order
+type KeysetPosition struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// KeysetPage is a decoded CursorQuery: up to Limit rows after Position, or
+// before it when Backward is set. A nil Position means the first page.
+type KeysetPage struct {
+	Position *KeysetPosition
+	Backward bool
+	Limit    int
+}
+
+type CursorPaginatedResponse struct {
+	Data       interface{}      `json:"data"`
+	Pagination CursorPagination `json:"pagination"`
+}
+
+// CursorPagination links to the neighbouring pages. A cursor is omitted when
+// there is no page in that direction.
+type CursorPagination struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
 // Constants for error codes
 const (
-	ErrCodeInvalidRequest     = "INVALID_REQUEST"
-	ErrCodeUnauthorized       = "UNAUTHORIZED"
-	ErrCodeForbidden          = "FORBIDDEN"
-	ErrCodeNotFound           = "NOT_FOUND"
-	ErrCodeConflict           = "CONFLICT"
-	ErrCodeInternalError      = "INTERNAL_ERROR"
-	ErrCodeTodoNotFound       = "TODO_NOT_FOUND"
-	ErrCodeUserNotFound       = "USER_NOT_FOUND"
-	ErrCodeInvalidCredentials = "INVALID_CREDENTIALS"
-	ErrCodeEmailExists        = "EMAIL_EXISTS"
+	ErrCodeInvalidRequest        = "INVALID_REQUEST"
+	ErrCodeUnauthorized          = "UNAUTHORIZED"
+	ErrCodeForbidden             = "FORBIDDEN"
+	ErrCodeNotFound              = "NOT_FOUND"
+	ErrCodeConflict              = "CONFLICT"
+	ErrCodePreconditionFailed    = "PRECONDITION_FAILED"
+	ErrCodeBulkAborted           = "BULK_ABORTED"
+	ErrCodeInternalError         = "INTERNAL_ERROR"
+	ErrCodeTodoNotFound          = "TODO_NOT_FOUND"
+	ErrCodeUserNotFound          = "USER_NOT_FOUND"
+	ErrCodeInvalidCredentials    = "INVALID_CREDENTIALS"
+	ErrCodeEmailExists           = "EMAIL_EXISTS"
+	ErrCodeTagNotFound           = "TAG_NOT_FOUND"
+	ErrCodeTagExists             = "TAG_EXISTS"
+	ErrCodeProjectNotFound       = "PROJECT_NOT_FOUND"
+	ErrCodeProjectMemberNotFound = "PROJECT_MEMBER_NOT_FOUND"
+	ErrCodeProjectMemberExists   = "PROJECT_MEMBER_EXISTS"
+	ErrCodeInvitationNotFound    = "INVITATION_NOT_FOUND"
+	ErrCodeCommentNotFound       = "COMMENT_NOT_FOUND"
+	ErrCodeAttachmentNotFound    = "ATTACHMENT_NOT_FOUND"
 )
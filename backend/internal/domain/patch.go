@@ -0,0 +1,42 @@
+package domain
+
+import "encoding/json"
+
+// Patch is a field of a JSON Merge Patch (RFC 7396) document. A member that
+// is absent leaves the stored value untouched, null clears it and any other
+// value replaces it.
+type Patch[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// PatchValue returns a Patch that sets v
+func PatchValue[T any](v T) Patch[T] {
+	return Patch[T]{Set: true, Value: v}
+}
+
+// PatchNull returns a Patch that clears the field
+func PatchNull[T any]() Patch[T] {
+	return Patch[T]{Set: true, Null: true}
+}
+
+func (p *Patch[T]) UnmarshalJSON(data []byte) error {
+	// Only called when the member is present
+	p.Set = true
+	if string(data) == "null" {
+		p.Null = true
+		var zero T
+		p.Value = zero
+		return nil
+	}
+	p.Null = false
+	return json.Unmarshal(data, &p.Value)
+}
+
+func (p Patch[T]) MarshalJSON() ([]byte, error) {
+	if !p.Set || p.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(p.Value)
+}
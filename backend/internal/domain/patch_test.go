@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPatchUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want Patch[string]
+	}{
+		{name: "absent leaves the field untouched", body: `{}`, want: Patch[string]{}},
+		{name: "null clears the field", body: `{"title": null}`, want: Patch[string]{Set: true, Null: true}},
+		{name: "value replaces the field", body: `{"title": "Ship it"}`, want: PatchValue("Ship it")},
+		{name: "empty string is a value", body: `{"title": ""}`, want: PatchValue("")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req struct {
+				Title Patch[string] `json:"title"`
+			}
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if req.Title != tt.want {
+				t.Fatalf("got %+v, want %+v", req.Title, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchUnmarshalJSONTypeMismatch(t *testing.T) {
+	var req struct {
+		Completed Patch[bool] `json:"completed"`
+	}
+	if err := json.Unmarshal([]byte(`{"completed": "yes"}`), &req); err == nil {
+		t.Fatal("a string was accepted for a boolean field")
+	}
+}
+
+func TestPatchMarshalJSON(t *testing.T) {
+	tests := []struct {
+		patch Patch[int]
+		want  string
+	}{
+		{patch: Patch[int]{}, want: "null"},
+		{patch: PatchNull[int](), want: "null"},
+		{patch: PatchValue(0), want: "0"},
+		{patch: PatchValue(42), want: "42"},
+	}
+
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.patch)
+		if err != nil {
+			t.Fatalf("Marshal(%+v): %v", tt.patch, err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("Marshal(%+v) = %s, want %s", tt.patch, data, tt.want)
+		}
+	}
+}
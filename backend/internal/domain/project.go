@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Project is a named collection of a user's todos. A todo belongs to at most
+// one project. Projects can be shared with other users, who then act on the
+// project's todos according to their ProjectRole; the todos stay owned by
+// the project's creator (UserID).
+type Project struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	// Position orders the owner's projects, starting at 0
+	Position int `json:"position" db:"position"`
+	// ArchivedAt is set while the project is archived. Archived projects are
+	// hidden from the project list but keep their todos.
+	ArchivedAt *time.Time `json:"archived_at" db:"archived_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+	// Role is the requesting user's role in the project; empty for admins
+	// looking at a project they are not a member of
+	Role ProjectRole `json:"role,omitempty" db:"role"`
+}
+
+type CreateProjectRequest struct {
+	Name        string    `json:"name" binding:"required,min=1,max=100"`
+	Description string    `json:"description"`
+	UserID      uuid.UUID `json:"-"`
+}
+
+// UpdateProjectRequest is a JSON Merge Patch of a project. Setting Position
+// moves the project and shifts the ones in between; Archived archives or
+// unarchives it. Name cannot be null.
+type UpdateProjectRequest struct {
+	Name        Patch[string] `json:"name" swaggertype:"string"`
+	Description Patch[string] `json:"description" swaggertype:"string"`
+	Position    Patch[int]    `json:"position" swaggertype:"integer"`
+	Archived    Patch[bool]   `json:"archived" swaggertype:"boolean"`
+}
+
+// ProjectListQuery selects active (the default) or archived projects
+type ProjectListQuery struct {
+	Archived bool `form:"archived"`
+}
+
+// ProjectRole is what a project member may do
+type ProjectRole string
+
+const (
+	// ProjectRoleViewer can see the project and its todos
+	ProjectRoleViewer ProjectRole = "viewer"
+	// ProjectRoleEditor can also create, change and delete its todos
+	ProjectRoleEditor ProjectRole = "editor"
+	// ProjectRoleOwner can also change the project and manage its members
+	ProjectRoleOwner ProjectRole = "owner"
+)
+
+// CanEdit reports whether the role may change the project's todos
+func (r ProjectRole) CanEdit() bool {
+	return r == ProjectRoleEditor || r == ProjectRoleOwner
+}
+
+// CanManage reports whether the role may change the project and its members
+func (r ProjectRole) CanManage() bool {
+	return r == ProjectRoleOwner
+}
+
+// ProjectMember is a user with access to a project
+type ProjectMember struct {
+	ProjectID uuid.UUID   `json:"project_id" db:"project_id"`
+	UserID    uuid.UUID   `json:"user_id" db:"user_id"`
+	Email     string      `json:"email" db:"email"`
+	Name      string      `json:"name" db:"name"`
+	Role      ProjectRole `json:"role" db:"role"`
+	CreatedAt time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+type UpdateProjectMemberRequest struct {
+	Role ProjectRole `json:"role" binding:"required,oneof=viewer editor owner" enums:"viewer,editor,owner"`
+}
+
+// ProjectInvitation is a pending invitation to join a project. It can only
+// be accepted by the user registered with Email.
+type ProjectInvitation struct {
+	ID          uuid.UUID   `json:"id" db:"id"`
+	ProjectID   uuid.UUID   `json:"project_id" db:"project_id"`
+	ProjectName string      `json:"project_name" db:"project_name"`
+	Email       string      `json:"email" db:"email"`
+	Role        ProjectRole `json:"role" db:"role"`
+	InvitedBy   *uuid.UUID  `json:"invited_by" db:"invited_by"`
+	ExpiresAt   time.Time   `json:"expires_at" db:"expires_at"`
+	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
+}
+
+type CreateProjectInvitationRequest struct {
+	Email string      `json:"email" binding:"required,email"`
+	Role  ProjectRole `json:"role" binding:"required,oneof=viewer editor owner" enums:"viewer,editor,owner"`
+}
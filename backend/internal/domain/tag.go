@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tag is a user-defined label that groups todos. Names are unique per user,
+// ignoring case.
+type Tag struct {
+	ID     uuid.UUID `json:"id" db:"id"`
+	UserID uuid.UUID `json:"user_id" db:"user_id"`
+	Name   string    `json:"name" db:"name"`
+	// Color is a CSS hex color such as "#ff8800", or empty
+	Color     string    `json:"color" db:"color"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateTagRequest struct {
+	Name   string    `json:"name" binding:"required,min=1,max=50"`
+	Color  string    `json:"color" example:"#ff8800"`
+	UserID uuid.UUID `json:"-"`
+}
+
+// UpdateTagRequest is a JSON Merge Patch of a tag. Name cannot be null; a
+// null color removes it.
+type UpdateTagRequest struct {
+	Name  Patch[string] `json:"name" swaggertype:"string"`
+	Color Patch[string] `json:"color" swaggertype:"string"`
+}
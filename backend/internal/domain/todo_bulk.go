@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// Bulk operation kinds
+const (
+	BulkOpCreate = "create"
+	BulkOpUpdate = "update"
+	BulkOpDelete = "delete"
+)
+
+// Bulk execution modes. In atomic mode the first failing operation rolls back
+// the whole batch; in best-effort mode every operation is applied on its own.
+const (
+	BulkModeAtomic     = "atomic"
+	BulkModeBestEffort = "best_effort"
+)
+
+type BulkTodoRequest struct {
+	Mode       string              `json:"mode" binding:"omitempty,oneof=atomic best_effort" example:"atomic"`
+	Operations []BulkTodoOperation `json:"operations" binding:"required,min=1,dive"`
+}
+
+// BulkTodoOperation is one item of a bulk request. Data is a
+// CreateTodoRequest for "create" and a merge patch (UpdateTodoRequest) for
+// "update". Version optionally makes an update or delete conditional, like
+// If-Match does for single requests.
+type BulkTodoOperation struct {
+	Op      string          `json:"op" binding:"required,oneof=create update delete"`
+	ID      uuid.UUID       `json:"id"`
+	Version int             `json:"version" binding:"min=0"`
+	Data    json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+type BulkTodoResponse struct {
+	Mode string `json:"mode"`
+	// Committed is false when an atomic batch was rolled back. FailedIndex
+	// then points at the operation that caused it.
+	Committed   bool             `json:"committed"`
+	FailedIndex *int             `json:"failed_index,omitempty"`
+	Results     []BulkTodoResult `json:"results"`
+}
+
+// BulkTodoResult is the outcome of one operation, in request order
+type BulkTodoResult struct {
+	Index   int       `json:"index"`
+	Op      string    `json:"op"`
+	Success bool      `json:"success"`
+	Todo    *Todo     `json:"todo,omitempty"`
+	Error   *APIError `json:"error,omitempty"`
+	// Err is the service error behind Error; the HTTP layer renders it
+	Err error `json:"-"`
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TodoFilter narrows and orders a todo listing. It is bound from the query
+// string, e.g. ?completed=true&q=docker&sort=-updated_at,title&tag=work.
+type TodoFilter struct {
+	Completed *bool `form:"completed"`
+	// Query is a full-text search over title and description. Without an
+	// explicit Sort, results are ordered by relevance.
+	Query         string     `form:"q" binding:"max=200"`
+	Sort          string     `form:"sort"`
+	CreatedAfter  *time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore *time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	Priority      Priority   `form:"priority" binding:"omitempty,oneof=low medium high"`
+	DueAfter      *time.Time `form:"due_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	DueBefore     *time.Time `form:"due_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	// Overdue selects todos that are past due and not completed (true) or
+	// everything else (false)
+	Overdue *bool `form:"overdue"`
+	// Tags selects todos by tag name, ignoring case. With TagMode "all" (the
+	// default) a todo needs every listed tag, with "any" at least one.
+	Tags    []string `form:"tag" binding:"max=10,dive,min=1,max=50"`
+	TagMode string   `form:"tag_mode" binding:"omitempty,oneof=all any"`
+	// ProjectID restricts the listing to one project. It is set by the
+	// project todos endpoint rather than bound from the query.
+	ProjectID *uuid.UUID `form:"-"`
+	// ParentID restricts the listing to the direct subtasks of a todo. It is
+	// set by the subtasks endpoint.
+	ParentID *uuid.UUID `form:"-"`
+	// Trashed lists the trash instead of live todos. It is set by the trash
+	// endpoint rather than bound from the query.
+	Trashed bool `form:"-"`
+}
+
+// Tag filter modes
+const (
+	TagModeAll = "all"
+	TagModeAny = "any"
+)
+
+// TagNames returns Tags lowercased, trimmed and without duplicates
+func (f TodoFilter) TagNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range f.Tags {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// SortField is one key of a sort order
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// TodoSortFields are the fields a todo listing can be sorted by
+var TodoSortFields = []string{"created_at", "updated_at", "title", "completed", "priority", "due_at", "position"}
+
+// SortFields parses Sort, a comma-separated list of fields where a leading
+// "-" means descending. Fields outside TodoSortFields are rejected.
+func (f TodoFilter) SortFields() ([]SortField, error) {
+	if strings.TrimSpace(f.Sort) == "" {
+		return nil, nil
+	}
+
+	var fields []SortField
+	seen := make(map[string]bool)
+	for _, key := range strings.Split(f.Sort, ",") {
+		key = strings.TrimSpace(key)
+		field := SortField{Field: strings.TrimPrefix(key, "-"), Desc: strings.HasPrefix(key, "-")}
+
+		if !isTodoSortField(field.Field) || seen[field.Field] {
+			return nil, NewValidationError("invalid sort", FieldError{
+				Field: "sort",
+				Rule:  "oneof",
+				Param: strings.Join(TodoSortFields, " "),
+			})
+		}
+
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// Validate reports invalid sort keys and empty date ranges
+func (f TodoFilter) Validate() error {
+	if _, err := f.SortFields(); err != nil {
+		return err
+	}
+
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedAfter.Before(*f.CreatedBefore) {
+		return NewValidationError("invalid date range", FieldError{
+			Field: "created_before",
+			Rule:  "gtfield",
+			Param: "created_after",
+		})
+	}
+
+	if f.DueAfter != nil && f.DueBefore != nil && !f.DueAfter.Before(*f.DueBefore) {
+		return NewValidationError("invalid date range", FieldError{
+			Field: "due_before",
+			Rule:  "gtfield",
+			Param: "due_after",
+		})
+	}
+
+	return nil
+}
+
+func isTodoSortField(field string) bool {
+	for _, allowed := range TodoSortFields {
+		if field == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestTodoFilterSortFields(t *testing.T) {
+	tests := []struct {
+		sort    string
+		want    []SortField
+		wantErr bool
+	}{
+		{sort: "", want: nil},
+		{sort: "  ", want: nil},
+		{sort: "title", want: []SortField{{Field: "title"}}},
+		{sort: "-updated_at,title", want: []SortField{{Field: "updated_at", Desc: true}, {Field: "title"}}},
+		{sort: " -due_at , priority ", want: []SortField{{Field: "due_at", Desc: true}, {Field: "priority"}}},
+		{sort: "password", wantErr: true},
+		{sort: "title,-title", wantErr: true},
+		{sort: "title,", wantErr: true},
+		{sort: "--title", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			got, err := TodoFilter{Sort: tt.sort}.SortFields()
+			if tt.wantErr {
+				if !errors.Is(err, ErrValidation) {
+					t.Fatalf("got error %v, want validation error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got error %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTodoFilterValidate(t *testing.T) {
+	earlier := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		filter    TodoFilter
+		wantField string
+	}{
+		{name: "empty filter"},
+		{name: "valid ranges", filter: TodoFilter{CreatedAfter: &earlier, CreatedBefore: &later, DueAfter: &earlier, DueBefore: &later}},
+		{name: "unknown sort", filter: TodoFilter{Sort: "secret"}, wantField: "sort"},
+		{name: "empty created range", filter: TodoFilter{CreatedAfter: &later, CreatedBefore: &earlier}, wantField: "created_before"},
+		{name: "zero-length due range", filter: TodoFilter{DueAfter: &earlier, DueBefore: &earlier}, wantField: "due_before"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("got error %v, want none", err)
+				}
+				return
+			}
+
+			var domainErr *Error
+			if !errors.As(err, &domainErr) || len(domainErr.Details) != 1 || domainErr.Details[0].Field != tt.wantField {
+				t.Fatalf("got error %v, want a validation error on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestTodoFilterTagNames(t *testing.T) {
+	tests := []struct {
+		tags []string
+		want []string
+	}{
+		{tags: nil, want: nil},
+		{tags: []string{"Work"}, want: []string{"work"}},
+		{tags: []string{" work ", "WORK", "home", "Home"}, want: []string{"work", "home"}},
+		{tags: []string{"  ", "work"}, want: []string{"work"}},
+	}
+
+	for _, tt := range tests {
+		if got := (TodoFilter{Tags: tt.tags}).TagNames(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TagNames(%q) = %q, want %q", tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestTodoFilterTagBinding(t *testing.T) {
+	// Gin validates query parameters against the binding tags
+	validate := validator.New()
+	validate.SetTagName("binding")
+
+	tests := []struct {
+		name    string
+		filter  TodoFilter
+		wantErr bool
+	}{
+		{name: "no tags", filter: TodoFilter{}},
+		{name: "all mode", filter: TodoFilter{Tags: []string{"work", "home"}, TagMode: "all"}},
+		{name: "any mode", filter: TodoFilter{Tags: []string{"work"}, TagMode: "any"}},
+		{name: "unknown mode", filter: TodoFilter{Tags: []string{"work"}, TagMode: "none"}, wantErr: true},
+		{name: "empty tag", filter: TodoFilter{Tags: []string{""}}, wantErr: true},
+		{name: "tag too long", filter: TodoFilter{Tags: []string{strings.Repeat("x", 51)}}, wantErr: true},
+		{name: "too many tags", filter: TodoFilter{Tags: strings.Split("a,b,c,d,e,f,g,h,i,j,k", ",")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
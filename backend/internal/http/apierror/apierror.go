@@ -0,0 +1,77 @@
+// Package apierror is the single place where service and repository errors
+// are turned into HTTP statuses and domain.APIError payloads.
+package apierror
+
+import (
+	"errors"
+	"net/http"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// From returns the HTTP status and API error for err. Errors that are not
+// domain errors are reported as a generic internal error so that driver
+// messages never reach the client.
+func From(err error) (int, *domain.APIError) {
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) {
+		return http.StatusInternalServerError, &domain.APIError{
+			Code:    domain.ErrCodeInternalError,
+			Message: "Internal server error",
+		}
+	}
+
+	return statusFor(domainErr.Kind), &domain.APIError{
+		Code:    domainErr.Code,
+		Message: domainErr.Message,
+	}
+}
+
+// Describe is like From, but localizes validation details for the request
+// and attaches internal errors to the gin context. Use it to embed errors in
+// a larger response; Respond is the shorthand for error-only responses.
+func Describe(c *gin.Context, err error) (int, *domain.APIError) {
+	status, apiErr := From(err)
+	if status == http.StatusInternalServerError {
+		_ = c.Error(err)
+	}
+
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) && len(domainErr.Details) > 0 {
+		apiErr = localizeDetails(c, domainErr)
+	}
+
+	return status, apiErr
+}
+
+func statusFor(kind error) int {
+	switch kind {
+	case domain.ErrNotFound:
+		return http.StatusNotFound
+	case domain.ErrConflict:
+		return http.StatusConflict
+	case domain.ErrValidation:
+		return http.StatusBadRequest
+	case domain.ErrForbidden:
+		return http.StatusForbidden
+	case domain.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case domain.ErrPreconditionFailed:
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Respond writes err as an APIResponse. Internal errors are also attached to
+// the gin context so ErrorHandlingMiddleware logs them.
+func Respond(c *gin.Context, err error) {
+	status, apiErr := Describe(c, err)
+
+	c.JSON(status, domain.APIResponse{
+		Success: false,
+		Error:   apiErr,
+	})
+}
@@ -0,0 +1,79 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFrom(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "not found", err: domain.ErrTodoNotFound, wantStatus: http.StatusNotFound, wantCode: domain.ErrCodeTodoNotFound},
+		{name: "conflict", err: domain.ErrEmailExists, wantStatus: http.StatusConflict, wantCode: domain.ErrCodeEmailExists},
+		{name: "validation", err: domain.NewValidationError("bad title"), wantStatus: http.StatusBadRequest, wantCode: domain.ErrCodeInvalidRequest},
+		{name: "forbidden", err: domain.NewForbiddenError("no access"), wantStatus: http.StatusForbidden, wantCode: domain.ErrCodeForbidden},
+		{name: "unauthorized", err: domain.ErrInvalidCredentials, wantStatus: http.StatusUnauthorized, wantCode: domain.ErrCodeInvalidCredentials},
+		{name: "precondition failed", err: domain.NewPreconditionFailedError("stale"), wantStatus: http.StatusPreconditionFailed, wantCode: domain.ErrCodePreconditionFailed},
+		{name: "wrapped", err: fmt.Errorf("load todo: %w", domain.ErrTodoNotFound), wantStatus: http.StatusNotFound, wantCode: domain.ErrCodeTodoNotFound},
+		{name: "unknown kind", err: &domain.Error{Kind: errors.New("other"), Code: "OTHER"}, wantStatus: http.StatusInternalServerError, wantCode: "OTHER"},
+		{name: "driver error", err: errors.New("pq: connection refused"), wantStatus: http.StatusInternalServerError, wantCode: domain.ErrCodeInternalError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, apiErr := From(tt.err)
+			if status != tt.wantStatus || apiErr.Code != tt.wantCode {
+				t.Fatalf("got %d %s, want %d %s", status, apiErr.Code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRespond(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	respond := func(err error) (*httptest.ResponseRecorder, *gin.Context) {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		Respond(c, err)
+		return rec, c
+	}
+
+	// Driver messages stay out of the response but reach the error log
+	rec, c := respond(errors.New("pq: connection refused"))
+	var resp domain.APIResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if rec.Code != http.StatusInternalServerError || resp.Success || resp.Error.Message != "Internal server error" {
+		t.Fatalf("got %d with %+v, want a generic internal error", rec.Code, resp.Error)
+	}
+	if len(c.Errors) != 1 {
+		t.Fatalf("got %d errors on the context, want the internal error", len(c.Errors))
+	}
+
+	// Domain errors are the client's concern and are not logged
+	rec, c = respond(domain.ErrTodoNotFound)
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if rec.Code != http.StatusNotFound || resp.Error.Message != domain.ErrTodoNotFound.Message {
+		t.Fatalf("got %d with %+v, want %q", rec.Code, resp.Error, domain.ErrTodoNotFound.Message)
+	}
+	if len(c.Errors) != 0 {
+		t.Fatalf("got errors %v on the context, want none", c.Errors)
+	}
+}
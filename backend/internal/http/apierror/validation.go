@@ -0,0 +1,230 @@
+package apierror
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/text/language"
+)
+
+// Languages we ship translations for, matching the frontend locales. The
+// first entry is the fallback.
+var supportedLanguages = language.NewMatcher([]language.Tag{
+	language.English,
+	language.Vietnamese,
+})
+
+type messageCatalog struct {
+	summary   string
+	malformed string
+	rules     map[string]string
+	// Rules whose wording differs for strings (length) and numbers (value)
+	stringRules map[string]string
+	// ...and for collections (number of items)
+	itemRules map[string]string
+	fallback  string
+}
+
+// Placeholders: {field} is the JSON field name and {param} the rule parameter
+var catalogs = map[string]messageCatalog{
+	"en": {
+		summary:   "Validation failed",
+		malformed: "Request body is malformed",
+		rules: map[string]string{
+			"required":         "{field} is required",
+			"email":            "{field} must be a valid email address",
+			"uuid":             "{field} must be a valid UUID",
+			"oneof":            "{field} must be one of: {param}",
+			"min":              "{field} must be at least {param}",
+			"max":              "{field} must be at most {param}",
+			"gte":              "{field} must be at least {param}",
+			"lte":              "{field} must be at most {param}",
+			"len":              "{field} must be exactly {param}",
+			"gtfield":          "{field} must be after {param}",
+			"cursor":           "{field} is not a valid pagination cursor",
+			"json":             "{field} must be a valid JSON object",
+			"excluded_with":    "{field} cannot be combined with {param}",
+			"exists":           "{field} refers to something that does not exist",
+			"hexcolor":         "{field} must be a hex color such as #ff8800",
+			"cycle":            "{field} cannot be the todo itself or one of its subtasks",
+			"depth":            "{field} would nest subtasks more than {param} levels deep",
+			"required_without": "{field} is required when {param} is missing",
+			"nefield":          "{field} cannot be the same as {param}",
+			"rrule":            "{field} must be a supported RFC 5545 recurrence rule",
+			"required_with":    "{field} is required when {param} is set",
+			"filesize":         "{field} must be at most {param} bytes",
+			"filetype":         "{field} must be one of these file types: {param}",
+			"max_items":        "{field} must contain at most {param} items",
+		},
+		stringRules: map[string]string{
+			"min": "{field} must be at least {param} characters long",
+			"max": "{field} must be at most {param} characters long",
+			"len": "{field} must be exactly {param} characters long",
+		},
+		itemRules: map[string]string{
+			"min": "{field} must contain at least {param} items",
+			"max": "{field} must contain at most {param} items",
+			"len": "{field} must contain exactly {param} items",
+		},
+		fallback: "{field} is invalid",
+	},
+	"vi": {
+		summary:   "Dữ liệu không hợp lệ",
+		malformed: "Nội dung yêu cầu không đúng định dạng",
+		rules: map[string]string{
+			"required":         "{field} là bắt buộc",
+			"email":            "{field} phải là địa chỉ email hợp lệ",
+			"uuid":             "{field} phải là UUID hợp lệ",
+			"oneof":            "{field} phải là một trong: {param}",
+			"min":              "{field} phải lớn hơn hoặc bằng {param}",
+			"max":              "{field} phải nhỏ hơn hoặc bằng {param}",
+			"gte":              "{field} phải lớn hơn hoặc bằng {param}",
+			"lte":              "{field} phải nhỏ hơn hoặc bằng {param}",
+			"len":              "{field} phải bằng {param}",
+			"gtfield":          "{field} phải sau {param}",
+			"cursor":           "{field} không phải là con trỏ phân trang hợp lệ",
+			"json":             "{field} phải là đối tượng JSON hợp lệ",
+			"excluded_with":    "{field} không thể dùng cùng với {param}",
+			"exists":           "{field} tham chiếu đến mục không tồn tại",
+			"hexcolor":         "{field} phải là mã màu hex, ví dụ #ff8800",
+			"cycle":            "{field} không thể là chính công việc này hoặc một công việc con của nó",
+			"depth":            "{field} sẽ làm công việc con lồng sâu quá {param} cấp",
+			"required_without": "{field} là bắt buộc khi thiếu {param}",
+			"nefield":          "{field} không được trùng với {param}",
+			"rrule":            "{field} phải là quy tắc lặp lại RFC 5545 được hỗ trợ",
+			"required_with":    "{field} là bắt buộc khi có {param}",
+			"filesize":         "{field} không được lớn hơn {param} byte",
+			"filetype":         "{field} phải thuộc một trong các loại tệp: {param}",
+			"max_items":        "{field} không được có quá {param} phần tử",
+		},
+		stringRules: map[string]string{
+			"min": "{field} phải có ít nhất {param} ký tự",
+			"max": "{field} không được vượt quá {param} ký tự",
+			"len": "{field} phải có đúng {param} ký tự",
+		},
+		itemRules: map[string]string{
+			"min": "{field} phải có ít nhất {param} phần tử",
+			"max": "{field} không được có quá {param} phần tử",
+			"len": "{field} phải có đúng {param} phần tử",
+		},
+		fallback: "{field} không hợp lệ",
+	},
+}
+
+// RegisterTagNames makes validation errors report JSON (or query) field names
+// instead of Go struct field names. Call once at startup.
+func RegisterTagNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
+
+// RespondInvalid writes a 400 response for an error returned by gin's
+// ShouldBind* helpers. Validation failures get one detail per field, with
+// messages in the language requested by Accept-Language.
+func RespondInvalid(c *gin.Context, err error) {
+	catalog := catalogFor(c.GetHeader("Accept-Language"))
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: catalog.malformed,
+			},
+		})
+		return
+	}
+
+	details := make([]domain.FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		details = append(details, domain.FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: catalog.message(fe.Field(), fe.Tag(), fe.Param(), fe.Kind()),
+		})
+	}
+
+	c.JSON(http.StatusBadRequest, domain.APIResponse{
+		Success: false,
+		Error: &domain.APIError{
+			Code:    domain.ErrCodeInvalidRequest,
+			Message: catalog.summary,
+			Details: details,
+		},
+	})
+}
+
+func catalogFor(acceptLanguage string) messageCatalog {
+	tag, _ := language.MatchStrings(supportedLanguages, acceptLanguage)
+	base, _ := tag.Base()
+	if catalog, ok := catalogs[base.String()]; ok {
+		return catalog
+	}
+	return catalogs["en"]
+}
+
+// message words rule for a field of the given kind
+func (mc messageCatalog) message(field, rule, param string, kind reflect.Kind) string {
+	template, ok := mc.rules[rule]
+
+	var kindRules map[string]string
+	switch kind {
+	case reflect.String:
+		kindRules = mc.stringRules
+	case reflect.Slice, reflect.Array, reflect.Map:
+		kindRules = mc.itemRules
+	}
+	if kindTemplate, found := kindRules[rule]; found {
+		template, ok = kindTemplate, true
+	}
+	if !ok {
+		template = mc.fallback
+	}
+
+	return strings.NewReplacer("{field}", field, "{param}", param).Replace(template)
+}
+
+// localizeDetails fills in messages for details produced by services. Their
+// min/max/len rules are on text fields, so length wording is used for them;
+// services report collections with max_items and numbers with gte/lte.
+func localizeDetails(c *gin.Context, domainErr *domain.Error) *domain.APIError {
+	catalog := catalogFor(c.GetHeader("Accept-Language"))
+
+	details := make([]domain.FieldError, len(domainErr.Details))
+	for i, detail := range domainErr.Details {
+		if detail.Message == "" {
+			detail.Message = catalog.message(detail.Field, detail.Rule, detail.Param, reflect.String)
+		}
+		details[i] = detail
+	}
+
+	return &domain.APIError{
+		Code:    domainErr.Code,
+		Message: catalog.summary,
+		Details: details,
+	}
+}
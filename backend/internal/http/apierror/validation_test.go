@@ -0,0 +1,139 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testSignup struct {
+	Email    string   `json:"email" binding:"required,email"`
+	Password string   `json:"password" binding:"required,min=8"`
+	Age      int      `json:"age" binding:"min=18"`
+	Topics   []string `json:"topics" binding:"max=2"`
+}
+
+// bindInvalid binds body like a handler would and returns the RespondInvalid response
+func bindInvalid(t *testing.T, body, acceptLanguage string) (int, *domain.APIError) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	if acceptLanguage != "" {
+		c.Request.Header.Set("Accept-Language", acceptLanguage)
+	}
+
+	var req testSignup
+	err := c.ShouldBindJSON(&req)
+	if err == nil {
+		t.Fatalf("binding %s succeeded", body)
+	}
+	RespondInvalid(c, err)
+
+	var resp domain.APIResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return rec.Code, resp.Error
+}
+
+func TestRespondInvalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	RegisterTagNames()
+
+	body := `{"email": "not-an-email", "password": "short", "age": 12, "topics": ["a", "b", "c"]}`
+	tests := []struct {
+		acceptLanguage string
+		wantSummary    string
+		wantMessages   []string
+	}{
+		{
+			wantSummary:  "Validation failed",
+			wantMessages: []string{"email must be a valid email address", "password must be at least 8 characters long", "age must be at least 18", "topics must contain at most 2 items"},
+		},
+		{
+			acceptLanguage: "vi-VN,vi;q=0.9,en;q=0.8",
+			wantSummary:    "Dữ liệu không hợp lệ",
+			wantMessages:   []string{"email phải là địa chỉ email hợp lệ", "password phải có ít nhất 8 ký tự", "age phải lớn hơn hoặc bằng 18", "topics không được có quá 2 phần tử"},
+		},
+		{
+			acceptLanguage: "fr-FR, vi;q=0.5",
+			wantSummary:    "Dữ liệu không hợp lệ",
+			wantMessages:   []string{"email phải là địa chỉ email hợp lệ", "password phải có ít nhất 8 ký tự", "age phải lớn hơn hoặc bằng 18", "topics không được có quá 2 phần tử"},
+		},
+		{
+			acceptLanguage: "de",
+			wantSummary:    "Validation failed",
+			wantMessages:   []string{"email must be a valid email address", "password must be at least 8 characters long", "age must be at least 18", "topics must contain at most 2 items"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			status, apiErr := bindInvalid(t, body, tt.acceptLanguage)
+			if status != http.StatusBadRequest || apiErr.Code != domain.ErrCodeInvalidRequest || apiErr.Message != tt.wantSummary {
+				t.Fatalf("got %d %s %q, want a 400 %q", status, apiErr.Code, apiErr.Message, tt.wantSummary)
+			}
+			if len(apiErr.Details) != len(tt.wantMessages) {
+				t.Fatalf("got details %+v, want %d", apiErr.Details, len(tt.wantMessages))
+			}
+			for i, detail := range apiErr.Details {
+				if detail.Message != tt.wantMessages[i] {
+					t.Errorf("detail %s: got %q, want %q", detail.Field, detail.Message, tt.wantMessages[i])
+				}
+			}
+		})
+	}
+
+	// A body that isn't JSON has no field details
+	status, apiErr := bindInvalid(t, `{"email": `, "vi")
+	if status != http.StatusBadRequest || apiErr.Message != "Nội dung yêu cầu không đúng định dạng" || len(apiErr.Details) != 0 {
+		t.Fatalf("got %d %+v, want the localized malformed body error", status, apiErr)
+	}
+}
+
+func TestRespondValidationDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	err := domain.NewValidationError("invalid todo",
+		domain.FieldError{Field: "title", Rule: "max", Param: "200"},
+		domain.FieldError{Field: "tag_ids", Rule: "max_items", Param: "20"},
+		domain.FieldError{Field: "position", Rule: "gte", Param: "0"},
+		domain.FieldError{Field: "color", Rule: "hexcolor", Message: "kept as is"},
+	)
+	tests := map[string][]string{
+		"en": {"title must be at most 200 characters long", "tag_ids must contain at most 20 items", "position must be at least 0", "kept as is"},
+		"vi": {"title không được vượt quá 200 ký tự", "tag_ids không được có quá 20 phần tử", "position phải lớn hơn hoặc bằng 0", "kept as is"},
+	}
+
+	for acceptLanguage, want := range tests {
+		t.Run(acceptLanguage, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodPatch, "/", nil)
+			c.Request.Header.Set("Accept-Language", acceptLanguage)
+			Respond(c, err)
+
+			var resp domain.APIResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if rec.Code != http.StatusBadRequest || len(resp.Error.Details) != len(want) {
+				t.Fatalf("got %d with details %+v, want 400 with %d details", rec.Code, resp.Error.Details, len(want))
+			}
+			for i, detail := range resp.Error.Details {
+				if detail.Message != want[i] {
+					t.Errorf("detail %s: got %q, want %q", detail.Field, detail.Message, want[i])
+				}
+			}
+		})
+	}
+}
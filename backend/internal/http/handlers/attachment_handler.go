@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/http/apierror"
+	"template-fullstack/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// multipartOverhead is how far an upload's body may exceed the maximum file
+// size, for the multipart boundaries and headers
+const multipartOverhead = 1 << 20
+
+type AttachmentHandler struct {
+	attachmentService service.AttachmentService
+	maxSize           int64
+}
+
+func NewAttachmentHandler(attachmentService service.AttachmentService, maxSize int64) *AttachmentHandler {
+	return &AttachmentHandler{attachmentService: attachmentService, maxSize: maxSize}
+}
+
+// UploadAttachment godoc
+// @Summary Attach a file to a todo
+// @Description Upload a file as the "file" field of a multipart form. Requires write access to the todo. The size and type limits are configured on the server; the type is detected from the contents.
+// @Tags attachments
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} domain.APIResponse{data=domain.Attachment}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 403 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /todos/{id}/attachments [post]
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid todo ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apierror.Respond(c, domain.NewAttachmentTooLargeError(h.maxSize))
+			return
+		}
+		apierror.Respond(c, domain.NewValidationError("file is required", domain.FieldError{Field: "file", Rule: "required"}))
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	defer file.Close()
+
+	upload := domain.AttachmentUpload{Filename: header.Filename, Size: header.Size, Content: file}
+	attachment, err := h.attachmentService.Upload(c.Request.Context(), actor, id, upload)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain.APIResponse{
+		Success: true,
+		Data:    attachment,
+	})
+}
+
+// GetAttachments godoc
+// @Summary Get a todo's attachments
+// @Description Get all of a todo's attachments in upload order, each with a short-lived download URL
+// @Tags attachments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Success 200 {object} domain.APIResponse{data=[]domain.Attachment}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /todos/{id}/attachments [get]
+func (h *AttachmentHandler) GetAttachments(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid todo ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	attachments, err := h.attachmentService.GetByTodoID(c.Request.Context(), actor, id)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    attachments,
+	})
+}
+
+// GetAttachment godoc
+// @Summary Get an attachment
+// @Description Get an attachment with a fresh short-lived download URL
+// @Tags attachments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Attachment ID"
+// @Success 200 {object} domain.APIResponse{data=domain.Attachment}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /attachments/{id} [get]
+func (h *AttachmentHandler) GetAttachment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid attachment ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	attachment, err := h.attachmentService.GetByID(c.Request.Context(), actor, id)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    attachment,
+	})
+}
+
+// DeleteAttachment godoc
+// @Summary Delete an attachment
+// @Description Delete an attachment and its file. Requires write access to the todo.
+// @Tags attachments
+// @Security BearerAuth
+// @Param id path string true "Attachment ID"
+// @Success 204
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 403 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /attachments/{id} [delete]
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid attachment ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	if err := h.attachmentService.Delete(c.Request.Context(), actor, id); err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DownloadAttachment godoc
+// @Summary Download an attachment
+// @Description Download the contents of an attachment through the URL returned with it. The signed token in the URL authorizes the download until it expires.
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path string true "Attachment ID"
+// @Param token query string true "Signed download token"
+// @Success 200 {file} file
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 403 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /attachments/{id}/download [get]
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid attachment ID",
+			},
+		})
+		return
+	}
+
+	attachment, contents, err := h.attachmentService.Download(c.Request.Context(), id, c.Query("token"))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+	defer contents.Close()
+
+	// Always download rather than display, so uploaded HTML or SVG can't run
+	// in the API's origin
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, contents, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
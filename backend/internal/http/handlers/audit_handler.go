@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/http/apierror"
+	"template-fullstack/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// GetTodoHistory godoc
+// @Summary Get a todo's history
+// @Description Get a page of the changes made to a todo, newest first. Each event holds the changed fields before and after the change. The history of a todo in the trash remains available.
+// @Tags todos
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Success 200 {object} domain.APIResponse{data=domain.PaginatedResponse}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /todos/{id}/history [get]
+func (h *AuditHandler) GetTodoHistory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid todo ID",
+			},
+		})
+		return
+	}
+
+	var pagination domain.PaginationQuery
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	resp, err := h.auditService.TodoHistory(c.Request.Context(), actor, id, pagination)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// SearchAudit godoc
+// @Summary Search the audit log (admin)
+// @Description Get a page of the audit events of all todos and users, newest first (admin endpoint)
+// @Tags audit
+// @Security BearerAuth
+// @Produce json
+// @Param entity_type query string false "Only events about this kind of record" Enums(todo, user)
+// @Param entity_id query string false "Only events about this record"
+// @Param actor_id query string false "Only changes made by this user"
+// @Param action query string false "Only this kind of change" Enums(create, update, delete, restore)
+// @Param from query string false "Only events at or after this RFC 3339 time"
+// @Param to query string false "Only events before this RFC 3339 time"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Success 200 {object} domain.APIResponse{data=domain.PaginatedResponse}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 403 {object} domain.APIResponse{error=domain.APIError}
+// @Router /admin/audit [get]
+func (h *AuditHandler) SearchAudit(c *gin.Context) {
+	var filter domain.AuditFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	var pagination domain.PaginationQuery
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	resp, err := h.auditService.Search(c.Request.Context(), filter, pagination)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    resp,
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/http/apierror"
 	"template-fullstack/backend/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -12,10 +13,50 @@ import (
 
 type AuthHandler struct {
 	authService service.AuthService
+	userService service.UserService
 }
 
-func NewAuthHandler(authService service.AuthService) *AuthHandler {
-	return &AuthHandler{authService: authService}
+func NewAuthHandler(authService service.AuthService, userService service.UserService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+		userService: userService,
+	}
+}
+
+// Register godoc
+// @Summary Register a new user
+// @Description Create a user account and return a JWT token so the client is signed in immediately
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body domain.CreateUserRequest true "Account details"
+// @Success 201 {object} domain.APIResponse{data=domain.LoginResponse}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 409 {object} domain.APIResponse{error=domain.APIError}
+// @Router /auth/register [post]
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req domain.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	user, err := h.userService.Create(c.Request.Context(), req)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	resp, err := h.authService.IssueTokens(c.Request.Context(), user)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain.APIResponse{
+		Success: true,
+		Data:    resp,
+	})
 }
 
 // Login godoc
@@ -32,25 +73,13 @@ func NewAuthHandler(authService service.AuthService) *AuthHandler {
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req domain.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Success: false,
-			Error: &domain.APIError{
-				Code:    domain.ErrCodeInvalidRequest,
-				Message: err.Error(),
-			},
-		})
+		apierror.RespondInvalid(c, err)
 		return
 	}
 
 	resp, err := h.authService.Login(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, domain.APIResponse{
-			Success: false,
-			Error: &domain.APIError{
-				Code:    domain.ErrCodeInvalidCredentials,
-				Message: "Invalid email or password",
-			},
-		})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -60,59 +89,101 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	})
 }
 
-// Mock refresh endpoint
+// Refresh godoc
 // @Summary Refresh token
-// @Description Refresh JWT token
+// @Description Exchange a refresh token for a new access and refresh token. Each refresh token is single-use; reusing one revokes the whole session.
 // @Tags auth
-// @Security BearerAuth
+// @Accept json
 // @Produce json
-// @Success 200 {object} domain.APIResponse{data=map[string]string}
+// @Param request body domain.RefreshRequest true "Refresh token"
+// @Success 200 {object} domain.APIResponse{data=domain.LoginResponse}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
 // @Failure 401 {object} domain.APIResponse{error=domain.APIError}
 // @Router /auth/refresh [post]
 func (h *AuthHandler) Refresh(c *gin.Context) {
-	// Mock implementation - in real app, validate refresh token
-	userID, exists := c.Get("user_id")
+	var req domain.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	resp, err := h.authService.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the current access token and, if provided, the refresh token's session
+// @Tags auth
+// @Security BearerAuth
+// @Accept json
+// @Param request body domain.LogoutRequest false "Refresh token to revoke"
+// @Success 204
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req domain.LogoutRequest
+	// The body is optional
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.RespondInvalid(c, err)
+			return
+		}
+	}
+
+	claims, exists := c.Get("claims")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, domain.APIResponse{
 			Success: false,
 			Error: &domain.APIError{
 				Code:    domain.ErrCodeUnauthorized,
-				Message: "Unauthorized",
+				Message: "User not authenticated",
 			},
 		})
 		return
 	}
 
-	email, _ := c.Get("email")
+	if err := h.authService.Logout(c.Request.Context(), claims.(*service.Claims), req.RefreshToken); err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
 
-	// Convert userID to UUID
-	userUUID, err := uuid.Parse(userID.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
+// LogoutAll godoc
+// @Summary Logout all sessions
+// @Description Invalidate every access and refresh token issued to the current user
+// @Tags auth
+// @Security BearerAuth
+// @Success 204
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Router /auth/logout-all [post]
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
 			Success: false,
 			Error: &domain.APIError{
-				Code:    domain.ErrCodeInvalidRequest,
-				Message: "Invalid user ID",
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
 			},
 		})
 		return
 	}
 
-	// Generate new token
-	token, err := h.authService.GenerateToken(userUUID, email.(string))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Success: false,
-			Error: &domain.APIError{
-				Code:    domain.ErrCodeInternalError,
-				Message: "Failed to generate token",
-			},
-		})
+	if err := h.authService.LogoutAll(c.Request.Context(), userID.(uuid.UUID)); err != nil {
+		apierror.Respond(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Success: true,
-		Data:    map[string]string{"token": token},
-	})
+	c.Status(http.StatusNoContent)
 }
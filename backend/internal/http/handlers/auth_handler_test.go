@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// fakeUserService keeps users in memory. Methods the tests don't use panic
+// through the nil embedded interface.
+type fakeUserService struct {
+	service.UserService
+	users map[uuid.UUID]domain.User
+}
+
+func newFakeUserService() *fakeUserService {
+	return &fakeUserService{users: make(map[uuid.UUID]domain.User)}
+}
+
+func (s *fakeUserService) Create(ctx context.Context, req domain.CreateUserRequest) (*domain.User, error) {
+	for _, user := range s.users {
+		if user.Email == req.Email {
+			return nil, domain.ErrEmailExists
+		}
+	}
+	user := domain.User{ID: uuid.New(), Email: req.Email, Name: req.Name, Role: domain.RoleUser, Password: req.Password}
+	s.users[user.ID] = user
+	return &user, nil
+}
+
+func (s *fakeUserService) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (s *fakeUserService) Update(ctx context.Context, id uuid.UUID, req domain.UpdateUserRequest) (*domain.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	if req.Name != "" {
+		user.Name = req.Name
+	}
+	if req.Email != "" {
+		user.Email = req.Email
+	}
+	s.users[id] = user
+	return &user, nil
+}
+
+func (s *fakeUserService) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, ok := s.users[id]; !ok {
+		return domain.ErrUserNotFound
+	}
+	delete(s.users, id)
+	return nil
+}
+
+// fakeAuthService issues opaque tokens naming the user
+type fakeAuthService struct {
+	service.AuthService
+}
+
+func (fakeAuthService) IssueTokens(ctx context.Context, user *domain.User) (*domain.LoginResponse, error) {
+	return &domain.LoginResponse{
+		Token:        "access-" + user.ID.String(),
+		ExpiresAt:    time.Now().Add(time.Hour),
+		RefreshToken: "refresh-" + user.ID.String(),
+		User:         *user,
+	}, nil
+}
+
+// serve runs a request through router and decodes the APIResponse, with data
+// decoded into data if it isn't nil
+func serve(t *testing.T, router *gin.Engine, method, path string, body interface{}, data interface{}) (int, domain.APIResponse) {
+	t.Helper()
+
+	var raw []byte
+	if body != nil {
+		var err error
+		if raw, err = json.Marshal(body); err != nil {
+			t.Fatalf("encode request: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	resp := domain.APIResponse{Data: data}
+	if rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+		}
+	}
+	return rec.Code, resp
+}
+
+func TestAuthHandlerRegister(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	users := newFakeUserService()
+	router := gin.New()
+	router.POST("/auth/register", NewAuthHandler(fakeAuthService{}, users).Register)
+
+	signup := domain.CreateUserRequest{Email: "ada@example.com", Name: "Ada", Password: "secret123"}
+	var login domain.LoginResponse
+	status, resp := serve(t, router, http.MethodPost, "/auth/register", signup, &login)
+	if status != http.StatusCreated || !resp.Success {
+		t.Fatalf("got %d %+v, want 201", status, resp.Error)
+	}
+	if login.User.Email != signup.Email || login.Token == "" || login.RefreshToken == "" {
+		t.Fatalf("got %+v, want the new user signed in", login)
+	}
+	if _, ok := users.users[login.User.ID]; !ok {
+		t.Fatal("the user was not created")
+	}
+
+	tests := []struct {
+		name       string
+		body       domain.CreateUserRequest
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "taken email", body: signup, wantStatus: http.StatusConflict, wantCode: domain.ErrCodeEmailExists},
+		{name: "invalid email", body: domain.CreateUserRequest{Email: "ada", Name: "Ada", Password: "secret123"}, wantStatus: http.StatusBadRequest, wantCode: domain.ErrCodeInvalidRequest},
+		{name: "short password", body: domain.CreateUserRequest{Email: "bob@example.com", Name: "Bob", Password: "123"}, wantStatus: http.StatusBadRequest, wantCode: domain.ErrCodeInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := serve(t, router, http.MethodPost, "/auth/register", tt.body, nil)
+			if status != tt.wantStatus || resp.Success || resp.Error.Code != tt.wantCode {
+				t.Fatalf("got %d %+v, want %d %s", status, resp.Error, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+	if len(users.users) != 1 {
+		t.Fatalf("got %d users, want only the first signup", len(users.users))
+	}
+}
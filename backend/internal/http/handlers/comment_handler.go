@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"net/http"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/http/apierror"
+	"template-fullstack/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CommentHandler struct {
+	commentService service.CommentService
+}
+
+func NewCommentHandler(commentService service.CommentService) *CommentHandler {
+	return &CommentHandler{commentService: commentService}
+}
+
+// CreateComment godoc
+// @Summary Comment on a todo
+// @Description Add a Markdown comment to a todo's thread. Everyone who can see the todo may comment.
+// @Tags comments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Param request body domain.CreateCommentRequest true "Comment"
+// @Success 201 {object} domain.APIResponse{data=domain.Comment}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /todos/{id}/comments [post]
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid todo ID",
+			},
+		})
+		return
+	}
+
+	var req domain.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	req.TodoID = id
+
+	comment, err := h.commentService.Create(c.Request.Context(), actor, req)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain.APIResponse{
+		Success: true,
+		Data:    comment,
+	})
+}
+
+// GetComments godoc
+// @Summary Get a todo's comments
+// @Description Get a page of a todo's comments, oldest first
+// @Tags comments
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Success 200 {object} domain.APIResponse{data=domain.PaginatedResponse}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /todos/{id}/comments [get]
+func (h *CommentHandler) GetComments(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid todo ID",
+			},
+		})
+		return
+	}
+
+	var pagination domain.PaginationQuery
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	resp, err := h.commentService.GetByTodoID(c.Request.Context(), actor, id, pagination)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// UpdateComment godoc
+// @Summary Edit a comment
+// @Description Replace the body of a comment. Only its author may edit it; the comment is then marked as edited.
+// @Tags comments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Comment ID"
+// @Param request body domain.UpdateCommentRequest true "New body"
+// @Success 200 {object} domain.APIResponse{data=domain.Comment}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 403 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /comments/{id} [patch]
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid comment ID",
+			},
+		})
+		return
+	}
+
+	var req domain.UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	comment, err := h.commentService.Update(c.Request.Context(), actor, id, req)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    comment,
+	})
+}
+
+// DeleteComment godoc
+// @Summary Delete a comment
+// @Description Delete a comment. Only its author or an admin may delete it.
+// @Tags comments
+// @Security BearerAuth
+// @Param id path string true "Comment ID"
+// @Success 204
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 403 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /comments/{id} [delete]
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid comment ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	if err := h.commentService.Delete(c.Request.Context(), actor, id); err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
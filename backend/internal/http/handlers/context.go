@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// actorFromContext builds the caller's identity from the values AuthMiddleware
+// stores in the context.
+func actorFromContext(c *gin.Context) (domain.Actor, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return domain.Actor{}, false
+	}
+
+	role, _ := c.Get("role")
+	actorRole, _ := role.(domain.Role)
+
+	return domain.Actor{
+		UserID: userID.(uuid.UUID),
+		Role:   actorRole,
+	}, true
+}
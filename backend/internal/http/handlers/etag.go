@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Todos use their version and a hash of their representation as a strong
+// ETag, e.g. "3-1f0c9a6e5d2b4c87". The version alone would miss changes to
+// what is loaded alongside the todo, such as renamed tags.
+func todoETag(todo *domain.Todo) string {
+	body, err := json.Marshal(todo)
+	if err != nil {
+		return strconv.Quote(strconv.Itoa(todo.Version))
+	}
+
+	sum := sha256.Sum256(body)
+	return strconv.Quote(strconv.Itoa(todo.Version) + "-" + hex.EncodeToString(sum[:8]))
+}
+
+func setTodoETag(c *gin.Context, todo *domain.Todo) {
+	c.Header("ETag", todoETag(todo))
+}
+
+// expectedVersion reads the If-Match header of a write request. It returns 0
+// when the write is unconditional (no header, or "*"). If-Match uses strong
+// comparison, so a weak ETag can never match. Only the version part of the
+// ETag is compared, and a bare version such as "3" is accepted as well.
+func expectedVersion(c *gin.Context) (int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	if strings.Contains(header, ",") {
+		return 0, domain.NewValidationError("If-Match must contain a single ETag")
+	}
+	if strings.HasPrefix(header, "W/") {
+		return 0, domain.ErrTodoVersionMismatch
+	}
+
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, domain.NewValidationError("If-Match is not a valid ETag")
+	}
+
+	versionPart, _, _ := strings.Cut(unquoted, "-")
+	version, err := strconv.Atoi(versionPart)
+	if err != nil || version < 1 {
+		// Well-formed, but not an ETag we ever issued
+		return 0, domain.ErrTodoVersionMismatch
+	}
+
+	return version, nil
+}
+
+// notModified reports whether the request's If-None-Match header matches
+// etag. If-None-Match uses weak comparison and may list several ETags.
+func notModified(c *gin.Context, etag string) bool {
+	header := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"net/http"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/http/apierror"
+	"template-fullstack/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ProjectHandler struct {
+	projectService service.ProjectService
+}
+
+func NewProjectHandler(projectService service.ProjectService) *ProjectHandler {
+	return &ProjectHandler{projectService: projectService}
+}
+
+// CreateProject godoc
+// @Summary Create a project
+// @Description Create a project for the current user. It is placed after the user's existing projects.
+// @Tags projects
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body domain.CreateProjectRequest true "Project data"
+// @Success 201 {object} domain.APIResponse{data=domain.Project}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Router /projects [post]
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	var req domain.CreateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	req.UserID = userID.(uuid.UUID)
+
+	project, err := h.projectService.Create(c.Request.Context(), req)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain.APIResponse{
+		Success: true,
+		Data:    project,
+	})
+}
+
+// GetProjects godoc
+// @Summary Get projects
+// @Description Get the current user's active projects, or the archived ones, in position order
+// @Tags projects
+// @Security BearerAuth
+// @Produce json
+// @Param archived query bool false "List archived instead of active projects"
+// @Success 200 {object} domain.APIResponse{data=[]domain.Project}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Router /projects [get]
+func (h *ProjectHandler) GetProjects(c *gin.Context) {
+	var query domain.ProjectListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	projects, err := h.projectService.GetByUserID(c.Request.Context(), userID.(uuid.UUID), query)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    projects,
+	})
+}
+
+// GetProject godoc
+// @Summary Get project by ID
+// @Description Get a specific project by ID
+// @Tags projects
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 {object} domain.APIResponse{data=domain.Project}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /projects/{id} [get]
+func (h *ProjectHandler) GetProject(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid project ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	project, err := h.projectService.GetByID(c.Request.Context(), actor, id)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    project,
+	})
+}
+
+// UpdateProject godoc
+// @Summary Update project
+// @Description Rename, move, archive or unarchive a project with a JSON Merge Patch
+// @Tags projects
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param request body domain.UpdateProjectRequest true "Fields to change"
+// @Success 200 {object} domain.APIResponse{data=domain.Project}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /projects/{id} [patch]
+func (h *ProjectHandler) UpdateProject(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid project ID",
+			},
+		})
+		return
+	}
+
+	var req domain.UpdateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	project, err := h.projectService.Update(c.Request.Context(), actor, id, req)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    project,
+	})
+}
+
+// DeleteProject godoc
+// @Summary Delete project
+// @Description Delete a project. Its todos are kept without a project.
+// @Tags projects
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Success 204
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /projects/{id} [delete]
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid project ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	if err := h.projectService.Delete(c.Request.Context(), actor, id); err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetProjectTodos godoc
+// @Summary Get project todos
+// @Description Get paginated list of a project's todos. Supports the same filters and sorting as GET /todos.
+// @Tags projects
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Param completed query bool false "Only completed (true) or open (false) todos"
+// @Param q query string false "Full-text search over title and description"
+// @Param sort query string false "Comma-separated sort keys, prefix with - for descending" example(-updated_at,title)
+// @Success 200 {object} domain.APIResponse{data=domain.PaginatedResponse}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /projects/{id}/todos [get]
+func (h *ProjectHandler) GetProjectTodos(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid project ID",
+			},
+		})
+		return
+	}
+
+	var pagination domain.PaginationQuery
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	var filter domain.TodoFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	resp, err := h.projectService.GetTodos(c.Request.Context(), actor, id, filter, pagination)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    resp,
+	})
+}
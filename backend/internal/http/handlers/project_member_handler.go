@@ -0,0 +1,477 @@
+package handlers
+
+import (
+	"net/http"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/http/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetMembers godoc
+// @Summary Get project members
+// @Description List the members of a project with their roles
+// @Tags projects
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 {object} domain.APIResponse{data=[]domain.ProjectMember}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /projects/{id}/members [get]
+func (h *ProjectHandler) GetMembers(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid project ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	members, err := h.projectService.GetMembers(c.Request.Context(), actor, id)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    members,
+	})
+}
+
+// UpdateMember godoc
+// @Summary Change a member's role
+// @Description Make a project member a viewer, editor or owner. Requires the owner role; the project's creator stays an owner.
+// @Tags projects
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param user_id path string true "User ID of the member"
+// @Param request body domain.UpdateProjectMemberRequest true "New role"
+// @Success 200 {object} domain.APIResponse{data=domain.ProjectMember}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 403 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 409 {object} domain.APIResponse{error=domain.APIError}
+// @Router /projects/{id}/members/{user_id} [patch]
+func (h *ProjectHandler) UpdateMember(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid project ID",
+			},
+		})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid user ID",
+			},
+		})
+		return
+	}
+
+	var req domain.UpdateProjectMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	member, err := h.projectService.UpdateMember(c.Request.Context(), actor, id, userID, req)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    member,
+	})
+}
+
+// RemoveMember godoc
+// @Summary Remove a member
+// @Description Remove a member from a project, which requires the owner role, or leave the project by passing your own user ID. The project's creator cannot be removed.
+// @Tags projects
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param user_id path string true "User ID of the member"
+// @Success 204
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 403 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 409 {object} domain.APIResponse{error=domain.APIError}
+// @Router /projects/{id}/members/{user_id} [delete]
+func (h *ProjectHandler) RemoveMember(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid project ID",
+			},
+		})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid user ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	if err := h.projectService.RemoveMember(c.Request.Context(), actor, id, userID); err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateInvitation godoc
+// @Summary Invite to project
+// @Description Invite an email address to join a project with a role. Requires the owner role. The invitation is sent to the address and can be accepted by the user registered with it until it expires; inviting the address again renews it.
+// @Tags projects
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Project ID"
+// @Param request body domain.CreateProjectInvitationRequest true "Invitation"
+// @Success 201 {object} domain.APIResponse{data=domain.ProjectInvitation}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 403 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 409 {object} domain.APIResponse{error=domain.APIError}
+// @Router /projects/{id}/invitations [post]
+func (h *ProjectHandler) CreateInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid project ID",
+			},
+		})
+		return
+	}
+
+	var req domain.CreateProjectInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	invitation, err := h.projectService.Invite(c.Request.Context(), actor, id, req)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain.APIResponse{
+		Success: true,
+		Data:    invitation,
+	})
+}
+
+// GetInvitations godoc
+// @Summary Get project invitations
+// @Description List a project's pending invitations. Requires the owner role.
+// @Tags projects
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Project ID"
+// @Success 200 {object} domain.APIResponse{data=[]domain.ProjectInvitation}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 403 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /projects/{id}/invitations [get]
+func (h *ProjectHandler) GetInvitations(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid project ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	invitations, err := h.projectService.GetInvitations(c.Request.Context(), actor, id)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    invitations,
+	})
+}
+
+// RevokeInvitation godoc
+// @Summary Revoke invitation
+// @Description Withdraw a pending invitation. Requires the owner role.
+// @Tags projects
+// @Security BearerAuth
+// @Param id path string true "Project ID"
+// @Param invitation_id path string true "Invitation ID"
+// @Success 204
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 403 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /projects/{id}/invitations/{invitation_id} [delete]
+func (h *ProjectHandler) RevokeInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid project ID",
+			},
+		})
+		return
+	}
+
+	invitationID, err := uuid.Parse(c.Param("invitation_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid invitation ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	if err := h.projectService.RevokeInvitation(c.Request.Context(), actor, id, invitationID); err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetMyInvitations godoc
+// @Summary Get my invitations
+// @Description List the pending project invitations sent to the current user's email
+// @Tags invitations
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.APIResponse{data=[]domain.ProjectInvitation}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Router /invitations [get]
+func (h *ProjectHandler) GetMyInvitations(c *gin.Context) {
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	invitations, err := h.projectService.GetMyInvitations(c.Request.Context(), actor)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    invitations,
+	})
+}
+
+// AcceptInvitation godoc
+// @Summary Accept invitation
+// @Description Join the project of an invitation sent to the current user's email
+// @Tags invitations
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} domain.APIResponse{data=domain.Project}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /invitations/{id}/accept [post]
+func (h *ProjectHandler) AcceptInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid invitation ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	project, err := h.projectService.AcceptInvitation(c.Request.Context(), actor, id)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    project,
+	})
+}
+
+// DeclineInvitation godoc
+// @Summary Decline invitation
+// @Description Decline an invitation sent to the current user's email
+// @Tags invitations
+// @Security BearerAuth
+// @Param id path string true "Invitation ID"
+// @Success 204
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /invitations/{id}/decline [post]
+func (h *ProjectHandler) DeclineInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid invitation ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	if err := h.projectService.DeclineInvitation(c.Request.Context(), actor, id); err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"net/http"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/http/apierror"
+	"template-fullstack/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type TagHandler struct {
+	tagService service.TagService
+}
+
+func NewTagHandler(tagService service.TagService) *TagHandler {
+	return &TagHandler{tagService: tagService}
+}
+
+// CreateTag godoc
+// @Summary Create a tag
+// @Description Create a tag for the current user. Names are unique per user, ignoring case.
+// @Tags tags
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body domain.CreateTagRequest true "Tag data"
+// @Success 201 {object} domain.APIResponse{data=domain.Tag}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 409 {object} domain.APIResponse{error=domain.APIError}
+// @Router /tags [post]
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	var req domain.CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	req.UserID = userID.(uuid.UUID)
+
+	tag, err := h.tagService.Create(c.Request.Context(), req)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain.APIResponse{
+		Success: true,
+		Data:    tag,
+	})
+}
+
+// GetTags godoc
+// @Summary Get tags
+// @Description Get all of the current user's tags, ordered by name
+// @Tags tags
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.APIResponse{data=[]domain.Tag}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Router /tags [get]
+func (h *TagHandler) GetTags(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	tags, err := h.tagService.GetByUserID(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    tags,
+	})
+}
+
+// GetTag godoc
+// @Summary Get tag by ID
+// @Description Get a specific tag by ID
+// @Tags tags
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Tag ID"
+// @Success 200 {object} domain.APIResponse{data=domain.Tag}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /tags/{id} [get]
+func (h *TagHandler) GetTag(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid tag ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	tag, err := h.tagService.GetByID(c.Request.Context(), actor, id)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    tag,
+	})
+}
+
+// UpdateTag godoc
+// @Summary Update tag
+// @Description Rename or recolor a tag with a JSON Merge Patch. A null color removes it.
+// @Tags tags
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Tag ID"
+// @Param request body domain.UpdateTagRequest true "Fields to change"
+// @Success 200 {object} domain.APIResponse{data=domain.Tag}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 409 {object} domain.APIResponse{error=domain.APIError}
+// @Router /tags/{id} [patch]
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid tag ID",
+			},
+		})
+		return
+	}
+
+	var req domain.UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	tag, err := h.tagService.Update(c.Request.Context(), actor, id, req)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    tag,
+	})
+}
+
+// DeleteTag godoc
+// @Summary Delete tag
+// @Description Delete a tag and detach it from all todos
+// @Tags tags
+// @Security BearerAuth
+// @Param id path string true "Tag ID"
+// @Success 204
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /tags/{id} [delete]
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid tag ID",
+			},
+		})
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	if err := h.tagService.Delete(c.Request.Context(), actor, id); err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/http/apierror"
 	"template-fullstack/backend/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -33,13 +34,7 @@ func NewTodoHandler(todoService service.TodoService) *TodoHandler {
 func (h *TodoHandler) CreateTodo(c *gin.Context) {
 	var req domain.CreateTodoRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Success: false,
-			Error: &domain.APIError{
-				Code:    domain.ErrCodeInvalidRequest,
-				Message: err.Error(),
-			},
-		})
+		apierror.RespondInvalid(c, err)
 		return
 	}
 
@@ -60,16 +55,11 @@ func (h *TodoHandler) CreateTodo(c *gin.Context) {
 
 	todo, err := h.todoService.Create(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Success: false,
-			Error: &domain.APIError{
-				Code:    domain.ErrCodeInternalError,
-				Message: "Failed to create todo",
-			},
-		})
+		apierror.Respond(c, err)
 		return
 	}
 
+	setTodoETag(c, todo)
 	c.JSON(http.StatusCreated, domain.APIResponse{
 		Success: true,
 		Data:    todo,
@@ -78,25 +68,33 @@ func (h *TodoHandler) CreateTodo(c *gin.Context) {
 
 // GetTodos godoc
 // @Summary Get todos
-// @Description Get paginated list of todos
+// @Description Get paginated list of the current user's todos, optionally filtered, searched and sorted. Pass limit (and then cursor) instead of page/page_size for cursor pagination, which is stable under concurrent inserts but only supports the default newest-first order.
 // @Tags todos
 // @Security BearerAuth
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param page_size query int false "Page size" default(10)
-// @Success 200 {object} domain.APIResponse{data=domain.PaginatedResponse}
+// @Param completed query bool false "Only completed (true) or open (false) todos"
+// @Param q query string false "Full-text search over title and description; results are ranked by relevance unless sort is given"
+// @Param sort query string false "Comma-separated sort keys, prefix with - for descending: created_at, updated_at, title, completed, priority, due_at, position" example(-updated_at,title)
+// @Param created_after query string false "Only todos created at or after this RFC 3339 time"
+// @Param created_before query string false "Only todos created before this RFC 3339 time"
+// @Param priority query string false "Only todos with this priority" Enums(low, medium, high)
+// @Param due_after query string false "Only todos due at or after this RFC 3339 time"
+// @Param due_before query string false "Only todos due before this RFC 3339 time"
+// @Param overdue query bool false "Only todos that are past due and not completed (true), or all others (false)"
+// @Param tag query []string false "Only todos with these tag names (case-insensitive); repeat for several" collectionFormat(multi)
+// @Param tag_mode query string false "Whether a todo needs all listed tags or any of them" Enums(all, any) default(all)
+// @Param cursor query string false "Cursor from a previous response; switches to cursor pagination"
+// @Param limit query int false "Page size for cursor pagination; switches to cursor pagination" default(10)
+// @Success 200 {object} domain.APIResponse{data=domain.PaginatedResponse} "data is a domain.CursorPaginatedResponse when cursor or limit is given"
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
 // @Failure 401 {object} domain.APIResponse{error=domain.APIError}
 // @Router /todos [get]
 func (h *TodoHandler) GetTodos(c *gin.Context) {
-	var pagination domain.PaginationQuery
-	if err := c.ShouldBindQuery(&pagination); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Success: false,
-			Error: &domain.APIError{
-				Code:    domain.ErrCodeInvalidRequest,
-				Message: err.Error(),
-			},
-		})
+	var filter domain.TodoFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		apierror.RespondInvalid(c, err)
 		return
 	}
 
@@ -113,15 +111,25 @@ func (h *TodoHandler) GetTodos(c *gin.Context) {
 		return
 	}
 
-	resp, err := h.todoService.GetByUserID(c.Request.Context(), userID.(uuid.UUID), pagination)
+	var resp interface{}
+	var err error
+	if usesCursor(c) {
+		var page domain.CursorQuery
+		if err := c.ShouldBindQuery(&page); err != nil {
+			apierror.RespondInvalid(c, err)
+			return
+		}
+		resp, err = h.todoService.GetByUserIDCursor(c.Request.Context(), userID.(uuid.UUID), filter, page)
+	} else {
+		var pagination domain.PaginationQuery
+		if err := c.ShouldBindQuery(&pagination); err != nil {
+			apierror.RespondInvalid(c, err)
+			return
+		}
+		resp, err = h.todoService.GetByUserID(c.Request.Context(), userID.(uuid.UUID), filter, pagination)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Success: false,
-			Error: &domain.APIError{
-				Code:    domain.ErrCodeInternalError,
-				Message: "Failed to get todos",
-			},
-		})
+		apierror.Respond(c, err)
 		return
 	}
 
@@ -133,12 +141,15 @@ func (h *TodoHandler) GetTodos(c *gin.Context) {
 
 // GetTodo godoc
 // @Summary Get todo by ID
-// @Description Get a single todo by ID
+// @Description Get a single todo by ID. The response carries an ETag of the todo's version and content; send it back in If-None-Match to get 304 Not Modified while the todo is unchanged.
 // @Tags todos
 // @Security BearerAuth
 // @Produce json
 // @Param id path string true "Todo ID"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} domain.APIResponse{data=domain.Todo}
+// @Header 200 {string} ETag "Todo version"
+// @Success 304
 // @Failure 400 {object} domain.APIResponse{error=domain.APIError}
 // @Failure 404 {object} domain.APIResponse{error=domain.APIError}
 // @Router /todos/{id} [get]
@@ -155,18 +166,30 @@ func (h *TodoHandler) GetTodo(c *gin.Context) {
 		return
 	}
 
-	todo, err := h.todoService.GetByID(c.Request.Context(), id)
-	if err != nil {
-		c.JSON(http.StatusNotFound, domain.APIResponse{
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
 			Success: false,
 			Error: &domain.APIError{
-				Code:    domain.ErrCodeTodoNotFound,
-				Message: "Todo not found",
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
 			},
 		})
 		return
 	}
 
+	todo, err := h.todoService.GetByID(c.Request.Context(), actor, id)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	setTodoETag(c, todo)
+	if notModified(c, todoETag(todo)) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	c.JSON(http.StatusOK, domain.APIResponse{
 		Success: true,
 		Data:    todo,
@@ -174,17 +197,20 @@ func (h *TodoHandler) GetTodo(c *gin.Context) {
 }
 
 // UpdateTodo godoc
-// @Summary Update todo
-// @Description Update a todo by ID
+// @Summary Replace todo
+// @Description Replace a todo by ID. Every field is written; omitted fields are reset to their empty values.
 // @Tags todos
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path string true "Todo ID"
-// @Param request body domain.UpdateTodoRequest true "Todo update data"
+// @Param request body domain.ReplaceTodoRequest true "Full todo representation"
+// @Param If-Match header string false "Only apply the change if the todo still has this ETag"
 // @Success 200 {object} domain.APIResponse{data=domain.Todo}
+// @Header 200 {string} ETag "New todo version"
 // @Failure 400 {object} domain.APIResponse{error=domain.APIError}
 // @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 412 {object} domain.APIResponse{error=domain.APIError}
 // @Router /todos/{id} [put]
 func (h *TodoHandler) UpdateTodo(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
@@ -199,30 +225,169 @@ func (h *TodoHandler) UpdateTodo(c *gin.Context) {
 		return
 	}
 
-	var req domain.UpdateTodoRequest
+	var req domain.ReplaceTodoRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	version, err := expectedVersion(c)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	todo, err := h.todoService.Update(c.Request.Context(), actor, id, req.ToUpdate(), version)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	setTodoETag(c, todo)
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    todo,
+	})
+}
+
+// PatchTodo godoc
+// @Summary Patch todo
+// @Description Partially update a todo using JSON Merge Patch (RFC 7396): absent fields are left untouched, null clears a field. Completing a recurring todo creates its next occurrence; send "recurrence": null with it to stop the series instead.
+// @Tags todos
+// @Security BearerAuth
+// @Accept json,application/merge-patch+json
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Param request body domain.UpdateTodoRequest true "Merge patch"
+// @Param If-Match header string false "Only apply the change if the todo still has this ETag"
+// @Success 200 {object} domain.APIResponse{data=domain.Todo}
+// @Header 200 {string} ETag "New todo version"
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 412 {object} domain.APIResponse{error=domain.APIError}
+// @Router /todos/{id} [patch]
+func (h *TodoHandler) PatchTodo(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusBadRequest, domain.APIResponse{
 			Success: false,
 			Error: &domain.APIError{
 				Code:    domain.ErrCodeInvalidRequest,
-				Message: err.Error(),
+				Message: "Invalid todo ID",
+			},
+		})
+		return
+	}
+
+	var req domain.UpdateTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
 			},
 		})
 		return
 	}
 
-	todo, err := h.todoService.Update(c.Request.Context(), id, req)
+	version, err := expectedVersion(c)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	todo, err := h.todoService.Update(c.Request.Context(), actor, id, req, version)
 	if err != nil {
-		c.JSON(http.StatusNotFound, domain.APIResponse{
+		apierror.Respond(c, err)
+		return
+	}
+
+	setTodoETag(c, todo)
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    todo,
+	})
+}
+
+// MoveTodo godoc
+// @Summary Move todo
+// @Description Reorder a todo by naming the todo that should come right before it, right after it, or both. Use sort=position to list todos in this order.
+// @Tags todos
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Param request body domain.MoveTodoRequest true "New neighbours"
+// @Param If-Match header string false "Only apply the change if the todo still has this ETag"
+// @Success 200 {object} domain.APIResponse{data=domain.Todo}
+// @Header 200 {string} ETag "New todo version"
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 412 {object} domain.APIResponse{error=domain.APIError}
+// @Router /todos/{id}/move [post]
+func (h *TodoHandler) MoveTodo(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid todo ID",
+			},
+		})
+		return
+	}
+
+	var req domain.MoveTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
 			Success: false,
 			Error: &domain.APIError{
-				Code:    domain.ErrCodeTodoNotFound,
-				Message: "Todo not found",
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
 			},
 		})
 		return
 	}
 
+	version, err := expectedVersion(c)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	todo, err := h.todoService.Move(c.Request.Context(), actor, id, req, version)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	setTodoETag(c, todo)
 	c.JSON(http.StatusOK, domain.APIResponse{
 		Success: true,
 		Data:    todo,
@@ -231,14 +396,16 @@ func (h *TodoHandler) UpdateTodo(c *gin.Context) {
 
 // DeleteTodo godoc
 // @Summary Delete todo
-// @Description Delete a todo by ID
+// @Description Move a todo and its subtasks to the trash. Trashed todos can be restored until they are purged.
 // @Tags todos
 // @Security BearerAuth
 // @Produce json
 // @Param id path string true "Todo ID"
+// @Param If-Match header string false "Only delete the todo if it still has this ETag"
 // @Success 204
 // @Failure 400 {object} domain.APIResponse{error=domain.APIError}
 // @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 412 {object} domain.APIResponse{error=domain.APIError}
 // @Router /todos/{id} [delete]
 func (h *TodoHandler) DeleteTodo(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
@@ -253,59 +420,381 @@ func (h *TodoHandler) DeleteTodo(c *gin.Context) {
 		return
 	}
 
-	err = h.todoService.Delete(c.Request.Context(), id)
-	if err != nil {
-		c.JSON(http.StatusNotFound, domain.APIResponse{
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
 			Success: false,
 			Error: &domain.APIError{
-				Code:    domain.ErrCodeTodoNotFound,
-				Message: "Todo not found",
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
 			},
 		})
 		return
 	}
 
+	version, err := expectedVersion(c)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	err = h.todoService.Delete(c.Request.Context(), actor, id, version)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
 	c.Status(http.StatusNoContent)
 }
 
-// GetAllTodos godoc
-// @Summary Get all todos (admin)
-// @Description Get paginated list of all todos (admin endpoint)
+// BulkTodos godoc
+// @Summary Bulk todo operations
+// @Description Apply a batch of create, update and delete operations. In atomic mode (default) the batch is rolled back at the first failure and the response status is that operation's error status; in best_effort mode every operation is applied independently. Either way the response lists one result per operation.
+// @Tags todos
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body domain.BulkTodoRequest true "Operations"
+// @Success 200 {object} domain.APIResponse{data=domain.BulkTodoResponse}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError,data=domain.BulkTodoResponse}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError,data=domain.BulkTodoResponse}
+// @Failure 412 {object} domain.APIResponse{error=domain.APIError,data=domain.BulkTodoResponse}
+// @Router /todos/bulk [post]
+func (h *TodoHandler) BulkTodos(c *gin.Context) {
+	var req domain.BulkTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	resp, err := h.todoService.Bulk(c.Request.Context(), actor, req)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	for i := range resp.Results {
+		if resp.Results[i].Err != nil {
+			_, resp.Results[i].Error = apierror.Describe(c, resp.Results[i].Err)
+		}
+	}
+
+	if !resp.Committed {
+		failed := resp.Results[*resp.FailedIndex]
+		status, _ := apierror.From(failed.Err)
+		c.JSON(status, domain.APIResponse{
+			Success: false,
+			Data:    resp,
+			Error:   failed.Error,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// GetTrash godoc
+// @Summary Get trashed todos
+// @Description Get paginated list of the current user's deleted todos, most recently deleted first
 // @Tags todos
 // @Security BearerAuth
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param page_size query int false "Page size" default(10)
+// @Param q query string false "Full-text search over title and description"
 // @Success 200 {object} domain.APIResponse{data=domain.PaginatedResponse}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
 // @Failure 401 {object} domain.APIResponse{error=domain.APIError}
-// @Router /admin/todos [get]
-func (h *TodoHandler) GetAllTodos(c *gin.Context) {
+// @Router /todos/trash [get]
+func (h *TodoHandler) GetTrash(c *gin.Context) {
 	var pagination domain.PaginationQuery
 	if err := c.ShouldBindQuery(&pagination); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	var filter domain.TodoFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+	filter.Trashed = true
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	resp, err := h.todoService.GetByUserID(c.Request.Context(), userID.(uuid.UUID), filter, pagination)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// RestoreTodo godoc
+// @Summary Restore todo
+// @Description Move a todo out of the trash, along with the subtasks deleted together with it. Fails with 409 while its parent is in the trash.
+// @Tags todos
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Success 200 {object} domain.APIResponse{data=domain.Todo}
+// @Header 200 {string} ETag "New todo version"
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 409 {object} domain.APIResponse{error=domain.APIError}
+// @Router /todos/{id}/restore [post]
+func (h *TodoHandler) RestoreTodo(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
 		c.JSON(http.StatusBadRequest, domain.APIResponse{
 			Success: false,
 			Error: &domain.APIError{
 				Code:    domain.ErrCodeInvalidRequest,
-				Message: err.Error(),
+				Message: "Invalid todo ID",
 			},
 		})
 		return
 	}
 
-	resp, err := h.todoService.List(c.Request.Context(), pagination)
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	todo, err := h.todoService.Restore(c.Request.Context(), actor, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
+		apierror.Respond(c, err)
+		return
+	}
+
+	setTodoETag(c, todo)
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    todo,
+	})
+}
+
+// GetSubtasks godoc
+// @Summary Get subtasks
+// @Description Get paginated list of a todo's direct subtasks. Supports the same filters and sorting as GET /todos.
+// @Tags todos
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Param completed query bool false "Only completed (true) or open (false) subtasks"
+// @Param sort query string false "Comma-separated sort keys, prefix with - for descending" example(title)
+// @Success 200 {object} domain.APIResponse{data=domain.PaginatedResponse}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /todos/{id}/subtasks [get]
+func (h *TodoHandler) GetSubtasks(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
 			Success: false,
 			Error: &domain.APIError{
-				Code:    domain.ErrCodeInternalError,
-				Message: "Failed to get todos",
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid todo ID",
 			},
 		})
 		return
 	}
 
+	var pagination domain.PaginationQuery
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	var filter domain.TodoFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	resp, err := h.todoService.GetSubtasks(c.Request.Context(), actor, id, filter, pagination)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, domain.APIResponse{
 		Success: true,
 		Data:    resp,
 	})
 }
+
+// GetOccurrences godoc
+// @Summary Get upcoming occurrences
+// @Description List when the next occurrences of a recurring todo will be due, up to and including until. Empty for todos that don't recur.
+// @Tags todos
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Todo ID"
+// @Param until query string true "Last date to include (RFC 3339)" example(2025-12-31T23:59:59Z)
+// @Param limit query int false "Maximum number of occurrences" default(10)
+// @Success 200 {object} domain.APIResponse{data=[]string}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /todos/{id}/occurrences [get]
+func (h *TodoHandler) GetOccurrences(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid todo ID",
+			},
+		})
+		return
+	}
+
+	var query domain.OccurrenceQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	actor, exists := actorFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	occurrences, err := h.todoService.GetOccurrences(c.Request.Context(), actor, id, query)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    occurrences,
+	})
+}
+
+// GetAllTodos godoc
+// @Summary Get all todos (admin)
+// @Description Get paginated list of all todos (admin endpoint). Supports the same filters and pagination modes as GET /todos.
+// @Tags todos
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Param completed query bool false "Only completed (true) or open (false) todos"
+// @Param q query string false "Full-text search over title and description; results are ranked by relevance unless sort is given"
+// @Param sort query string false "Comma-separated sort keys, prefix with - for descending: created_at, updated_at, title, completed, priority, due_at, position" example(-updated_at,title)
+// @Param created_after query string false "Only todos created at or after this RFC 3339 time"
+// @Param created_before query string false "Only todos created before this RFC 3339 time"
+// @Param priority query string false "Only todos with this priority" Enums(low, medium, high)
+// @Param due_after query string false "Only todos due at or after this RFC 3339 time"
+// @Param due_before query string false "Only todos due before this RFC 3339 time"
+// @Param overdue query bool false "Only todos that are past due and not completed (true), or all others (false)"
+// @Param tag query []string false "Only todos with these tag names (case-insensitive); repeat for several" collectionFormat(multi)
+// @Param tag_mode query string false "Whether a todo needs all listed tags or any of them" Enums(all, any) default(all)
+// @Param cursor query string false "Cursor from a previous response; switches to cursor pagination"
+// @Param limit query int false "Page size for cursor pagination; switches to cursor pagination" default(10)
+// @Success 200 {object} domain.APIResponse{data=domain.PaginatedResponse} "data is a domain.CursorPaginatedResponse when cursor or limit is given"
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Router /admin/todos [get]
+func (h *TodoHandler) GetAllTodos(c *gin.Context) {
+	var filter domain.TodoFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	var resp interface{}
+	var err error
+	if usesCursor(c) {
+		var page domain.CursorQuery
+		if err := c.ShouldBindQuery(&page); err != nil {
+			apierror.RespondInvalid(c, err)
+			return
+		}
+		resp, err = h.todoService.ListCursor(c.Request.Context(), filter, page)
+	} else {
+		var pagination domain.PaginationQuery
+		if err := c.ShouldBindQuery(&pagination); err != nil {
+			apierror.RespondInvalid(c, err)
+			return
+		}
+		resp, err = h.todoService.List(c.Request.Context(), filter, pagination)
+	}
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// usesCursor reports whether a listing request asks for cursor pagination
+// rather than page/page_size.
+func usesCursor(c *gin.Context) bool {
+	_, hasCursor := c.GetQuery("cursor")
+	_, hasLimit := c.GetQuery("limit")
+	return hasCursor || hasLimit
+}
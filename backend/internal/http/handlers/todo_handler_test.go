@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// fakeTodoService serves a single todo
+type fakeTodoService struct {
+	service.TodoService
+	todo domain.Todo
+}
+
+func (s *fakeTodoService) GetByID(ctx context.Context, actor domain.Actor, id uuid.UUID) (*domain.Todo, error) {
+	if id != s.todo.ID {
+		return nil, domain.ErrTodoNotFound
+	}
+	todo := s.todo
+	return &todo, nil
+}
+
+func TestTodoHandlerGetTodoETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	owner := uuid.New()
+	tag := domain.Tag{ID: uuid.New(), UserID: owner, Name: "work"}
+	todos := &fakeTodoService{todo: domain.Todo{
+		ID:       uuid.New(),
+		Title:    "Ship it",
+		UserID:   owner,
+		Version:  3,
+		Tags:     []domain.Tag{tag},
+		Progress: domain.TodoProgress{Completed: 0, Total: 2},
+	}}
+
+	router := gin.New()
+	router.GET("/todos/:id", func(c *gin.Context) {
+		c.Set("user_id", owner)
+		c.Set("role", domain.RoleUser)
+	}, NewTodoHandler(todos).GetTodo)
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/todos/"+todos.todo.ID.String(), nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("got %d with ETag %q, want 200 with an ETag", first.Code, etag)
+	}
+	if rec := get(etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("unchanged todo: got %d, want 304", rec.Code)
+	}
+	if rec := get(`"1-0000000000000000", W/` + etag); rec.Code != http.StatusNotModified {
+		t.Fatalf("weak match in a list: got %d, want 304", rec.Code)
+	}
+
+	// Neither change bumps the todo's own version
+	changes := map[string]func(todo *domain.Todo){
+		"subtask completed": func(todo *domain.Todo) { todo.Progress.Completed++ },
+		"tag renamed":       func(todo *domain.Todo) { todo.Tags = []domain.Tag{{ID: tag.ID, UserID: owner, Name: "office"}} },
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			before := todos.todo
+			defer func() { todos.todo = before }()
+			todos.todo.Tags = append([]domain.Tag(nil), before.Tags...)
+			change(&todos.todo)
+
+			rec := get(etag)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("got %d, want 200 with the changed todo", rec.Code)
+			}
+			if changed := rec.Header().Get("ETag"); changed == etag {
+				t.Fatalf("got the old ETag %s for a changed todo", etag)
+			}
+		})
+	}
+}
+
+func TestExpectedVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		ifMatch string
+		want    int
+		wantErr error
+	}{
+		{ifMatch: "", want: 0},
+		{ifMatch: "*", want: 0},
+		{ifMatch: todoETag(&domain.Todo{Version: 3}), want: 3},
+		{ifMatch: `"3"`, want: 3},
+		{ifMatch: `W/"3"`, wantErr: domain.ErrTodoVersionMismatch},
+		{ifMatch: `"0-abc"`, wantErr: domain.ErrTodoVersionMismatch},
+		{ifMatch: `"x-abc"`, wantErr: domain.ErrTodoVersionMismatch},
+		{ifMatch: `3`, wantErr: domain.ErrValidation},
+		{ifMatch: `"3", "4"`, wantErr: domain.ErrValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ifMatch, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPatch, "/", nil)
+			c.Request.Header.Set("If-Match", tt.ifMatch)
+
+			got, err := expectedVersion(c)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("got %d, %v, want %d", got, err, tt.want)
+			}
+		})
+	}
+}
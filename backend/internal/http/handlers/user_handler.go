@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"net/http"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/http/apierror"
+	"template-fullstack/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type UserHandler struct {
+	userService service.UserService
+}
+
+func NewUserHandler(userService service.UserService) *UserHandler {
+	return &UserHandler{userService: userService}
+}
+
+// GetMe godoc
+// @Summary Get current user
+// @Description Get the profile of the authenticated user
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} domain.APIResponse{data=domain.User}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /users/me [get]
+func (h *UserHandler) GetMe(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	user, err := h.userService.GetByID(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    user,
+	})
+}
+
+// UpdateMe godoc
+// @Summary Update current user
+// @Description Update the name and/or email of the authenticated user
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body domain.UpdateUserRequest true "Profile update data"
+// @Success 200 {object} domain.APIResponse{data=domain.User}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 409 {object} domain.APIResponse{error=domain.APIError}
+// @Router /users/me [patch]
+func (h *UserHandler) UpdateMe(c *gin.Context) {
+	var req domain.UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	user, err := h.userService.Update(c.Request.Context(), userID.(uuid.UUID), req)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    user,
+	})
+}
+
+// DeleteMe godoc
+// @Summary Delete current user
+// @Description Delete the authenticated user's account and all of their todos
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Success 204
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /users/me [delete]
+func (h *UserHandler) DeleteMe(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeUnauthorized,
+				Message: "User not authenticated",
+			},
+		})
+		return
+	}
+
+	// Todos are removed by the ON DELETE CASCADE foreign key
+	if err := h.userService.Delete(c.Request.Context(), userID.(uuid.UUID)); err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListUsers godoc
+// @Summary List users (admin)
+// @Description Get paginated list of all users (admin endpoint)
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Success 200 {object} domain.APIResponse{data=domain.PaginatedResponse}
+// @Failure 401 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 403 {object} domain.APIResponse{error=domain.APIError}
+// @Router /admin/users [get]
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	var pagination domain.PaginationQuery
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	resp, err := h.userService.List(c.Request.Context(), pagination)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    resp,
+	})
+}
+
+// UpdateUserRole godoc
+// @Summary Change a user's role (admin)
+// @Description Set the role of a user. Existing sessions of that user are invalidated.
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body domain.UpdateRoleRequest true "New role"
+// @Success 200 {object} domain.APIResponse{data=domain.User}
+// @Failure 400 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 403 {object} domain.APIResponse{error=domain.APIError}
+// @Failure 404 {object} domain.APIResponse{error=domain.APIError}
+// @Router /admin/users/{id}/role [put]
+func (h *UserHandler) UpdateUserRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeInvalidRequest,
+				Message: "Invalid user ID",
+			},
+		})
+		return
+	}
+
+	var req domain.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondInvalid(c, err)
+		return
+	}
+
+	user, err := h.userService.SetRole(c.Request.Context(), id, req.Role)
+	if err != nil {
+		apierror.Respond(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.APIResponse{
+		Success: true,
+		Data:    user,
+	})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// meRouter serves /users/me as userID, or unauthenticated if userID is nil
+func meRouter(users *fakeUserService, userID *uuid.UUID) *gin.Engine {
+	handler := NewUserHandler(users)
+	router := gin.New()
+	me := router.Group("/users/me", func(c *gin.Context) {
+		if userID != nil {
+			c.Set("user_id", *userID)
+		}
+	})
+	me.GET("", handler.GetMe)
+	me.PATCH("", handler.UpdateMe)
+	me.DELETE("", handler.DeleteMe)
+	return router
+}
+
+func TestUserHandlerMe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	users := newFakeUserService()
+	ada := domain.User{ID: uuid.New(), Email: "ada@example.com", Name: "Ada", Role: domain.RoleUser}
+	users.users[ada.ID] = ada
+	router := meRouter(users, &ada.ID)
+
+	var me domain.User
+	status, _ := serve(t, router, http.MethodGet, "/users/me", nil, &me)
+	if status != http.StatusOK || me.ID != ada.ID || me.Email != ada.Email {
+		t.Fatalf("GET: got %d %+v, want %+v", status, me, ada)
+	}
+
+	var updated domain.User
+	status, _ = serve(t, router, http.MethodPatch, "/users/me", domain.UpdateUserRequest{Name: "Ada Lovelace"}, &updated)
+	if status != http.StatusOK || updated.Name != "Ada Lovelace" || updated.Email != ada.Email {
+		t.Fatalf("PATCH: got %d %+v, want only the name changed", status, updated)
+	}
+
+	status, resp := serve(t, router, http.MethodPatch, "/users/me", domain.UpdateUserRequest{Email: "not-an-email"}, nil)
+	if status != http.StatusBadRequest || resp.Error.Code != domain.ErrCodeInvalidRequest {
+		t.Fatalf("PATCH invalid email: got %d %+v, want 400", status, resp.Error)
+	}
+	if users.users[ada.ID].Email != ada.Email {
+		t.Fatal("the invalid email was saved")
+	}
+
+	status, _ = serve(t, router, http.MethodDelete, "/users/me", nil, nil)
+	if status != http.StatusNoContent {
+		t.Fatalf("DELETE: got %d, want 204", status)
+	}
+	if _, ok := users.users[ada.ID]; ok {
+		t.Fatal("the user was not deleted")
+	}
+
+	// The deleted user's token is still valid until it expires
+	status, resp = serve(t, router, http.MethodGet, "/users/me", nil, nil)
+	if status != http.StatusNotFound || resp.Error.Code != domain.ErrCodeUserNotFound {
+		t.Fatalf("GET after delete: got %d %+v, want 404", status, resp.Error)
+	}
+}
+
+func TestUserHandlerMeUnauthenticated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := meRouter(newFakeUserService(), nil)
+	for _, method := range []string{http.MethodGet, http.MethodPatch, http.MethodDelete} {
+		status, resp := serve(t, router, method, "/users/me", domain.UpdateUserRequest{Name: "Ada"}, nil)
+		if status != http.StatusUnauthorized || resp.Error.Code != domain.ErrCodeUnauthorized {
+			t.Errorf("%s: got %d %+v, want 401", method, status, resp.Error)
+		}
+	}
+}
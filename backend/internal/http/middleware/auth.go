@@ -10,7 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(authService service.AuthService) gin.HandlerFunc {
+func AuthMiddleware(authService service.AuthService, revocations service.TokenRevocationStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -53,7 +53,7 @@ func AuthMiddleware(authService service.AuthService) gin.HandlerFunc {
 		}
 
 		// Validate token
-		claims, err := authService.ValidateToken(token)
+		claims, err := authService.ValidateToken(c.Request.Context(), token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, domain.APIResponse{
 				Success: false,
@@ -66,10 +66,65 @@ func AuthMiddleware(authService service.AuthService) gin.HandlerFunc {
 			return
 		}
 
+		// Reject tokens that were logged out before they expired
+		revoked, err := revocations.IsRevoked(c.Request.Context(), claims.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, domain.APIResponse{
+				Success: false,
+				Error: &domain.APIError{
+					Code:    domain.ErrCodeInternalError,
+					Message: "Failed to validate token",
+				},
+			})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, domain.APIResponse{
+				Success: false,
+				Error: &domain.APIError{
+					Code:    domain.ErrCodeUnauthorized,
+					Message: "Token has been revoked",
+				},
+			})
+			c.Abort()
+			return
+		}
+
 		// Set user info in context
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
+		c.Set("role", claims.Role)
+		c.Set("claims", claims)
+
+		// Attribute changes made in this request to the user
+		info := domain.RequestInfoFromContext(c.Request.Context())
+		info.ActorID = &claims.UserID
+		c.Request = c.Request.WithContext(domain.WithRequestInfo(c.Request.Context(), info))
 
 		c.Next()
 	}
 }
+
+// RequireRole allows the request through only if AuthMiddleware has placed one
+// of the given roles in the context. It must be registered after AuthMiddleware.
+func RequireRole(roles ...domain.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, domain.APIResponse{
+			Success: false,
+			Error: &domain.APIError{
+				Code:    domain.ErrCodeForbidden,
+				Message: "Insufficient permissions",
+			},
+		})
+		c.Abort()
+	}
+}
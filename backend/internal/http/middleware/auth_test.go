@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		role       interface{}
+		wantStatus int
+	}{
+		{name: "admin", role: domain.RoleAdmin, wantStatus: http.StatusOK},
+		{name: "user", role: domain.RoleUser, wantStatus: http.StatusForbidden},
+		{name: "no role", wantStatus: http.StatusForbidden},
+		// The role must be a domain.Role, as AuthMiddleware sets it
+		{name: "untyped role", role: "admin", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			router := gin.New()
+			router.GET("/admin",
+				func(c *gin.Context) {
+					if tt.role != nil {
+						c.Set("role", tt.role)
+					}
+				},
+				RequireRole(domain.RoleAdmin),
+				func(c *gin.Context) {
+					reached = true
+					c.Status(http.StatusOK)
+				},
+			)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if reached != (tt.wantStatus == http.StatusOK) {
+				t.Fatalf("handler reached: got %v, want %v", reached, !reached)
+			}
+			if tt.wantStatus != http.StatusForbidden {
+				return
+			}
+
+			var resp domain.APIResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Success || resp.Error == nil || resp.Error.Code != domain.ErrCodeForbidden {
+				t.Fatalf("got response %+v, want a %s error", resp, domain.ErrCodeForbidden)
+			}
+		})
+	}
+}
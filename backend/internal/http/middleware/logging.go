@@ -39,6 +39,7 @@ func StructuredLoggingMiddleware(log zerolog.Logger) gin.HandlerFunc {
 		}
 
 		logEvent.
+			Str("request_id", c.GetString("request_id")).
 			Str("method", c.Request.Method).
 			Str("path", c.Request.URL.Path).
 			Str("query", c.Request.URL.RawQuery).
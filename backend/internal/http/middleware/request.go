@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID. A proxy may set it; otherwise one
+// is generated. It is echoed in the response.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// RequestInfoMiddleware stores the request ID and client IP in the context,
+// as "request_id" and in the request context as domain.RequestInfo for the
+// audit log. AuthMiddleware adds the signed-in user.
+func RequestInfoMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(domain.WithRequestInfo(c.Request.Context(), domain.RequestInfo{
+			RequestID: requestID,
+			IP:        c.ClientIP(),
+		}))
+
+		c.Next()
+	}
+}
+
+// validRequestID accepts IDs of printable ASCII up to maxRequestIDLength
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -5,9 +5,13 @@ import (
 	"time"
 
 	"template-fullstack/backend/internal/config"
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/http/apierror"
 	"template-fullstack/backend/internal/http/handlers"
 	"template-fullstack/backend/internal/http/middleware"
+	"template-fullstack/backend/internal/pkg/cursor"
 	"template-fullstack/backend/internal/pkg/db"
+	"template-fullstack/backend/internal/pkg/storage"
 	"template-fullstack/backend/internal/repository"
 	"template-fullstack/backend/internal/service"
 
@@ -17,16 +21,24 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func New(cfg *config.Config, database *db.DB, log zerolog.Logger) *gin.Engine {
+func New(cfg *config.Config, database *db.DB, store storage.BlobStore, log zerolog.Logger) *gin.Engine {
 	// Set Gin mode
 	if cfg.App.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	r := gin.New()
+	// Believe X-Forwarded-For only from the configured proxies, so clients
+	// cannot choose the IP recorded in logs and the audit log. The entries
+	// are validated by config.Load.
+	_ = r.SetTrustedProxies(cfg.App.TrustedProxies)
+
+	// Report validation errors with JSON field names
+	apierror.RegisterTagNames()
 
 	// Middleware
 	r.Use(gin.Recovery())
+	r.Use(middleware.RequestInfoMiddleware())
 	r.Use(middleware.StructuredLoggingMiddleware(log))
 	r.Use(middleware.ErrorHandlingMiddleware())
 	r.Use(middleware.CORSMiddleware(cfg.CORS.Origins))
@@ -42,16 +54,46 @@ func New(cfg *config.Config, database *db.DB, log zerolog.Logger) *gin.Engine {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(database)
 	todoRepo := repository.NewTodoRepository(database)
+	tagRepo := repository.NewTagRepository(database)
+	projectRepo := repository.NewProjectRepository(database)
+	projectMemberRepo := repository.NewProjectMemberRepository(database)
+	commentRepo := repository.NewCommentRepository(database)
+	attachmentRepo := repository.NewAttachmentRepository(database)
+	auditRepo := repository.NewAuditRepository(database)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(database)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(database)
 
 	// Initialize services
-	jwtExpiry, _ := time.ParseDuration(cfg.JWT.Expiry)
-	authService := service.NewAuthService(userRepo, cfg.JWT.Secret, jwtExpiry)
-	// userService := service.NewUserService(userRepo) // TODO: Add user management handlers
-	todoService := service.NewTodoService(todoRepo)
+	jwtExpiry, _ := time.ParseDuration(cfg.JWT.AccessExpiry)
+	refreshExpiry, _ := time.ParseDuration(cfg.JWT.RefreshExpiry)
+	invitationTTL, _ := time.ParseDuration(cfg.Invitation.TTL)
+	attachmentURLTTL, _ := time.ParseDuration(cfg.Attachment.URLTTL)
+	passwordHasher := service.NewPasswordHasher(cfg.Password)
+	revocationStore := service.NewTokenRevocationStore(revokedTokenRepo, userRepo, cfg.JWT.RevocationCacheSize)
+	authService := service.NewAuthService(userRepo, refreshTokenRepo, revocationStore, passwordHasher, cfg.JWT.Secret, jwtExpiry, refreshExpiry)
+	userService := service.NewUserService(userRepo, passwordHasher, revocationStore)
+	todoService := service.NewTodoService(todoRepo, cursor.NewCodec(cfg.Pagination.CursorSecret), cfg.Bulk.MaxOperations)
+	tagService := service.NewTagService(tagRepo)
+	projectService := service.NewProjectService(projectRepo, projectMemberRepo, todoRepo, userRepo,
+		service.LogInvitationNotifier{Log: log}, invitationTTL)
+	commentService := service.NewCommentService(commentRepo, todoRepo)
+	auditService := service.NewAuditService(auditRepo, todoRepo)
+	attachmentService := service.NewAttachmentService(attachmentRepo, todoRepo, store, cursor.NewCodec(cfg.Attachment.URLSecret), service.AttachmentOptions{
+		MaxSize:      int64(cfg.Attachment.MaxSize),
+		AllowedTypes: cfg.Attachment.AllowedTypes,
+		URLTTL:       attachmentURLTTL,
+		DownloadBase: "/api/v1/attachments",
+	})
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService)
+	authHandler := handlers.NewAuthHandler(authService, userService)
+	userHandler := handlers.NewUserHandler(userService)
 	todoHandler := handlers.NewTodoHandler(todoService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	projectHandler := handlers.NewProjectHandler(projectService)
+	commentHandler := handlers.NewCommentHandler(commentService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService, int64(cfg.Attachment.MaxSize))
+	auditHandler := handlers.NewAuditHandler(auditService)
 
 	// Swagger documentation
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -63,26 +105,109 @@ func New(cfg *config.Config, database *db.DB, log zerolog.Logger) *gin.Engine {
 	// Auth routes (public)
 	auth := v1.Group("/auth")
 	{
+		auth.POST("/register", authHandler.Register)
 		auth.POST("/login", authHandler.Login)
-		auth.POST("/refresh", middleware.AuthMiddleware(authService), authHandler.Refresh)
+		auth.POST("/refresh", authHandler.Refresh)
+		auth.POST("/logout", middleware.AuthMiddleware(authService, revocationStore), authHandler.Logout)
+		auth.POST("/logout-all", middleware.AuthMiddleware(authService, revocationStore), authHandler.LogoutAll)
+	}
+
+	// User routes (protected)
+	users := v1.Group("/users")
+	users.Use(middleware.AuthMiddleware(authService, revocationStore))
+	{
+		users.GET("/me", userHandler.GetMe)
+		users.PATCH("/me", userHandler.UpdateMe)
+		users.DELETE("/me", userHandler.DeleteMe)
 	}
 
 	// Todo routes (protected)
 	todos := v1.Group("/todos")
-	todos.Use(middleware.AuthMiddleware(authService))
+	todos.Use(middleware.AuthMiddleware(authService, revocationStore))
 	{
 		todos.POST("", todoHandler.CreateTodo)
 		todos.GET("", todoHandler.GetTodos)
+		todos.POST("/bulk", todoHandler.BulkTodos)
+		todos.GET("/trash", todoHandler.GetTrash)
 		todos.GET("/:id", todoHandler.GetTodo)
 		todos.PUT("/:id", todoHandler.UpdateTodo)
+		todos.PATCH("/:id", todoHandler.PatchTodo)
 		todos.DELETE("/:id", todoHandler.DeleteTodo)
+		todos.POST("/:id/restore", todoHandler.RestoreTodo)
+		todos.POST("/:id/move", todoHandler.MoveTodo)
+		todos.GET("/:id/subtasks", todoHandler.GetSubtasks)
+		todos.GET("/:id/occurrences", todoHandler.GetOccurrences)
+		todos.GET("/:id/history", auditHandler.GetTodoHistory)
+		todos.GET("/:id/comments", commentHandler.GetComments)
+		todos.POST("/:id/comments", commentHandler.CreateComment)
+		todos.GET("/:id/attachments", attachmentHandler.GetAttachments)
+		todos.POST("/:id/attachments", attachmentHandler.UploadAttachment)
+	}
+
+	// Comment routes (protected)
+	comments := v1.Group("/comments")
+	comments.Use(middleware.AuthMiddleware(authService, revocationStore))
+	{
+		comments.PATCH("/:id", commentHandler.UpdateComment)
+		comments.DELETE("/:id", commentHandler.DeleteComment)
+	}
+
+	// Attachment routes (protected)
+	attachments := v1.Group("/attachments")
+	attachments.Use(middleware.AuthMiddleware(authService, revocationStore))
+	{
+		attachments.GET("/:id", attachmentHandler.GetAttachment)
+		attachments.DELETE("/:id", attachmentHandler.DeleteAttachment)
+	}
+	// Downloads are authorized by the signed token in the URL instead
+	v1.GET("/attachments/:id/download", attachmentHandler.DownloadAttachment)
+
+	// Tag routes (protected)
+	tags := v1.Group("/tags")
+	tags.Use(middleware.AuthMiddleware(authService, revocationStore))
+	{
+		tags.POST("", tagHandler.CreateTag)
+		tags.GET("", tagHandler.GetTags)
+		tags.GET("/:id", tagHandler.GetTag)
+		tags.PATCH("/:id", tagHandler.UpdateTag)
+		tags.DELETE("/:id", tagHandler.DeleteTag)
+	}
+
+	// Project routes (protected)
+	projects := v1.Group("/projects")
+	projects.Use(middleware.AuthMiddleware(authService, revocationStore))
+	{
+		projects.POST("", projectHandler.CreateProject)
+		projects.GET("", projectHandler.GetProjects)
+		projects.GET("/:id", projectHandler.GetProject)
+		projects.PATCH("/:id", projectHandler.UpdateProject)
+		projects.DELETE("/:id", projectHandler.DeleteProject)
+		projects.GET("/:id/todos", projectHandler.GetProjectTodos)
+		projects.GET("/:id/members", projectHandler.GetMembers)
+		projects.PATCH("/:id/members/:user_id", projectHandler.UpdateMember)
+		projects.DELETE("/:id/members/:user_id", projectHandler.RemoveMember)
+		projects.POST("/:id/invitations", projectHandler.CreateInvitation)
+		projects.GET("/:id/invitations", projectHandler.GetInvitations)
+		projects.DELETE("/:id/invitations/:invitation_id", projectHandler.RevokeInvitation)
+	}
+
+	// Invitations to the current user (protected)
+	invitations := v1.Group("/invitations")
+	invitations.Use(middleware.AuthMiddleware(authService, revocationStore))
+	{
+		invitations.GET("", projectHandler.GetMyInvitations)
+		invitations.POST("/:id/accept", projectHandler.AcceptInvitation)
+		invitations.POST("/:id/decline", projectHandler.DeclineInvitation)
 	}
 
-	// Admin routes (protected)
+	// Admin routes (protected, admin role only)
 	admin := v1.Group("/admin")
-	admin.Use(middleware.AuthMiddleware(authService))
+	admin.Use(middleware.AuthMiddleware(authService, revocationStore), middleware.RequireRole(domain.RoleAdmin))
 	{
 		admin.GET("/todos", todoHandler.GetAllTodos)
+		admin.GET("/users", userHandler.ListUsers)
+		admin.PUT("/users/:id/role", userHandler.UpdateUserRole)
+		admin.GET("/audit", auditHandler.SearchAudit)
 	}
 
 	return r
@@ -0,0 +1,71 @@
+// Package cursor encodes pagination positions as opaque strings that clients
+// can hand back but not forge or modify.
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalid is returned by Decode for cursors that are malformed or whose
+// signature does not match.
+var ErrInvalid = errors.New("invalid cursor")
+
+// Codec signs cursors with HMAC-SHA256. A cursor is the base64url encoded
+// JSON payload followed by "." and the base64url encoded signature.
+type Codec struct {
+	secret []byte
+}
+
+func NewCodec(secret string) *Codec {
+	return &Codec{secret: []byte(secret)}
+}
+
+// Encode returns the signed cursor for payload
+func (c *Codec) Encode(payload interface{}) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data) + "." +
+		base64.RawURLEncoding.EncodeToString(c.sign(data)), nil
+}
+
+// Decode verifies cursor and unmarshals its payload into payload
+func (c *Codec) Decode(cursor string, payload interface{}) error {
+	encodedData, encodedSig, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return ErrInvalid
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encodedData)
+	if err != nil {
+		return ErrInvalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil {
+		return ErrInvalid
+	}
+
+	if !hmac.Equal(sig, c.sign(data)) {
+		return ErrInvalid
+	}
+
+	if err := json.Unmarshal(data, payload); err != nil {
+		return ErrInvalid
+	}
+
+	return nil
+}
+
+func (c *Codec) sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -46,7 +46,7 @@ func (db *DB) Close() {
 }
 
 // Transaction executes a function within a database transaction
-func (db *DB) Transaction(ctx context.Context, fn func(tx Querier) error) error {
+func (db *DB) Transaction(ctx context.Context, fn func(tx Querier) error) (err error) {
 	tx, err := db.Pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
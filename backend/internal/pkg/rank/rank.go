@@ -0,0 +1,112 @@
+// Package rank generates fractional index keys: strings whose byte order is
+// the order of the items they are attached to, and between any two of which
+// another key can always be generated. Moving an item only rewrites its own
+// key.
+//
+// Keys use the base-62 digits 0-9, A-Z and a-z and never end in '0', so they
+// must be compared bytewise (COLLATE "C" in Postgres).
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ErrInvalidRange is returned by Between when a does not sort before b or a
+// key is not well-formed
+var ErrInvalidRange = errors.New("invalid key range")
+
+// Between returns a key that sorts after a and before b. An empty a means
+// "before everything" and an empty b "after everything".
+func Between(a, b string) (string, error) {
+	if !valid(a) || !valid(b) || (b != "" && a >= b) {
+		return "", ErrInvalidRange
+	}
+	return midpoint(a, b), nil
+}
+
+// midpoint implements Between for well-formed keys. It keeps the common
+// prefix and picks a digit halfway between the first differing ones, going
+// one digit deeper when they are adjacent.
+func midpoint(a, b string) string {
+	// Shared prefix, reading a as if padded with zeros
+	n := 0
+	for n < len(b) && digitAt(a, n) == b[n] {
+		n++
+	}
+	if n > 0 {
+		rest := ""
+		if n < len(a) {
+			rest = a[n:]
+		}
+		return b[:n] + midpoint(rest, b[n:])
+	}
+
+	lo := strings.IndexByte(digits, digitAt(a, 0))
+	hi := len(digits)
+	if b != "" {
+		hi = strings.IndexByte(digits, b[0])
+	}
+	if hi-lo > 1 {
+		return string(digits[(lo+hi)/2])
+	}
+
+	// Adjacent digits: b's first digit alone sorts between a and b when b
+	// continues, otherwise extend a
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(digits[lo]) + midpoint(rest, "")
+}
+
+// Spread returns n keys in ascending order, evenly spaced and all of the
+// same short length, for rebalancing a list whose keys have grown long.
+func Spread(n int) []string {
+	// Leave room for about len(digits) insertions between neighbours
+	width := 1
+	space := uint64(len(digits))
+	for space/uint64(n+1) < uint64(len(digits)) {
+		width++
+		space *= uint64(len(digits))
+	}
+
+	step := space / uint64(n+1)
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = encode(uint64(i+1)*step, width)
+	}
+	return keys
+}
+
+// encode writes value as a base-62 number of width digits without trailing
+// zeros, which keeps the order of the values
+func encode(value uint64, width int) string {
+	buf := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		buf[i] = digits[value%uint64(len(digits))]
+		value /= uint64(len(digits))
+	}
+	return strings.TrimRight(string(buf), "0")
+}
+
+func digitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return digits[0]
+}
+
+func valid(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return !strings.HasSuffix(key, "0")
+}
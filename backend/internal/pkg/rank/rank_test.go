@@ -0,0 +1,83 @@
+package rank
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"", ""},
+		{"", "1"},
+		{"", "01"},
+		{"V", ""},
+		{"V", "W"},
+		{"V", "V1"},
+		{"Az", "B"},
+		{"zz", ""},
+		{"0000000001V", "0000000002V"},
+	}
+
+	for _, tt := range tests {
+		key, err := Between(tt.a, tt.b)
+		if err != nil {
+			t.Fatalf("Between(%q, %q): %v", tt.a, tt.b, err)
+		}
+		if key <= tt.a || (tt.b != "" && key >= tt.b) || !valid(key) {
+			t.Fatalf("Between(%q, %q) = %q", tt.a, tt.b, key)
+		}
+	}
+
+	for _, tt := range []struct{ a, b string }{{"B", "A"}, {"A", "A"}, {"A0", ""}, {"", "a-b"}} {
+		if _, err := Between(tt.a, tt.b); err != ErrInvalidRange {
+			t.Fatalf("Between(%q, %q) = %v, want ErrInvalidRange", tt.a, tt.b, err)
+		}
+	}
+}
+
+// Random insertions must keep every key distinct and in order
+func TestBetweenRandomInsertions(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	keys := []string{}
+
+	for i := 0; i < 2000; i++ {
+		pos := rng.Intn(len(keys) + 1)
+		var a, b string
+		if pos > 0 {
+			a = keys[pos-1]
+		}
+		if pos < len(keys) {
+			b = keys[pos]
+		}
+
+		key, err := Between(a, b)
+		if err != nil {
+			t.Fatalf("Between(%q, %q): %v", a, b, err)
+		}
+		keys = append(keys[:pos], append([]string{key}, keys[pos:]...)...)
+	}
+
+	if !sort.StringsAreSorted(keys) {
+		t.Fatal("keys are out of order")
+	}
+	for i := 1; i < len(keys); i++ {
+		if keys[i] == keys[i-1] {
+			t.Fatalf("duplicate key %q", keys[i])
+		}
+	}
+}
+
+func TestSpread(t *testing.T) {
+	for _, n := range []int{1, 2, 61, 62, 1000, 100000} {
+		keys := Spread(n)
+		if len(keys) != n {
+			t.Fatalf("Spread(%d) returned %d keys", n, len(keys))
+		}
+		for i, key := range keys {
+			if !valid(key) || key == "" || (i > 0 && key <= keys[i-1]) {
+				t.Fatalf("Spread(%d)[%d] = %q after %q", n, i, key, keys[max(i-1, 0)])
+			}
+		}
+	}
+}
@@ -0,0 +1,495 @@
+// Package rrule implements the subset of RFC 5545 recurrence rules used by
+// recurring todos: FREQ (DAILY, WEEKLY, MONTHLY, YEARLY) with INTERVAL, COUNT
+// or UNTIL, BYMONTH, BYMONTHDAY, BYDAY and WKST. Other rule parts, such as
+// BYSETPOS or BYHOUR, are rejected.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalid is wrapped by every error Parse returns
+var ErrInvalid = errors.New("invalid recurrence rule")
+
+// Frequency is the unit a rule repeats in
+type Frequency int
+
+const (
+	Daily Frequency = iota + 1
+	Weekly
+	Monthly
+	Yearly
+)
+
+var frequencyNames = map[Frequency]string{
+	Daily:   "DAILY",
+	Weekly:  "WEEKLY",
+	Monthly: "MONTHLY",
+	Yearly:  "YEARLY",
+}
+
+var weekdayNames = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Weekday is a BYDAY entry. N is zero for every such weekday, otherwise the
+// Nth one in the month, counted from the end when negative (-1FR is the last
+// Friday).
+type Weekday struct {
+	Day time.Weekday
+	N   int
+}
+
+// Rule is a parsed recurrence rule. Occurrences are computed from a start
+// time, the DTSTART of RFC 5545, which is always the first occurrence and
+// provides the time of day for all others.
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	// Count limits the number of occurrences, including the start
+	Count int
+	// Until is the last instant an occurrence may fall on
+	Until      *time.Time
+	ByMonth    []time.Month
+	ByMonthDay []int
+	ByDay      []Weekday
+	WeekStart  time.Weekday
+}
+
+const untilLayout = "20060102T150405Z"
+
+// Parse parses an RRULE value such as "FREQ=WEEKLY;BYDAY=MO,TH". A leading
+// "RRULE:" is accepted.
+func Parse(s string) (*Rule, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalid)
+	}
+
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalid, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %s given twice", ErrInvalid, name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq, err = parseFrequency(value)
+		case "INTERVAL":
+			r.Interval, err = parseInt(value, 1, 1000)
+		case "COUNT":
+			r.Count, err = parseInt(value, 1, 1000)
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "BYMONTH":
+			r.ByMonth, err = parseMonths(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseMonthDays(value)
+		case "BYDAY":
+			r.ByDay, err = parseWeekdays(value)
+		case "WKST":
+			r.WeekStart, err = parseWeekday(value)
+		default:
+			err = fmt.Errorf("%s is not supported", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalid, err)
+		}
+	}
+
+	if err := r.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalid, err)
+	}
+	return r, nil
+}
+
+func (r *Rule) validate() error {
+	if r.Freq == 0 {
+		return errors.New("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return errors.New("COUNT and UNTIL cannot be combined")
+	}
+	if r.Freq == Weekly && len(r.ByMonthDay) > 0 {
+		return errors.New("BYMONTHDAY cannot be used with FREQ=WEEKLY")
+	}
+
+	for _, day := range r.ByDay {
+		if day.N == 0 {
+			continue
+		}
+		// Numbered weekdays are only supported within a month
+		if r.Freq != Monthly && (r.Freq != Yearly || len(r.ByMonth) == 0) {
+			return errors.New("numbered BYDAY requires FREQ=MONTHLY, or FREQ=YEARLY with BYMONTH")
+		}
+	}
+
+	return nil
+}
+
+// String returns the rule in canonical form, without the "RRULE:" prefix
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + frequencyNames[r.Freq]}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayout))
+	}
+	if len(r.ByMonth) > 0 {
+		var months []string
+		for _, month := range r.ByMonth {
+			months = append(months, strconv.Itoa(int(month)))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		var days []string
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, day := range r.ByDay {
+			name := weekdayNames[day.Day]
+			if day.N != 0 {
+				name = strconv.Itoa(day.N) + name
+			}
+			days = append(days, name)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayNames[r.WeekStart])
+	}
+	return strings.Join(parts, ";")
+}
+
+// maxEmptyPeriods bounds the search for the next occurrence of a rule that
+// rarely or never matches, such as FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30
+const maxEmptyPeriods = 10000
+
+// Iterator yields the occurrences of a rule in chronological order
+type Iterator struct {
+	rule    *Rule
+	start   time.Time
+	period  int
+	pending []time.Time
+	emitted int
+	done    bool
+}
+
+// Iterator returns an iterator over the occurrences starting at start
+func (r *Rule) Iterator(start time.Time) *Iterator {
+	return &Iterator{rule: r, start: start, pending: []time.Time{start}}
+}
+
+// Next returns the next occurrence, or false once the rule is exhausted
+func (it *Iterator) Next() (time.Time, bool) {
+	empty := 0
+	for len(it.pending) == 0 && !it.done {
+		if empty == maxEmptyPeriods {
+			it.done = true
+			break
+		}
+		for _, t := range it.rule.expand(it.start, it.period) {
+			// The start was already returned as the first occurrence
+			if t.After(it.start) {
+				it.pending = append(it.pending, t)
+			}
+		}
+		it.period++
+		empty++
+	}
+	if it.done {
+		return time.Time{}, false
+	}
+
+	t := it.pending[0]
+	it.pending = it.pending[1:]
+	if (it.rule.Until != nil && t.After(*it.rule.Until)) || (it.rule.Count > 0 && it.emitted == it.rule.Count) {
+		it.done = true
+		return time.Time{}, false
+	}
+
+	it.emitted++
+	return t, true
+}
+
+// SkipTo advances the iterator close to t without expanding the periods in
+// between, so that iterating from a start long ago stays cheap. Occurrences
+// before t may still follow; callers filter them. Counted rules are not
+// skipped, because their count includes the skipped occurrences; COUNT is at
+// most 1000, which bounds iterating them.
+func (it *Iterator) SkipTo(t time.Time) {
+	if it.rule.Count > 0 {
+		return
+	}
+
+	// Periods don't align with t exactly, so stop one short of it
+	period := it.rule.periodsBetween(it.start, t) - 1
+	if period <= it.period {
+		return
+	}
+	// The pending occurrences all lie in earlier periods
+	it.period = period
+	it.pending = nil
+}
+
+// periodsBetween returns how many whole periods of the rule lie roughly
+// between start and t, counted in start's location
+func (r *Rule) periodsBetween(start, t time.Time) int {
+	t = t.In(start.Location())
+
+	var units int
+	switch r.Freq {
+	case Daily:
+		units = daysBetween(start, t)
+	case Weekly:
+		units = daysBetween(start, t) / 7
+	case Monthly:
+		units = (t.Year()-start.Year())*12 + int(t.Month()-start.Month())
+	case Yearly:
+		units = t.Year() - start.Year()
+	}
+	return units / r.Interval
+}
+
+// daysBetween returns the number of calendar days from a to b
+func daysBetween(a, b time.Time) int {
+	dateA := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	dateB := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(dateB.Sub(dateA).Hours() / 24)
+}
+
+// expand returns the sorted candidate occurrences of the given period, the
+// day, week, month or year that lies period*Interval units after start
+func (r *Rule) expand(start time.Time, period int) []time.Time {
+	step := period * r.Interval
+	year, month, day := start.Date()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	}
+
+	var candidates []time.Time
+	switch r.Freq {
+	case Daily:
+		t := at(year, month, day+step)
+		if r.matchesMonth(t.Month()) && r.matchesMonthDay(t) && r.matchesWeekday(t) {
+			candidates = append(candidates, t)
+		}
+
+	case Weekly:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := at(year, month, day-offset+7*step)
+		for i := 0; i < 7; i++ {
+			t := weekStart.AddDate(0, 0, i)
+			if !r.matchesMonth(t.Month()) {
+				continue
+			}
+			if len(r.ByDay) == 0 && t.Weekday() != start.Weekday() {
+				continue
+			}
+			if r.matchesWeekday(t) {
+				candidates = append(candidates, t)
+			}
+		}
+
+	case Monthly:
+		first := at(year, month+time.Month(step), 1)
+		if r.matchesMonth(first.Month()) {
+			candidates = r.monthDays(first, day)
+		}
+
+	case Yearly:
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{month}
+			if len(r.ByMonthDay) > 0 || len(r.ByDay) > 0 {
+				months = []time.Month{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+			}
+		}
+		for _, m := range months {
+			candidates = append(candidates, r.monthDays(at(year+step, m, 1), day)...)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	return candidates
+}
+
+// monthDays returns the occurrences in the month starting at first. Without
+// BYMONTHDAY and BYDAY that is startDay, skipped in months too short for it.
+func (r *Rule) monthDays(first time.Time, startDay int) []time.Time {
+	length := daysIn(first)
+
+	var days []time.Time
+	for d := 1; d <= length; d++ {
+		t := first.AddDate(0, 0, d-1)
+		if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+			if d == startDay {
+				days = append(days, t)
+			}
+			continue
+		}
+		if r.matchesMonthDay(t) && r.matchesWeekday(t) {
+			days = append(days, t)
+		}
+	}
+	return days
+}
+
+func (r *Rule) matchesMonth(month time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	length := daysIn(t)
+	for _, d := range r.ByMonthDay {
+		if d < 0 {
+			d += length + 1
+		}
+		if d == t.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesWeekday(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	// Which occurrence of its weekday t is in its month, from either end
+	nth := (t.Day()-1)/7 + 1
+	nthLast := -((daysIn(t)-t.Day())/7 + 1)
+	for _, day := range r.ByDay {
+		if day.Day == t.Weekday() && (day.N == 0 || day.N == nth || day.N == nthLast) {
+			return true
+		}
+	}
+	return false
+}
+
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func parseFrequency(value string) (Frequency, error) {
+	for freq, name := range frequencyNames {
+		if name == value {
+			return freq, nil
+		}
+	}
+	return 0, fmt.Errorf("FREQ=%s is not supported", value)
+}
+
+func parseInt(value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%q must be a number from %d to %d", value, min, max)
+	}
+	return n, nil
+}
+
+func parseUntil(value string) (*time.Time, error) {
+	if t, err := time.Parse(untilLayout, value); err == nil {
+		return &t, nil
+	}
+	// A date means the end of that day
+	if t, err := time.Parse("20060102", value); err == nil {
+		t = t.Add(24*time.Hour - time.Second)
+		return &t, nil
+	}
+	return nil, fmt.Errorf("UNTIL=%s must be a date or a UTC date-time", value)
+}
+
+func parseMonths(value string) ([]time.Month, error) {
+	var months []time.Month
+	for _, item := range strings.Split(value, ",") {
+		n, err := parseInt(item, 1, 12)
+		if err != nil {
+			return nil, err
+		}
+		months = append(months, time.Month(n))
+	}
+	return months, nil
+}
+
+func parseMonthDays(value string) ([]int, error) {
+	var days []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := parseInt(strings.TrimPrefix(item, "-"), 1, 31)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(item, "-") {
+			n = -n
+		}
+		days = append(days, n)
+	}
+	return days, nil
+}
+
+func parseWeekdays(value string) ([]Weekday, error) {
+	var days []Weekday
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("BYDAY %q is not a weekday", item)
+		}
+		day, err := parseWeekday(item[len(item)-2:])
+		if err != nil {
+			return nil, err
+		}
+
+		weekday := Weekday{Day: day}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := parseInt(strings.TrimPrefix(strings.TrimPrefix(prefix, "+"), "-"), 1, 5)
+			if err != nil {
+				return nil, err
+			}
+			if strings.HasPrefix(prefix, "-") {
+				n = -n
+			}
+			weekday.N = n
+		}
+		days = append(days, weekday)
+	}
+	return days, nil
+}
+
+func parseWeekday(value string) (time.Weekday, error) {
+	for day, name := range weekdayNames {
+		if name == value {
+			return time.Weekday(day), nil
+		}
+	}
+	return 0, fmt.Errorf("%q is not a weekday", value)
+}
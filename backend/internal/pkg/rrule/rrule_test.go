@@ -0,0 +1,189 @@
+package rrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOccurrences(t *testing.T) {
+	// Monday 2024-01-01 09:00 UTC
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		rule  string
+		start time.Time
+		want  []string
+	}{
+		{"FREQ=DAILY;INTERVAL=2;COUNT=3", start, []string{"2024-01-01", "2024-01-03", "2024-01-05"}},
+		{"FREQ=WEEKLY;BYDAY=MO,TH", start, []string{"2024-01-01", "2024-01-04", "2024-01-08", "2024-01-11"}},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", start, []string{"2024-01-01", "2024-01-02", "2024-01-16", "2024-01-30"}},
+		// Months without a 31st are skipped
+		{"FREQ=MONTHLY", time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC), []string{"2024-01-31", "2024-03-31", "2024-05-31", "2024-07-31"}},
+		{"FREQ=MONTHLY;BYDAY=-1FR", start, []string{"2024-01-01", "2024-01-26", "2024-02-23", "2024-03-29"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,-1", start, []string{"2024-01-01", "2024-01-31", "2024-02-01", "2024-02-29"}},
+		{"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29", start, []string{"2024-01-01", "2024-02-29", "2028-02-29", "2032-02-29"}},
+		{"FREQ=YEARLY;BYMONTH=11;BYDAY=4TH", start, []string{"2024-01-01", "2024-11-28", "2025-11-27", "2026-11-26"}},
+		{"FREQ=DAILY;UNTIL=20240103", start, []string{"2024-01-01", "2024-01-02", "2024-01-03"}},
+		{"FREQ=DAILY;BYDAY=SA,SU;COUNT=3", start, []string{"2024-01-01", "2024-01-06", "2024-01-07"}},
+	}
+
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.rule, err)
+		}
+
+		var got []string
+		it := rule.Iterator(tt.start)
+		for len(got) < 4 {
+			next, ok := it.Next()
+			if !ok {
+				break
+			}
+			if next.Hour() != 9 {
+				t.Fatalf("%s: occurrence %s lost the time of day", tt.rule, next)
+			}
+			got = append(got, next.Format("2006-01-02"))
+		}
+
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: got %v, want %v", tt.rule, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Fatalf("%s: got %v, want %v", tt.rule, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	valid := map[string]string{
+		"freq=weekly;byday=mo,th":            "FREQ=WEEKLY;BYDAY=MO,TH",
+		"RRULE:FREQ=MONTHLY;BYDAY=+2TU":      "FREQ=MONTHLY;BYDAY=2TU",
+		"FREQ=DAILY;INTERVAL=1;COUNT=5":      "FREQ=DAILY;COUNT=5",
+		"FREQ=YEARLY;UNTIL=20301231T000000Z": "FREQ=YEARLY;UNTIL=20301231T000000Z",
+		"FREQ=WEEKLY;WKST=SU;BYDAY=SA":       "FREQ=WEEKLY;BYDAY=SA;WKST=SU",
+	}
+	for input, want := range valid {
+		rule, err := Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", input, err)
+		}
+		if got := rule.String(); got != want {
+			t.Fatalf("Parse(%q).String() = %q, want %q", input, got, want)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=3;UNTIL=20300101",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYSETPOS=-1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;",
+	}
+	for _, input := range invalid {
+		if _, err := Parse(input); !errors.Is(err, ErrInvalid) {
+			t.Fatalf("Parse(%q) = %v, want ErrInvalid", input, err)
+		}
+	}
+}
+
+// A rule that can never match again must end instead of searching forever
+func TestIteratorGivesUp(t *testing.T) {
+	rule, err := Parse("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	it := rule.Iterator(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if _, ok := it.Next(); !ok {
+		t.Fatal("the start is always an occurrence")
+	}
+	if next, ok := it.Next(); ok {
+		t.Fatalf("got occurrence %s, want none", next)
+	}
+}
+
+func TestIteratorSkipTo(t *testing.T) {
+	start := time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC)
+	skipTo := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	rules := []string{
+		"FREQ=DAILY",
+		"FREQ=DAILY;INTERVAL=3;BYDAY=MO,FR",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,SU;WKST=SU",
+		"FREQ=MONTHLY;BYMONTHDAY=15,-1",
+		"FREQ=MONTHLY;INTERVAL=5;BYDAY=-1FR",
+		"FREQ=YEARLY;BYMONTH=3;BYDAY=3FR",
+		"FREQ=YEARLY;INTERVAL=3",
+		"FREQ=DAILY;COUNT=1000",
+	}
+
+	for _, input := range rules {
+		rule, err := Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", input, err)
+		}
+
+		// Skipping must not change the occurrences after skipTo
+		want := occurrencesAfter(rule.Iterator(start), skipTo, 5)
+		it := rule.Iterator(start)
+		it.SkipTo(skipTo)
+		got := occurrencesAfter(it, skipTo, 5)
+
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %v, want %v", input, got, want)
+		}
+		for i := range got {
+			if !got[i].Equal(want[i]) {
+				t.Fatalf("%s: got %v, want %v", input, got, want)
+			}
+		}
+	}
+}
+
+// occurrencesAfter returns the first n occurrences of it after t
+func occurrencesAfter(it *Iterator, t time.Time, n int) []time.Time {
+	var occurrences []time.Time
+	for len(occurrences) < n {
+		next, ok := it.Next()
+		if !ok {
+			break
+		}
+		if next.After(t) {
+			occurrences = append(occurrences, next)
+		}
+	}
+	return occurrences
+}
+
+// Rules follow the wall clock of the start's location, across DST changes
+// and on the weekday of that location
+func TestOccurrencesInLocation(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	rule, err := Parse("FREQ=WEEKLY;BYDAY=MO")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Monday 2024-03-18 00:30 in Berlin is still Sunday in UTC
+	it := rule.Iterator(time.Date(2024, 3, 18, 0, 30, 0, 0, berlin))
+	for i := 0; i < 3; i++ {
+		next, _ := it.Next()
+		if next.Weekday() != time.Monday || next.Hour() != 0 || next.Minute() != 30 {
+			t.Fatalf("occurrence %d is %s, want a Monday at 00:30 in Berlin", i, next)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Package storage keeps file contents (blobs) outside the database, on the
+// local filesystem or in an S3-compatible object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"template-fullstack/backend/internal/config"
+)
+
+// ErrNotFound is returned by BlobStore.Open for keys that hold no blob
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores blobs under keys such as "todos/<id>/<id>". Keys are
+// chosen by the caller and contain only letters, digits, '-', '_', '.' and
+// '/'.
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing any blob there
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob under key; deleting a missing blob succeeds
+	Delete(ctx context.Context, key string) error
+}
+
+// Presigner is implemented by stores that can hand out URLs from which a
+// client downloads a blob directly, without going through the API
+type Presigner interface {
+	// PresignGet returns a URL that downloads key as an attachment named
+	// filename until ttl has passed
+	PresignGet(ctx context.Context, key, filename string, ttl time.Duration) (string, error)
+}
+
+// New returns the store selected by cfg.Driver
+func New(cfg config.StorageConfig) (BlobStore, error) {
+	if cfg.Driver == "s3" {
+		store, err := NewS3Store(S3Config{
+			Endpoint:       cfg.S3Endpoint,
+			PublicEndpoint: cfg.S3PublicEndpoint,
+			Region:         cfg.S3Region,
+			Bucket:         cfg.S3Bucket,
+			AccessKey:      cfg.S3AccessKey,
+			SecretKey:      cfg.S3SecretKey,
+			PathStyle:      cfg.S3PathStyle,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	}
+
+	store, err := NewLocalStore(cfg.LocalDir)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/pkg/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// AttachmentRepository stores the metadata of files attached to todos. The
+// contents live in a storage.BlobStore under each attachment's StorageKey.
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment domain.Attachment) (*domain.Attachment, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Attachment, error)
+	GetByTodoID(ctx context.Context, todoID uuid.UUID) ([]domain.Attachment, error)
+	// Delete removes the attachment and queues its blob for deletion
+	Delete(ctx context.Context, id uuid.UUID) error
+	// OrphanedBlobs returns up to limit storage keys of blobs whose
+	// attachment is gone, oldest first, skipping the first offset
+	OrphanedBlobs(ctx context.Context, limit, offset int) ([]string, error)
+	// ForgetOrphanedBlob takes a blob off the queue once it is deleted
+	ForgetOrphanedBlob(ctx context.Context, storageKey string) error
+}
+
+// attachmentColumns is the column list read by scanAttachment, in scan order
+const attachmentColumns = `id, todo_id, user_id, filename, content_type, size, checksum, storage_key, created_at`
+
+type attachmentRepository struct {
+	db *db.DB
+}
+
+func NewAttachmentRepository(database *db.DB) AttachmentRepository {
+	return &attachmentRepository{db: database}
+}
+
+func (r *attachmentRepository) Create(ctx context.Context, attachment domain.Attachment) (*domain.Attachment, error) {
+	created := &domain.Attachment{}
+	query := `
+		INSERT INTO todo_attachments (id, todo_id, user_id, filename, content_type, size, checksum, storage_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + attachmentColumns
+
+	err := scanAttachment(r.db.QueryRow(ctx, query, uuid.New(), attachment.TodoID, attachment.UserID, attachment.Filename,
+		attachment.ContentType, attachment.Size, attachment.Checksum, attachment.StorageKey, time.Now()), created)
+	if err != nil {
+		return nil, translateError(err, nil, "failed to create attachment")
+	}
+
+	return created, nil
+}
+
+func (r *attachmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Attachment, error) {
+	attachment := &domain.Attachment{}
+	query := `SELECT ` + attachmentColumns + ` FROM todo_attachments WHERE id = $1`
+
+	if err := scanAttachment(r.db.QueryRow(ctx, query, id), attachment); err != nil {
+		return nil, translateError(err, domain.ErrAttachmentNotFound, "failed to get attachment by id")
+	}
+
+	return attachment, nil
+}
+
+// GetByTodoID returns all of the todo's attachments in upload order. Todos
+// have few enough attachments that the list is not paginated.
+func (r *attachmentRepository) GetByTodoID(ctx context.Context, todoID uuid.UUID) ([]domain.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM todo_attachments WHERE todo_id = $1 ORDER BY created_at, id`
+
+	rows, err := r.db.Query(ctx, query, todoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []domain.Attachment{}
+	for rows.Next() {
+		var attachment domain.Attachment
+		if err := scanAttachment(rows, &attachment); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// Delete relies on a trigger to queue the blob, which also covers
+// attachments deleted along with their todo or uploader
+func (r *attachmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM todo_attachments WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrAttachmentNotFound
+	}
+
+	return nil
+}
+
+func (r *attachmentRepository) OrphanedBlobs(ctx context.Context, limit, offset int) ([]string, error) {
+	rows, err := r.db.Query(ctx, `SELECT storage_key FROM orphaned_blobs ORDER BY created_at, storage_key LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get orphaned blobs: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan orphaned blob: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get orphaned blobs: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *attachmentRepository) ForgetOrphanedBlob(ctx context.Context, storageKey string) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM orphaned_blobs WHERE storage_key = $1`, storageKey); err != nil {
+		return fmt.Errorf("failed to forget orphaned blob: %w", err)
+	}
+	return nil
+}
+
+func scanAttachment(row pgx.Row, attachment *domain.Attachment) error {
+	return row.Scan(&attachment.ID, &attachment.TodoID, &attachment.UserID, &attachment.Filename, &attachment.ContentType,
+		&attachment.Size, &attachment.Checksum, &attachment.StorageKey, &attachment.CreatedAt)
+}
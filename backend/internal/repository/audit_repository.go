@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/pkg/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// AuditRepository reads the audit log. Events are written by the repository
+// of the entity that changed (see TodoRepository.RecordAudit), so that they
+// are part of the same transaction.
+type AuditRepository interface {
+	// Search returns a page of the events matching filter, newest first
+	Search(ctx context.Context, filter domain.AuditFilter, pagination domain.PaginationQuery) ([]domain.AuditEvent, int64, error)
+}
+
+// auditColumns is the column list read by scanAuditEvent, in scan order
+const auditColumns = `id, actor_id, entity_type, entity_id, action, before, after, request_id, ip, created_at`
+
+type auditRepository struct {
+	db *db.DB
+}
+
+func NewAuditRepository(database *db.DB) AuditRepository {
+	return &auditRepository{db: database}
+}
+
+func (r *auditRepository) Search(ctx context.Context, filter domain.AuditFilter, pagination domain.PaginationQuery) ([]domain.AuditEvent, int64, error) {
+	var args queryArgs
+	conds := []string{"TRUE"}
+	if filter.EntityType != "" {
+		conds = append(conds, "entity_type = "+args.add(filter.EntityType))
+	}
+	if filter.EntityID != "" {
+		conds = append(conds, "entity_id = "+args.add(filter.EntityID))
+	}
+	if filter.ActorID != "" {
+		conds = append(conds, "actor_id = "+args.add(filter.ActorID))
+	}
+	if filter.Action != "" {
+		conds = append(conds, "action = "+args.add(filter.Action))
+	}
+	if filter.From != nil {
+		conds = append(conds, "created_at >= "+args.add(*filter.From))
+	}
+	if filter.To != nil {
+		conds = append(conds, "created_at < "+args.add(*filter.To))
+	}
+	where := strings.Join(conds, " AND ")
+
+	var total int64
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM audit_events WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	query := `SELECT ` + auditColumns + ` FROM audit_events WHERE ` + where +
+		` ORDER BY created_at DESC, id DESC LIMIT ` + args.add(pagination.PageSize) + ` OFFSET ` + args.add(offset)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []domain.AuditEvent{}
+	for rows.Next() {
+		var event domain.AuditEvent
+		if err := scanAuditEvent(rows, &event); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to get audit events: %w", err)
+	}
+
+	return events, total, nil
+}
+
+// insertAuditEvent writes event with q, which is the transaction of the
+// change it records
+func insertAuditEvent(ctx context.Context, q db.Querier, event domain.AuditEvent) error {
+	query := `
+		INSERT INTO audit_events (id, actor_id, entity_type, entity_id, action, before, after, request_id, ip, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	_, err := q.Exec(ctx, query, uuid.New(), event.ActorID, event.EntityType, event.EntityID, event.Action,
+		event.Before, event.After, event.RequestID, event.IP, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	return nil
+}
+
+func scanAuditEvent(row pgx.Row, event *domain.AuditEvent) error {
+	return row.Scan(&event.ID, &event.ActorID, &event.EntityType, &event.EntityID, &event.Action,
+		&event.Before, &event.After, &event.RequestID, &event.IP, &event.CreatedAt)
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/pkg/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// CommentRepository stores the comments on todos. Deleted comments are soft
+// deleted and treated as missing by every method.
+type CommentRepository interface {
+	Create(ctx context.Context, req domain.CreateCommentRequest) (*domain.Comment, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Comment, error)
+	GetByTodoID(ctx context.Context, todoID uuid.UUID, pagination domain.PaginationQuery) ([]domain.Comment, int64, error)
+	Update(ctx context.Context, id uuid.UUID, body string) (*domain.Comment, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// commentColumns is the column list read by scanComment, in scan order. The
+// author's name comes from a join with users u.
+const commentColumns = `c.id, c.todo_id, c.user_id, u.name, c.body, c.edited_at, c.created_at, c.updated_at`
+
+type commentRepository struct {
+	db *db.DB
+}
+
+func NewCommentRepository(database *db.DB) CommentRepository {
+	return &commentRepository{db: database}
+}
+
+func (r *commentRepository) Create(ctx context.Context, req domain.CreateCommentRequest) (*domain.Comment, error) {
+	comment := &domain.Comment{}
+	now := time.Now()
+	query := `
+		WITH c AS (
+			INSERT INTO todo_comments (id, todo_id, user_id, body, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $5)
+			RETURNING *
+		)
+		SELECT ` + commentColumns + `
+		FROM c
+		JOIN users u ON u.id = c.user_id`
+
+	err := scanComment(r.db.QueryRow(ctx, query, uuid.New(), req.TodoID, req.UserID, req.Body, now), comment)
+	if err != nil {
+		return nil, translateError(err, nil, "failed to create comment")
+	}
+
+	return comment, nil
+}
+
+func (r *commentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Comment, error) {
+	comment := &domain.Comment{}
+	query := `
+		SELECT ` + commentColumns + `
+		FROM todo_comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.id = $1 AND c.deleted_at IS NULL`
+
+	if err := scanComment(r.db.QueryRow(ctx, query, id), comment); err != nil {
+		return nil, translateError(err, domain.ErrCommentNotFound, "failed to get comment by id")
+	}
+
+	return comment, nil
+}
+
+// GetByTodoID returns a page of the todo's comments, oldest first
+func (r *commentRepository) GetByTodoID(ctx context.Context, todoID uuid.UUID, pagination domain.PaginationQuery) ([]domain.Comment, int64, error) {
+	var total int64
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM todo_comments WHERE todo_id = $1 AND deleted_at IS NULL`, todoID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	offset := (pagination.Page - 1) * pagination.PageSize
+	rows, err := r.db.Query(ctx, `
+		SELECT `+commentColumns+`
+		FROM todo_comments c
+		JOIN users u ON u.id = c.user_id
+		WHERE c.todo_id = $1 AND c.deleted_at IS NULL
+		ORDER BY c.created_at, c.id
+		LIMIT $2 OFFSET $3`, todoID, pagination.PageSize, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := []domain.Comment{}
+	for rows.Next() {
+		var comment domain.Comment
+		if err := scanComment(rows, &comment); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to get comments: %w", err)
+	}
+
+	return comments, total, nil
+}
+
+// Update replaces the body, marking the comment as edited if it changed
+func (r *commentRepository) Update(ctx context.Context, id uuid.UUID, body string) (*domain.Comment, error) {
+	comment := &domain.Comment{}
+	query := `
+		WITH c AS (
+			UPDATE todo_comments
+			SET edited_at = CASE WHEN body <> $2 THEN $3 ELSE edited_at END,
+				body = $2, updated_at = $3
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING *
+		)
+		SELECT ` + commentColumns + `
+		FROM c
+		JOIN users u ON u.id = c.user_id`
+
+	if err := scanComment(r.db.QueryRow(ctx, query, id, body, time.Now()), comment); err != nil {
+		return nil, translateError(err, domain.ErrCommentNotFound, "failed to update comment")
+	}
+
+	return comment, nil
+}
+
+func (r *commentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `UPDATE todo_comments SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL`, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrCommentNotFound
+	}
+
+	return nil
+}
+
+func scanComment(row pgx.Row, comment *domain.Comment) error {
+	return row.Scan(&comment.ID, &comment.TodoID, &comment.UserID, &comment.AuthorName, &comment.Body,
+		&comment.EditedAt, &comment.CreatedAt, &comment.UpdatedAt)
+}
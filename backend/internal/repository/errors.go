@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const pgUniqueViolation = "23505"
+
+// isUniqueViolation reports whether err is a unique constraint violation,
+// optionally restricted to the named constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgUniqueViolation {
+		return false
+	}
+	return constraint == "" || pgErr.ConstraintName == constraint
+}
+
+// translateError maps driver errors to domain errors: no rows becomes
+// notFound and unique violations become a conflict. Anything else is wrapped
+// with msg and surfaces as an internal error.
+func translateError(err error, notFound error, msg string) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, pgx.ErrNoRows) && notFound != nil:
+		return notFound
+	case isUniqueViolation(err, ""):
+		return domain.NewConflictError(domain.ErrCodeConflict, "Resource already exists")
+	default:
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+}
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/pkg/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ProjectMemberRepository stores who has access to a project, and the
+// pending invitations to join one
+type ProjectMemberRepository interface {
+	GetRole(ctx context.Context, projectID, userID uuid.UUID) (domain.ProjectRole, error)
+	GetMembers(ctx context.Context, projectID uuid.UUID) ([]domain.ProjectMember, error)
+	UpdateRole(ctx context.Context, projectID, userID uuid.UUID, role domain.ProjectRole) (*domain.ProjectMember, error)
+	RemoveMember(ctx context.Context, projectID, userID uuid.UUID) error
+	CreateInvitation(ctx context.Context, invitation domain.ProjectInvitation) (*domain.ProjectInvitation, error)
+	GetInvitationByID(ctx context.Context, id uuid.UUID) (*domain.ProjectInvitation, error)
+	GetInvitations(ctx context.Context, projectID uuid.UUID) ([]domain.ProjectInvitation, error)
+	GetInvitationsByEmail(ctx context.Context, email string) ([]domain.ProjectInvitation, error)
+	AcceptInvitation(ctx context.Context, id, userID uuid.UUID) error
+	DeleteInvitation(ctx context.Context, id uuid.UUID) error
+}
+
+// projectMemberColumns is the column list read by scanProjectMember, in scan
+// order. The user's email and name come from a join with users u.
+const projectMemberColumns = `m.project_id, m.user_id, u.email, u.name, m.role, m.created_at, m.updated_at`
+
+// invitationColumns is the column list read by scanInvitation, in scan
+// order. The project name comes from a join with projects p.
+const invitationColumns = `i.id, i.project_id, p.name, i.email, i.role, i.invited_by, i.expires_at, i.created_at`
+
+type projectMemberRepository struct {
+	db *db.DB
+}
+
+func NewProjectMemberRepository(database *db.DB) ProjectMemberRepository {
+	return &projectMemberRepository{db: database}
+}
+
+// GetRole returns domain.ErrProjectMemberNotFound if the user is not a
+// member of the project
+func (r *projectMemberRepository) GetRole(ctx context.Context, projectID, userID uuid.UUID) (domain.ProjectRole, error) {
+	var role domain.ProjectRole
+	query := `SELECT role FROM project_members WHERE project_id = $1 AND user_id = $2`
+
+	if err := r.db.QueryRow(ctx, query, projectID, userID).Scan(&role); err != nil {
+		return "", translateError(err, domain.ErrProjectMemberNotFound, "failed to get project role")
+	}
+
+	return role, nil
+}
+
+// GetMembers returns the project's members in the order they joined
+func (r *projectMemberRepository) GetMembers(ctx context.Context, projectID uuid.UUID) ([]domain.ProjectMember, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+projectMemberColumns+`
+		FROM project_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.project_id = $1
+		ORDER BY m.created_at, u.email`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []domain.ProjectMember{}
+	for rows.Next() {
+		var member domain.ProjectMember
+		if err := scanProjectMember(rows, &member); err != nil {
+			return nil, fmt.Errorf("failed to scan project member: %w", err)
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get project members: %w", err)
+	}
+
+	return members, nil
+}
+
+func (r *projectMemberRepository) UpdateRole(ctx context.Context, projectID, userID uuid.UUID, role domain.ProjectRole) (*domain.ProjectMember, error) {
+	member := &domain.ProjectMember{}
+	query := `
+		WITH m AS (
+			UPDATE project_members
+			SET role = $3, updated_at = $4
+			WHERE project_id = $1 AND user_id = $2
+			RETURNING *
+		)
+		SELECT ` + projectMemberColumns + `
+		FROM m
+		JOIN users u ON u.id = m.user_id`
+
+	err := scanProjectMember(r.db.QueryRow(ctx, query, projectID, userID, string(role), time.Now()), member)
+	if err != nil {
+		return nil, translateError(err, domain.ErrProjectMemberNotFound, "failed to update project member")
+	}
+
+	return member, nil
+}
+
+func (r *projectMemberRepository) RemoveMember(ctx context.Context, projectID, userID uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM project_members WHERE project_id = $1 AND user_id = $2`, projectID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove project member: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrProjectMemberNotFound
+	}
+
+	return nil
+}
+
+// CreateInvitation stores an invitation. Inviting an address that already
+// has a pending invitation to the project replaces its role and expiry.
+func (r *projectMemberRepository) CreateInvitation(ctx context.Context, invitation domain.ProjectInvitation) (*domain.ProjectInvitation, error) {
+	created := &domain.ProjectInvitation{}
+	query := `
+		WITH i AS (
+			INSERT INTO project_invitations (id, project_id, email, role, invited_by, expires_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (project_id, lower(email)) DO UPDATE
+			SET role = EXCLUDED.role, invited_by = EXCLUDED.invited_by,
+				expires_at = EXCLUDED.expires_at, created_at = EXCLUDED.created_at
+			RETURNING *
+		)
+		SELECT ` + invitationColumns + `
+		FROM i
+		JOIN projects p ON p.id = i.project_id`
+
+	err := scanInvitation(r.db.QueryRow(ctx, query, uuid.New(), invitation.ProjectID, invitation.Email,
+		string(invitation.Role), invitation.InvitedBy, invitation.ExpiresAt, time.Now()), created)
+	if err != nil {
+		return nil, translateError(err, domain.ErrProjectNotFound, "failed to create invitation")
+	}
+
+	return created, nil
+}
+
+// GetInvitationByID returns the invitation even if it has expired
+func (r *projectMemberRepository) GetInvitationByID(ctx context.Context, id uuid.UUID) (*domain.ProjectInvitation, error) {
+	invitation := &domain.ProjectInvitation{}
+	query := `
+		SELECT ` + invitationColumns + `
+		FROM project_invitations i
+		JOIN projects p ON p.id = i.project_id
+		WHERE i.id = $1`
+
+	if err := scanInvitation(r.db.QueryRow(ctx, query, id), invitation); err != nil {
+		return nil, translateError(err, domain.ErrInvitationNotFound, "failed to get invitation by id")
+	}
+
+	return invitation, nil
+}
+
+// GetInvitations returns the project's unexpired invitations, newest first
+func (r *projectMemberRepository) GetInvitations(ctx context.Context, projectID uuid.UUID) ([]domain.ProjectInvitation, error) {
+	return r.listInvitations(ctx, "i.project_id = $1", projectID)
+}
+
+// GetInvitationsByEmail returns the unexpired invitations sent to email,
+// ignoring case, newest first
+func (r *projectMemberRepository) GetInvitationsByEmail(ctx context.Context, email string) ([]domain.ProjectInvitation, error) {
+	return r.listInvitations(ctx, "lower(i.email) = lower($1)", email)
+}
+
+func (r *projectMemberRepository) listInvitations(ctx context.Context, cond string, arg interface{}) ([]domain.ProjectInvitation, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+invitationColumns+`
+		FROM project_invitations i
+		JOIN projects p ON p.id = i.project_id
+		WHERE `+cond+` AND i.expires_at > NOW()
+		ORDER BY i.created_at DESC, i.id`, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitations: %w", err)
+	}
+	defer rows.Close()
+
+	invitations := []domain.ProjectInvitation{}
+	for rows.Next() {
+		var invitation domain.ProjectInvitation
+		if err := scanInvitation(rows, &invitation); err != nil {
+			return nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+		invitations = append(invitations, invitation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get invitations: %w", err)
+	}
+
+	return invitations, nil
+}
+
+// AcceptInvitation consumes an unexpired invitation and makes userID a member
+// with the invited role. A user who is already a member keeps their role.
+func (r *projectMemberRepository) AcceptInvitation(ctx context.Context, id, userID uuid.UUID) error {
+	return r.db.Transaction(ctx, func(tx db.Querier) error {
+		var projectID uuid.UUID
+		var role domain.ProjectRole
+		err := tx.QueryRow(ctx, `
+			DELETE FROM project_invitations
+			WHERE id = $1 AND expires_at > NOW()
+			RETURNING project_id, role`, id).Scan(&projectID, &role)
+		if err != nil {
+			return translateError(err, domain.ErrInvitationNotFound, "failed to accept invitation")
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO project_members (project_id, user_id, role, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $4)
+			ON CONFLICT (project_id, user_id) DO NOTHING`, projectID, userID, string(role), time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to add project member: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *projectMemberRepository) DeleteInvitation(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM project_invitations WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete invitation: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrInvitationNotFound
+	}
+
+	return nil
+}
+
+func scanProjectMember(row pgx.Row, member *domain.ProjectMember) error {
+	return row.Scan(&member.ProjectID, &member.UserID, &member.Email, &member.Name, &member.Role, &member.CreatedAt, &member.UpdatedAt)
+}
+
+func scanInvitation(row pgx.Row, invitation *domain.ProjectInvitation) error {
+	return row.Scan(&invitation.ID, &invitation.ProjectID, &invitation.ProjectName, &invitation.Email, &invitation.Role,
+		&invitation.InvitedBy, &invitation.ExpiresAt, &invitation.CreatedAt)
+}
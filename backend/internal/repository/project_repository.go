@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/pkg/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type ProjectRepository interface {
+	Create(ctx context.Context, req domain.CreateProjectRequest) (*domain.Project, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Project, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID, query domain.ProjectListQuery) ([]domain.Project, error)
+	Update(ctx context.Context, id uuid.UUID, req domain.UpdateProjectRequest) (*domain.Project, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// projectColumns is the column list read by scanProject, in scan order
+const projectColumns = `id, user_id, name, description, position, archived_at, created_at, updated_at`
+
+type projectRepository struct {
+	db *db.DB
+}
+
+func NewProjectRepository(database *db.DB) ProjectRepository {
+	return &projectRepository{db: database}
+}
+
+// Create adds the project after the owner's existing projects, with the
+// owner as its first member
+func (r *projectRepository) Create(ctx context.Context, req domain.CreateProjectRequest) (*domain.Project, error) {
+	project := &domain.Project{}
+	now := time.Now()
+	query := `
+		INSERT INTO projects (id, user_id, name, description, position, created_at, updated_at)
+		SELECT $1, $2, $3, $4, COALESCE(MAX(position) + 1, 0), $5, $5
+		FROM projects WHERE user_id = $2
+		RETURNING ` + projectColumns
+
+	err := r.db.Transaction(ctx, func(tx db.Querier) error {
+		if err := lockProjectPositions(ctx, tx, req.UserID); err != nil {
+			return err
+		}
+
+		err := scanProject(tx.QueryRow(ctx, query, uuid.New(), req.UserID, req.Name, req.Description, now), project)
+		if err != nil {
+			return translateError(err, nil, "failed to create project")
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO project_members (project_id, user_id, role, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $4)`, project.ID, project.UserID, string(domain.ProjectRoleOwner), now)
+		if err != nil {
+			return fmt.Errorf("failed to add project owner: %w", err)
+		}
+
+		project.Role = domain.ProjectRoleOwner
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (r *projectRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Project, error) {
+	project := &domain.Project{}
+	query := `SELECT ` + projectColumns + ` FROM projects WHERE id = $1`
+
+	if err := scanProject(r.db.QueryRow(ctx, query, id), project); err != nil {
+		return nil, translateError(err, domain.ErrProjectNotFound, "failed to get project by id")
+	}
+
+	return project, nil
+}
+
+// GetByUserID returns the active or archived projects the user is a member
+// of, with their role: their own projects in position order, then the ones
+// shared with them by name
+func (r *projectRepository) GetByUserID(ctx context.Context, userID uuid.UUID, query domain.ProjectListQuery) ([]domain.Project, error) {
+	archived := "archived_at IS NULL"
+	if query.Archived {
+		archived = "archived_at IS NOT NULL"
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT `+projectColumns+`, m.role
+		FROM projects
+		JOIN (SELECT project_id, role FROM project_members WHERE user_id = $1) m ON m.project_id = projects.id
+		WHERE `+archived+`
+		ORDER BY user_id <> $1, CASE WHEN user_id = $1 THEN position END, name, created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get projects: %w", err)
+	}
+	defer rows.Close()
+
+	projects := []domain.Project{}
+	for rows.Next() {
+		var project domain.Project
+		if err := rows.Scan(&project.ID, &project.UserID, &project.Name, &project.Description, &project.Position,
+			&project.ArchivedAt, &project.CreatedAt, &project.UpdatedAt, &project.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		projects = append(projects, project)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get projects: %w", err)
+	}
+
+	return projects, nil
+}
+
+// Update applies a merge patch. A new position is clamped to the owner's
+// range of positions and the projects in between shift by one to make room,
+// so positions stay contiguous.
+func (r *projectRepository) Update(ctx context.Context, id uuid.UUID, req domain.UpdateProjectRequest) (*domain.Project, error) {
+	now := time.Now()
+	project := &domain.Project{}
+
+	err := r.db.Transaction(ctx, func(tx db.Querier) error {
+		var args queryArgs
+		where := "id = " + args.add(id)
+		sets := []string{"updated_at = " + args.add(now)}
+
+		if req.Name.Set {
+			sets = append(sets, "name = "+args.add(req.Name.Value))
+		}
+		if req.Description.Set {
+			sets = append(sets, "description = "+args.add(req.Description.Value))
+		}
+		if req.Archived.Set {
+			if req.Archived.Value {
+				// Archiving an archived project keeps the original time
+				sets = append(sets, "archived_at = COALESCE(archived_at, "+args.add(now)+")")
+			} else {
+				sets = append(sets, "archived_at = NULL")
+			}
+		}
+		if req.Position.Set {
+			position, err := moveProject(ctx, tx, id, req.Position.Value)
+			if err != nil {
+				return err
+			}
+			sets = append(sets, "position = "+args.add(position))
+		}
+
+		query := fmt.Sprintf(`
+			UPDATE projects
+			SET %s
+			WHERE %s
+			RETURNING %s`, strings.Join(sets, ", "), where, projectColumns)
+
+		err := scanProject(tx.QueryRow(ctx, query, args...), project)
+		return translateError(err, domain.ErrProjectNotFound, "failed to update project")
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// moveProject shifts the owner's other projects to free position for the
+// project and returns the position it should take
+func moveProject(ctx context.Context, tx db.Querier, id uuid.UUID, position int) (int, error) {
+	ownerID, err := lockProjectOwnerPositions(ctx, tx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	var current, last int
+	err = tx.QueryRow(ctx, `
+		SELECT p.position, (SELECT COUNT(*) - 1 FROM projects WHERE user_id = p.user_id)
+		FROM projects p
+		WHERE p.id = $1
+		FOR UPDATE`, id).Scan(&current, &last)
+	if err != nil {
+		return 0, translateError(err, domain.ErrProjectNotFound, "failed to get project position")
+	}
+
+	if position > last {
+		position = last
+	}
+
+	switch {
+	case position < current:
+		_, err = tx.Exec(ctx, `
+			UPDATE projects SET position = position + 1
+			WHERE user_id = $1 AND position >= $2 AND position < $3`, ownerID, position, current)
+	case position > current:
+		_, err = tx.Exec(ctx, `
+			UPDATE projects SET position = position - 1
+			WHERE user_id = $1 AND position > $2 AND position <= $3`, ownerID, current, position)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to move project: %w", err)
+	}
+
+	return position, nil
+}
+
+// Delete removes the project and closes the gap in the owner's positions.
+// Its todos stay, without a project (ON DELETE SET NULL).
+func (r *projectRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.Transaction(ctx, func(tx db.Querier) error {
+		ownerID, err := lockProjectOwnerPositions(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		var position int
+		err = tx.QueryRow(ctx, `DELETE FROM projects WHERE id = $1 RETURNING position`, id).Scan(&position)
+		if err != nil {
+			return translateError(err, domain.ErrProjectNotFound, "failed to delete project")
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE projects SET position = position - 1 WHERE user_id = $1 AND position > $2`, ownerID, position)
+		if err != nil {
+			return fmt.Errorf("failed to reorder projects: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// lockProjectPositions serializes changes to the positions of the user's
+// projects until the transaction ends. Without it two concurrent creates
+// read the same MAX(position) and the projects share a position.
+func lockProjectPositions(ctx context.Context, tx db.Querier, userID uuid.UUID) error {
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('project_positions:' || $1::text))`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to lock project positions: %w", err)
+	}
+	return nil
+}
+
+// lockProjectOwnerPositions locks the positions of the projects of the
+// project's owner and returns the owner
+func lockProjectOwnerPositions(ctx context.Context, tx db.Querier, id uuid.UUID) (uuid.UUID, error) {
+	var ownerID uuid.UUID
+	err := tx.QueryRow(ctx, `SELECT user_id FROM projects WHERE id = $1`, id).Scan(&ownerID)
+	if err != nil {
+		return uuid.Nil, translateError(err, domain.ErrProjectNotFound, "failed to get project owner")
+	}
+
+	return ownerID, lockProjectPositions(ctx, tx, ownerID)
+}
+
+func scanProject(row pgx.Row, project *domain.Project) error {
+	return row.Scan(&project.ID, &project.UserID, &project.Name, &project.Description, &project.Position, &project.ArchivedAt, &project.CreatedAt, &project.UpdatedAt)
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/pkg/db"
+
+	"github.com/google/uuid"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *domain.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	Rotate(ctx context.Context, oldID uuid.UUID, next *domain.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+type refreshTokenRepository struct {
+	db *db.DB
+}
+
+func NewRefreshTokenRepository(database *db.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: database}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	return insertRefreshToken(ctx, r.db, token)
+}
+
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	token := &domain.RefreshToken{}
+	query := `
+		SELECT id, user_id, family_id, token_hash, expires_at, revoked_at, replaced_by, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1`
+
+	err := r.db.QueryRow(ctx, query, tokenHash).
+		Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.ExpiresAt, &token.RevokedAt, &token.ReplacedBy, &token.CreatedAt)
+
+	if err != nil {
+		return nil, translateError(err, domain.ErrInvalidRefreshToken, "failed to get refresh token")
+	}
+
+	return token, nil
+}
+
+// Rotate marks the old token as replaced by next and stores next, atomically.
+// It returns domain.ErrRefreshTokenReused if the old token was already revoked,
+// which happens when two requests race to use the same token.
+func (r *refreshTokenRepository) Rotate(ctx context.Context, oldID uuid.UUID, next *domain.RefreshToken) error {
+	return r.db.Transaction(ctx, func(tx db.Querier) error {
+		query := `
+			UPDATE refresh_tokens
+			SET revoked_at = $2, replaced_by = $3
+			WHERE id = $1 AND revoked_at IS NULL`
+
+		cmdTag, err := tx.Exec(ctx, query, oldID, time.Now(), next.ID)
+		if err != nil {
+			return fmt.Errorf("failed to revoke refresh token: %w", err)
+		}
+
+		if cmdTag.RowsAffected() == 0 {
+			return domain.ErrRefreshTokenReused
+		}
+
+		return insertRefreshToken(ctx, tx, next)
+	})
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.Exec(ctx, query, familyID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	return nil
+}
+
+func (r *refreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`
+
+	if _, err := r.db.Exec(ctx, query, userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+func insertRefreshToken(ctx context.Context, q db.Querier, token *domain.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := q.Exec(ctx, query, token.ID, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"template-fullstack/backend/internal/pkg/db"
+
+	"github.com/google/uuid"
+)
+
+type RevokedTokenRepository interface {
+	Revoke(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type revokedTokenRepository struct {
+	db *db.DB
+}
+
+func NewRevokedTokenRepository(database *db.DB) RevokedTokenRepository {
+	return &revokedTokenRepository{db: database}
+}
+
+func (r *revokedTokenRepository) Revoke(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (jti) DO NOTHING`
+
+	if _, err := r.db.Exec(ctx, query, jti, userID, expiresAt, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *revokedTokenRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	query := `SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)`
+
+	if err := r.db.QueryRow(ctx, query, jti).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// DeleteExpired removes entries for tokens that have expired anyway.
+func (r *revokedTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	query := `DELETE FROM revoked_tokens WHERE expires_at < $1`
+
+	cmdTag, err := r.db.Exec(ctx, query, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired revoked tokens: %w", err)
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
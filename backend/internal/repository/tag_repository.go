@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/pkg/db"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type TagRepository interface {
+	Create(ctx context.Context, req domain.CreateTagRequest) (*domain.Tag, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Tag, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]domain.Tag, error)
+	Update(ctx context.Context, id uuid.UUID, req domain.UpdateTagRequest) (*domain.Tag, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// tagColumns is the column list read by scanTag, in scan order
+const tagColumns = `id, user_id, name, color, created_at, updated_at`
+
+// tagNameConstraint is the unique index on (user_id, lower(name))
+const tagNameConstraint = "tags_user_id_name_key"
+
+type tagRepository struct {
+	db *db.DB
+}
+
+func NewTagRepository(database *db.DB) TagRepository {
+	return &tagRepository{db: database}
+}
+
+func (r *tagRepository) Create(ctx context.Context, req domain.CreateTagRequest) (*domain.Tag, error) {
+	tag := &domain.Tag{}
+	now := time.Now()
+	query := `
+		INSERT INTO tags (id, user_id, name, color, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + tagColumns
+
+	err := scanTag(r.db.QueryRow(ctx, query, uuid.New(), req.UserID, req.Name, req.Color, now, now), tag)
+
+	if isUniqueViolation(err, tagNameConstraint) {
+		return nil, domain.ErrTagExists
+	}
+	if err != nil {
+		return nil, translateError(err, nil, "failed to create tag")
+	}
+
+	return tag, nil
+}
+
+func (r *tagRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Tag, error) {
+	tag := &domain.Tag{}
+	query := `SELECT ` + tagColumns + ` FROM tags WHERE id = $1`
+
+	if err := scanTag(r.db.QueryRow(ctx, query, id), tag); err != nil {
+		return nil, translateError(err, domain.ErrTagNotFound, "failed to get tag by id")
+	}
+
+	return tag, nil
+}
+
+// GetByUserID returns all of the user's tags ordered by name. Users have few
+// enough tags that the list is not paginated.
+func (r *tagRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]domain.Tag, error) {
+	query := `SELECT ` + tagColumns + ` FROM tags WHERE user_id = $1 ORDER BY lower(name), id`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []domain.Tag{}
+	for rows.Next() {
+		var tag domain.Tag
+		if err := scanTag(rows, &tag); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// Update applies a merge patch; a null color clears it
+func (r *tagRepository) Update(ctx context.Context, id uuid.UUID, req domain.UpdateTagRequest) (*domain.Tag, error) {
+	var args queryArgs
+	where := "id = " + args.add(id)
+	sets := []string{"updated_at = " + args.add(time.Now())}
+
+	if req.Name.Set {
+		sets = append(sets, "name = "+args.add(req.Name.Value))
+	}
+	if req.Color.Set {
+		sets = append(sets, "color = "+args.add(req.Color.Value))
+	}
+
+	tag := &domain.Tag{}
+	query := fmt.Sprintf(`
+		UPDATE tags
+		SET %s
+		WHERE %s
+		RETURNING %s`, strings.Join(sets, ", "), where, tagColumns)
+
+	err := scanTag(r.db.QueryRow(ctx, query, args...), tag)
+
+	if isUniqueViolation(err, tagNameConstraint) {
+		return nil, domain.ErrTagExists
+	}
+	if err != nil {
+		return nil, translateError(err, domain.ErrTagNotFound, "failed to update tag")
+	}
+
+	return tag, nil
+}
+
+// Delete removes the tag; it is detached from its todos by the ON DELETE
+// CASCADE foreign key
+func (r *tagRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	cmdTag, err := r.db.Exec(ctx, `DELETE FROM tags WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return domain.ErrTagNotFound
+	}
+
+	return nil
+}
+
+func scanTag(row pgx.Row, tag *domain.Tag) error {
+	return row.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt)
+}
@@ -2,32 +2,79 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"template-fullstack/backend/internal/domain"
 	"template-fullstack/backend/internal/pkg/db"
+	"template-fullstack/backend/internal/pkg/rank"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type TodoRepository interface {
 	Create(ctx context.Context, req domain.CreateTodoRequest) (*domain.Todo, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Todo, error)
-	GetByUserID(ctx context.Context, userID uuid.UUID, pagination domain.PaginationQuery) ([]domain.Todo, int64, error)
-	Update(ctx context.Context, id uuid.UUID, req domain.UpdateTodoRequest) (*domain.Todo, error)
-	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, pagination domain.PaginationQuery) ([]domain.Todo, int64, error)
+	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.Todo, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID, filter domain.TodoFilter, pagination domain.PaginationQuery) ([]domain.Todo, int64, error)
+	// Update, Delete and Restore also return the subtasks they cascaded to
+	Update(ctx context.Context, id uuid.UUID, req domain.UpdateTodoRequest, expectedVersion int) (*domain.Todo, []domain.TodoChange, error)
+	Delete(ctx context.Context, id uuid.UUID, expectedVersion int) ([]domain.TodoChange, error)
+	List(ctx context.Context, filter domain.TodoFilter, pagination domain.PaginationQuery) ([]domain.Todo, int64, error)
+	GetByUserIDKeyset(ctx context.Context, userID uuid.UUID, filter domain.TodoFilter, page domain.KeysetPage) ([]domain.Todo, bool, error)
+	ListKeyset(ctx context.Context, filter domain.TodoFilter, page domain.KeysetPage) ([]domain.Todo, bool, error)
+	GetTrashedByID(ctx context.Context, id uuid.UUID) (*domain.Todo, error)
+	Restore(ctx context.Context, id uuid.UUID) (*domain.Todo, []domain.TodoChange, error)
+	PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error)
+	ClaimDueReminders(ctx context.Context, dueBefore time.Time, limit int) ([]domain.Todo, error)
+	Move(ctx context.Context, id uuid.UUID, req domain.MoveTodoRequest, expectedVersion int) (*domain.Todo, error)
+	RebalancePositions(ctx context.Context, maxKeyLength int) (int, error)
+	ProjectRole(ctx context.Context, projectID, userID uuid.UUID) (ownerID uuid.UUID, role domain.ProjectRole, err error)
+	// RecordAudit adds an event to the audit log, in the repository's
+	// transaction when called inside Transaction
+	RecordAudit(ctx context.Context, event domain.AuditEvent) error
+	Transaction(ctx context.Context, fn func(repo TodoRepository) error) error
 }
 
+// todoColumns is the column list read by scanTodo, in scan order
+const todoColumns = `id, title, description, completed, user_id, project_id, parent_id, priority, due_at, recurrence, timezone, completed_at, position, version, created_at, updated_at, deleted_at`
+
 type todoRepository struct {
 	db *db.DB
+	// q runs the queries: the pool, or the transaction inside Transaction
+	q    db.Querier
+	inTx bool
 }
 
 func NewTodoRepository(database *db.DB) TodoRepository {
-	return &todoRepository{db: database}
+	return &todoRepository{db: database, q: database}
+}
+
+// Transaction runs fn with a repository whose queries all run in a single
+// database transaction, committed if fn returns nil. Calling Transaction on
+// that repository again reuses the transaction.
+func (r *todoRepository) Transaction(ctx context.Context, fn func(repo TodoRepository) error) error {
+	return r.withTx(ctx, func(tx *todoRepository) error {
+		return fn(tx)
+	})
+}
+
+func (r *todoRepository) withTx(ctx context.Context, fn func(tx *todoRepository) error) error {
+	if r.inTx {
+		return fn(r)
+	}
+
+	return r.db.Transaction(ctx, func(tx db.Querier) error {
+		return fn(&todoRepository{db: r.db, q: tx, inTx: true})
+	})
 }
 
+// Create places the new todo first in its owner's position order. Creating
+// a subtask bumps its parent's version.
 func (r *todoRepository) Create(ctx context.Context, req domain.CreateTodoRequest) (*domain.Todo, error) {
 	todo := &domain.Todo{
 		ID:          uuid.New(),
@@ -35,20 +82,65 @@ func (r *todoRepository) Create(ctx context.Context, req domain.CreateTodoReques
 		Description: req.Description,
 		Completed:   false,
 		UserID:      req.UserID,
+		ProjectID:   req.ProjectID,
+		ParentID:    req.ParentID,
+		Priority:    req.Priority,
+		DueAt:       req.DueAt,
+		Recurrence:  req.Recurrence,
+		Timezone:    req.Timezone,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
 	query := `
-		INSERT INTO todos (id, title, description, completed, user_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, title, description, completed, user_id, created_at, updated_at`
+		INSERT INTO todos (id, title, description, completed, user_id, project_id, parent_id, priority, due_at, recurrence, timezone, position, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING ` + todoColumns
+
+	// The todo and its tags are written together
+	err := r.withTx(ctx, func(tx *todoRepository) error {
+		if todo.ProjectID != nil {
+			if err := tx.checkProject(ctx, *todo.ProjectID, todo.UserID); err != nil {
+				return err
+			}
+		}
+		if todo.ParentID != nil {
+			if err := tx.checkParent(ctx, todo.ID, *todo.ParentID, todo.UserID); err != nil {
+				return err
+			}
+		}
+
+		first, err := tx.neighbourPosition(ctx, todo.UserID, todo.ID, nil, true)
+		if err != nil {
+			return err
+		}
+		position, err := rank.Between("", first)
+		if err != nil {
+			return fmt.Errorf("failed to position todo: %w", err)
+		}
 
-	err := r.db.QueryRow(ctx, query, todo.ID, todo.Title, todo.Description, todo.Completed, todo.UserID, todo.CreatedAt, todo.UpdatedAt).
-		Scan(&todo.ID, &todo.Title, &todo.Description, &todo.Completed, &todo.UserID, &todo.CreatedAt, &todo.UpdatedAt)
+		row := tx.q.QueryRow(ctx, query, todo.ID, todo.Title, todo.Description, todo.Completed, todo.UserID,
+			todo.ProjectID, todo.ParentID, string(todo.Priority), todo.DueAt, todo.Recurrence, todo.Timezone, position, todo.CreatedAt, todo.UpdatedAt)
+		if err := scanTodo(row, todo); err != nil {
+			return translateError(err, nil, "failed to create todo")
+		}
+
+		if err := tx.touchParents(ctx, []*uuid.UUID{todo.ParentID}, nil); err != nil {
+			return err
+		}
+
+		if len(req.TagIDs) == 0 {
+			todo.Tags = []domain.Tag{}
+			return nil
+		}
+		if err := tx.setTags(ctx, todo.ID, todo.UserID, req.TagIDs); err != nil {
+			return err
+		}
+		return tx.loadTags(ctx, todo)
+	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create todo: %w", err)
+		return nil, err
 	}
 
 	return todo, nil
@@ -56,40 +148,368 @@ func (r *todoRepository) Create(ctx context.Context, req domain.CreateTodoReques
 
 func (r *todoRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
 	todo := &domain.Todo{}
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE id = $1 AND deleted_at IS NULL`
+
+	err := scanTodo(r.q.QueryRow(ctx, query, id), todo)
+
+	if err != nil {
+		return nil, translateError(err, domain.ErrTodoNotFound, "failed to get todo by id")
+	}
+
+	if err := r.loadDetails(ctx, todo); err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// GetByIDForUpdate is GetByID that also locks the todo until the surrounding
+// transaction ends, for read-modify-write sequences inside Transaction
+func (r *todoRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
+	todo := &domain.Todo{}
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	if err := scanTodo(r.q.QueryRow(ctx, query, id), todo); err != nil {
+		return nil, translateError(err, domain.ErrTodoNotFound, "failed to get todo by id")
+	}
+
+	if err := r.loadDetails(ctx, todo); err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+func (r *todoRepository) GetByUserID(ctx context.Context, userID uuid.UUID, filter domain.TodoFilter, pagination domain.PaginationQuery) ([]domain.Todo, int64, error) {
+	return r.list(ctx, &userID, filter, pagination)
+}
+
+// Update applies a merge patch: only fields present in req are written, and
+// a null clears the field to its empty value. Every update bumps the version.
+// When expectedVersion is non-zero the write only happens if the stored
+// version still matches, otherwise domain.ErrTodoVersionMismatch is returned.
+// TagIDs replaces the tag set; its tags and ProjectID must belong to the
+// todo's owner, and so must ParentID, which moves the todo with its subtasks.
+// Completing a todo completes its open subtasks as well, except for
+// recurring ones; they are returned as changes. Completing or moving a
+// subtask bumps the version of its parents, old and new.
+func (r *todoRepository) Update(ctx context.Context, id uuid.UUID, req domain.UpdateTodoRequest, expectedVersion int) (*domain.Todo, []domain.TodoChange, error) {
+	now := time.Now()
+	args := queryArgs{id}
+	sets := todoPatchSets(&args, req, now)
+	sets = append(sets, "updated_at = "+args.add(now), "version = version + 1")
+
+	where := "id = $1 AND deleted_at IS NULL"
+	notFound := domain.ErrTodoNotFound
+	if expectedVersion > 0 {
+		where += " AND version = " + args.add(expectedVersion)
+		notFound = domain.ErrTodoVersionMismatch
+	}
+
+	todo := &domain.Todo{}
+	var changes []domain.TodoChange
+	query := fmt.Sprintf(`
+		UPDATE todos
+		SET %s
+		WHERE %s
+		RETURNING %s`, strings.Join(sets, ", "), where, todoColumns)
+
+	err := r.withTx(ctx, func(tx *todoRepository) error {
+		// Check the new parent before the todo points at it, so that the
+		// ancestor walk cannot run into a cycle
+		var oldParentID *uuid.UUID
+		if req.ParentID.Set {
+			var ownerID uuid.UUID
+			err := tx.q.QueryRow(ctx, `SELECT user_id, parent_id FROM todos WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&ownerID, &oldParentID)
+			if err != nil {
+				return translateError(err, domain.ErrTodoNotFound, "failed to update todo")
+			}
+			if !req.ParentID.Null {
+				if err := tx.checkParent(ctx, id, req.ParentID.Value, ownerID); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := scanTodo(tx.q.QueryRow(ctx, query, args...), todo); err != nil {
+			return translateError(err, notFound, "failed to update todo")
+		}
+
+		if req.Completed.Set && req.Completed.Value {
+			var err error
+			if changes, err = tx.completeSubtasks(ctx, id, now); err != nil {
+				return err
+			}
+		}
+
+		// Progress counts completed subtasks, so their parents change too.
+		// The todo and its subtasks were bumped by the update already.
+		var parentIDs []*uuid.UUID
+		changed := []uuid.UUID{todo.ID}
+		if req.Completed.Set || req.ParentID.Set {
+			parentIDs = append(parentIDs, todo.ParentID, oldParentID)
+		}
+		for _, change := range changes {
+			parentIDs = append(parentIDs, change.After.ParentID)
+			changed = append(changed, change.After.ID)
+		}
+		if err := tx.touchParents(ctx, parentIDs, changed); err != nil {
+			return err
+		}
+
+		// The owner is only known now; a foreign project rolls the update back
+		if req.ProjectID.Set && !req.ProjectID.Null {
+			if err := tx.checkProject(ctx, req.ProjectID.Value, todo.UserID); err != nil {
+				return err
+			}
+		}
+
+		if req.TagIDs.Set {
+			if err := tx.setTags(ctx, todo.ID, todo.UserID, req.TagIDs.Value); err != nil {
+				return err
+			}
+		}
+		return tx.loadDetails(ctx, todo)
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return todo, changes, nil
+}
+
+// todoPatchSets returns the SET assignments that apply the fields present in
+// req, adding their values to args
+func todoPatchSets(args *queryArgs, req domain.UpdateTodoRequest, now time.Time) []string {
+	var sets []string
+	set := func(column string, value interface{}) {
+		sets = append(sets, column+" = "+args.add(value))
+	}
+
+	if req.Title.Set {
+		set("title", req.Title.Value)
+	}
+	if req.Description.Set {
+		set("description", req.Description.Value)
+	}
+	if req.Completed.Set {
+		set("completed", req.Completed.Value)
+		if req.Completed.Value {
+			// Completing an already completed todo keeps the original time
+			sets = append(sets, "completed_at = COALESCE(completed_at, "+args.add(now)+")")
+		} else {
+			sets = append(sets, "completed_at = NULL")
+		}
+	}
+	if req.Priority.Set {
+		set("priority", string(req.Priority.Value))
+	}
+	if req.DueAt.Set {
+		var dueAt *time.Time
+		if !req.DueAt.Null {
+			dueAt = &req.DueAt.Value
+		}
+		set("due_at", dueAt)
+		// A new due date gets a new reminder
+		sets = append(sets, "reminded_at = NULL")
+	}
+	if req.Recurrence.Set {
+		set("recurrence", req.Recurrence.Value)
+	}
+	if req.Timezone.Set {
+		set("timezone", req.Timezone.Value)
+	}
+	if req.ProjectID.Set {
+		var projectID *uuid.UUID
+		if !req.ProjectID.Null {
+			projectID = &req.ProjectID.Value
+		}
+		set("project_id", projectID)
+	}
+	if req.ParentID.Set {
+		var parentID *uuid.UUID
+		if !req.ParentID.Null {
+			parentID = &req.ParentID.Value
+		}
+		set("parent_id", parentID)
+	}
+
+	return sets
+}
+
+// Delete moves the todo and its subtasks to the trash and returns the
+// subtasks as changes. A non-zero expectedVersion makes the delete
+// conditional, as in Update. Trashing a subtask bumps its parent's version.
+func (r *todoRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersion int) ([]domain.TodoChange, error) {
+	now := time.Now()
 	query := `
-		SELECT id, title, description, completed, user_id, created_at, updated_at
-		FROM todos
-		WHERE id = $1`
+		UPDATE todos
+		SET deleted_at = $2, version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL`
+	args := []interface{}{id, now}
+	notFound := domain.ErrTodoNotFound
+	if expectedVersion > 0 {
+		query += ` AND version = $3`
+		args = append(args, expectedVersion)
+		notFound = domain.ErrTodoVersionMismatch
+	}
+	query += ` RETURNING parent_id`
 
-	err := r.db.QueryRow(ctx, query, id).
-		Scan(&todo.ID, &todo.Title, &todo.Description, &todo.Completed, &todo.UserID, &todo.CreatedAt, &todo.UpdatedAt)
+	var changes []domain.TodoChange
+	err := r.withTx(ctx, func(tx *todoRepository) error {
+		var parentID *uuid.UUID
+		if err := tx.q.QueryRow(ctx, query, args...).Scan(&parentID); err != nil {
+			return translateError(err, notFound, "failed to delete todo")
+		}
+		if err := tx.touchParents(ctx, []*uuid.UUID{parentID}, nil); err != nil {
+			return err
+		}
+
+		// The subtasks share the parent's deletion time, which is how Restore
+		// finds them again
+		subtasks := `
+			WITH RECURSIVE subtasks AS (` + subtasksCTE + `)
+			SELECT ` + todoColumns + ` FROM todos WHERE id IN (SELECT id FROM subtasks)`
+
+		var err error
+		changes, err = tx.changeTodos(ctx, subtasks, []interface{}{id}, "deleted_at = $2, version = version + 1", now)
+		if err != nil {
+			return fmt.Errorf("failed to delete subtasks: %w", err)
+		}
+
+		return nil
+	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to get todo by id: %w", err)
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// GetTrashedByID returns a todo that is in the trash
+func (r *todoRepository) GetTrashedByID(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
+	todo := &domain.Todo{}
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	if err := scanTodo(r.q.QueryRow(ctx, query, id), todo); err != nil {
+		return nil, translateError(err, domain.ErrTodoNotFound, "failed to get trashed todo")
+	}
+
+	if err := r.loadDetails(ctx, todo); err != nil {
+		return nil, err
 	}
 
 	return todo, nil
 }
 
-func (r *todoRepository) GetByUserID(ctx context.Context, userID uuid.UUID, pagination domain.PaginationQuery) ([]domain.Todo, int64, error) {
-	// Count total records for the user
+// Restore takes a todo out of the trash, together with the subtasks that
+// were deleted along with it. A subtask can't be restored while its parent
+// is still in the trash. The restored subtasks are returned as changes.
+// Restoring a subtask bumps its parent's version.
+func (r *todoRepository) Restore(ctx context.Context, id uuid.UUID) (*domain.Todo, []domain.TodoChange, error) {
+	now := time.Now()
+	todo := &domain.Todo{}
+	var changes []domain.TodoChange
+
+	err := r.withTx(ctx, func(tx *todoRepository) error {
+		var deletedAt time.Time
+		var parentTrashed bool
+		err := tx.q.QueryRow(ctx, `
+			SELECT t.deleted_at, COALESCE(p.deleted_at IS NOT NULL, FALSE)
+			FROM todos t
+			LEFT JOIN todos p ON p.id = t.parent_id
+			WHERE t.id = $1 AND t.deleted_at IS NOT NULL
+			FOR UPDATE OF t`, id).Scan(&deletedAt, &parentTrashed)
+		if err != nil {
+			return translateError(err, domain.ErrTodoNotFound, "failed to restore todo")
+		}
+		if parentTrashed {
+			return domain.ErrTodoParentTrashed
+		}
+
+		query := `
+			UPDATE todos
+			SET deleted_at = NULL, updated_at = $2, version = version + 1
+			WHERE id = $1
+			RETURNING ` + todoColumns
+
+		if err := scanTodo(tx.q.QueryRow(ctx, query, id, now), todo); err != nil {
+			return translateError(err, domain.ErrTodoNotFound, "failed to restore todo")
+		}
+
+		// Subtasks trashed on their own before the parent stay in the trash
+		query = `
+			WITH RECURSIVE subtasks AS (
+				SELECT id FROM todos WHERE parent_id = $1 AND deleted_at = $2
+				UNION ALL
+				SELECT t.id FROM todos t JOIN subtasks s ON t.parent_id = s.id WHERE t.deleted_at = $2
+			)
+			SELECT ` + todoColumns + ` FROM todos WHERE id IN (SELECT id FROM subtasks)`
+
+		changes, err = tx.changeTodos(ctx, query, []interface{}{id, deletedAt}, "deleted_at = NULL, updated_at = $2, version = version + 1", now)
+		if err != nil {
+			return fmt.Errorf("failed to restore subtasks: %w", err)
+		}
+
+		if err := tx.touchParents(ctx, []*uuid.UUID{todo.ParentID}, nil); err != nil {
+			return err
+		}
+		return tx.loadDetails(ctx, todo)
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return todo, changes, nil
+}
+
+// PurgeDeleted permanently deletes todos that were trashed before cutoff and
+// returns how many were removed.
+func (r *todoRepository) PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error) {
+	cmdTag, err := r.q.Exec(ctx, `DELETE FROM todos WHERE deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted todos: %w", err)
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
+
+func (r *todoRepository) List(ctx context.Context, filter domain.TodoFilter, pagination domain.PaginationQuery) ([]domain.Todo, int64, error) {
+	return r.list(ctx, nil, filter, pagination)
+}
+
+// list returns one page of todos matching filter, owned by ownerID unless it
+// is nil, together with the total number of matches.
+func (r *todoRepository) list(ctx context.Context, ownerID *uuid.UUID, filter domain.TodoFilter, pagination domain.PaginationQuery) ([]domain.Todo, int64, error) {
+	sortFields, err := filter.SortFields()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var args queryArgs
+	conds, rank := todoFilterConditions(&args, ownerID, filter)
+	where := whereClause(conds)
+
+	// Count total matches
 	var total int64
-	countQuery := `SELECT COUNT(*) FROM todos WHERE user_id = $1`
-	err := r.db.QueryRow(ctx, countQuery, userID).Scan(&total)
+	err = r.q.QueryRow(ctx, `SELECT COUNT(*) FROM todos `+where, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count todos: %w", err)
 	}
 
 	// Get paginated results
 	offset := (pagination.Page - 1) * pagination.PageSize
-	query := `
-		SELECT id, title, description, completed, user_id, created_at, updated_at
+	query := fmt.Sprintf(`
+		SELECT %s
 		FROM todos
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3`
+		%s
+		ORDER BY %s
+		LIMIT %s OFFSET %s`, todoColumns, where, todoOrderBy(sortFields, rank, filter.Trashed), args.add(pagination.PageSize), args.add(offset))
 
-	rows, err := r.db.Query(ctx, query, userID, pagination.PageSize, offset)
+	rows, err := r.q.Query(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get todos: %w", err)
 	}
@@ -98,89 +518,843 @@ func (r *todoRepository) GetByUserID(ctx context.Context, userID uuid.UUID, pagi
 	var todos []domain.Todo
 	for rows.Next() {
 		var todo domain.Todo
-		err := rows.Scan(&todo.ID, &todo.Title, &todo.Description, &todo.Completed, &todo.UserID, &todo.CreatedAt, &todo.UpdatedAt)
-		if err != nil {
+		if err := scanTodo(rows, &todo); err != nil {
 			return nil, 0, fmt.Errorf("failed to scan todo: %w", err)
 		}
 		todos = append(todos, todo)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	if err := r.loadListDetails(ctx, todos); err != nil {
+		return nil, 0, err
+	}
 
 	return todos, total, nil
 }
 
-func (r *todoRepository) Update(ctx context.Context, id uuid.UUID, req domain.UpdateTodoRequest) (*domain.Todo, error) {
-	todo := &domain.Todo{}
+func (r *todoRepository) GetByUserIDKeyset(ctx context.Context, userID uuid.UUID, filter domain.TodoFilter, page domain.KeysetPage) ([]domain.Todo, bool, error) {
+	return r.listKeyset(ctx, &userID, filter, page)
+}
+
+func (r *todoRepository) ListKeyset(ctx context.Context, filter domain.TodoFilter, page domain.KeysetPage) ([]domain.Todo, bool, error) {
+	return r.listKeyset(ctx, nil, filter, page)
+}
+
+// listKeyset returns up to page.Limit todos in newest-first order, seeking
+// from page.Position instead of counting and skipping rows. The bool reports
+// whether more rows exist beyond the returned ones in the paging direction.
+// filter.Sort is ignored; the order is always (created_at, id).
+func (r *todoRepository) listKeyset(ctx context.Context, ownerID *uuid.UUID, filter domain.TodoFilter, page domain.KeysetPage) ([]domain.Todo, bool, error) {
+	var args queryArgs
+	conds, _ := todoFilterConditions(&args, ownerID, filter)
+
+	// Going backward, walk the index in ascending order from the position and
+	// reverse the rows afterwards
+	comparison, order := "<", "created_at DESC, id DESC"
+	if page.Backward {
+		comparison, order = ">", "created_at ASC, id ASC"
+	}
+	if page.Position != nil {
+		conds = append(conds, fmt.Sprintf("(created_at, id) %s (%s, %s)",
+			comparison, args.add(page.Position.CreatedAt), args.add(page.Position.ID)))
+	}
+
+	// Fetch one extra row to learn whether there is another page
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM todos
+		%s
+		ORDER BY %s
+		LIMIT %s`, todoColumns, whereClause(conds), order, args.add(page.Limit+1))
+
+	rows, err := r.q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get todos: %w", err)
+	}
+	defer rows.Close()
+
+	var todos []domain.Todo
+	for rows.Next() {
+		var todo domain.Todo
+		if err := scanTodo(rows, &todo); err != nil {
+			return nil, false, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("failed to get todos: %w", err)
+	}
+
+	hasMore := len(todos) > page.Limit
+	if hasMore {
+		todos = todos[:page.Limit]
+	}
+	if err := r.loadListDetails(ctx, todos); err != nil {
+		return nil, false, err
+	}
+	if page.Backward {
+		for i, j := 0, len(todos)-1; i < j; i, j = i+1, j-1 {
+			todos[i], todos[j] = todos[j], todos[i]
+		}
+	}
+
+	return todos, hasMore, nil
+}
+
+// ClaimDueReminders marks up to limit open todos due before dueBefore as
+// reminded and returns them. Each todo is claimed once per due date, and
+// SKIP LOCKED lets several server instances claim concurrently without
+// reminding twice.
+func (r *todoRepository) ClaimDueReminders(ctx context.Context, dueBefore time.Time, limit int) ([]domain.Todo, error) {
 	query := `
 		UPDATE todos
-		SET title = COALESCE(NULLIF($2, ''), title),
-		    description = COALESCE(NULLIF($3, ''), description),
-		    completed = COALESCE($4, completed),
-		    updated_at = $5
-		WHERE id = $1
-		RETURNING id, title, description, completed, user_id, created_at, updated_at`
+		SET reminded_at = $3
+		WHERE id IN (
+			SELECT id FROM todos
+			WHERE due_at < $1 AND reminded_at IS NULL AND NOT completed AND deleted_at IS NULL
+			ORDER BY due_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + todoColumns
 
-	var completed interface{}
-	if req.Completed != nil {
-		completed = *req.Completed
+	rows, err := r.q.Query(ctx, query, dueBefore, limit, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim reminders: %w", err)
 	}
+	defer rows.Close()
 
-	err := r.db.QueryRow(ctx, query, id, req.Title, req.Description, completed, time.Now()).
-		Scan(&todo.ID, &todo.Title, &todo.Description, &todo.Completed, &todo.UserID, &todo.CreatedAt, &todo.UpdatedAt)
+	var todos []domain.Todo
+	for rows.Next() {
+		var todo domain.Todo
+		if err := scanTodo(rows, &todo); err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim reminders: %w", err)
+	}
+
+	if err := r.loadListDetails(ctx, todos); err != nil {
+		return nil, err
+	}
+
+	return todos, nil
+}
+
+// Move gives the todo a position key between its new neighbours. Only the
+// moved todo is written, unless the neighbours share a key; the owner's keys
+// are then rebalanced first. A non-zero expectedVersion makes the move
+// conditional, as in Update.
+func (r *todoRepository) Move(ctx context.Context, id uuid.UUID, req domain.MoveTodoRequest, expectedVersion int) (*domain.Todo, error) {
+	todo := &domain.Todo{}
+
+	err := r.withTx(ctx, func(tx *todoRepository) error {
+		var ownerID uuid.UUID
+		var version int
+		err := tx.q.QueryRow(ctx, `SELECT user_id, version FROM todos WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, id).
+			Scan(&ownerID, &version)
+		if err != nil {
+			return translateError(err, domain.ErrTodoNotFound, "failed to move todo")
+		}
+		if expectedVersion > 0 && version != expectedVersion {
+			return domain.ErrTodoVersionMismatch
+		}
+
+		var position string
+		for attempt := 0; ; attempt++ {
+			lower, upper, err := tx.moveBounds(ctx, ownerID, id, req)
+			if err != nil {
+				return err
+			}
+
+			position, err = rank.Between(lower, upper)
+			if err == nil {
+				break
+			}
+			if lower != upper || attempt > 0 {
+				return fmt.Errorf("failed to position todo: %w", err)
+			}
+			// Two todos created concurrently can share a key; spread the keys
+			// apart and look the neighbours up again
+			if err := tx.rebalanceUser(ctx, ownerID); err != nil {
+				return err
+			}
+		}
+
+		query := `
+			UPDATE todos
+			SET position = $2, updated_at = $3, version = version + 1
+			WHERE id = $1
+			RETURNING ` + todoColumns
+
+		if err := scanTodo(tx.q.QueryRow(ctx, query, id, position, time.Now()), todo); err != nil {
+			return translateError(err, domain.ErrTodoNotFound, "failed to move todo")
+		}
+		return tx.loadDetails(ctx, todo)
+	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to update todo: %w", err)
+		return nil, err
 	}
 
 	return todo, nil
 }
 
-func (r *todoRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM todos WHERE id = $1`
+// moveBounds returns the position keys the moved todo id must fall between.
+// A missing neighbour in the request is the todo next to the given one.
+func (r *todoRepository) moveBounds(ctx context.Context, ownerID, id uuid.UUID, req domain.MoveTodoRequest) (lower, upper string, err error) {
+	if req.Before == nil && req.After == nil {
+		return "", "", domain.NewValidationError("before or after is required", domain.FieldError{Field: "before", Rule: "required_without", Param: "after"})
+	}
+
+	var before, after *todoPosition
+	if req.Before != nil {
+		if before, err = r.positionOf(ctx, ownerID, *req.Before, "before"); err != nil {
+			return "", "", err
+		}
+	}
+	if req.After != nil {
+		if after, err = r.positionOf(ctx, ownerID, *req.After, "after"); err != nil {
+			return "", "", err
+		}
+	}
+
+	switch {
+	case before != nil && after != nil:
+		if !before.less(*after) {
+			return "", "", domain.NewValidationError("before must come before after", domain.FieldError{
+				Field: "after",
+				Rule:  "gtfield",
+				Param: "before",
+			})
+		}
+		return before.position, after.position, nil
+	case before != nil:
+		upper, err = r.neighbourPosition(ctx, ownerID, id, before, true)
+		return before.position, upper, err
+	default:
+		lower, err = r.neighbourPosition(ctx, ownerID, id, after, false)
+		return lower, after.position, err
+	}
+}
+
+// todoPosition is a todo's place in (position, id) order
+type todoPosition struct {
+	position string
+	id       uuid.UUID
+}
 
-	cmdTag, err := r.db.Exec(ctx, query, id)
+func (p todoPosition) less(other todoPosition) bool {
+	return p.position < other.position || (p.position == other.position && p.id.String() < other.id.String())
+}
+
+// positionOf returns the position of a live todo of ownerID, reporting a
+// validation error on field otherwise
+func (r *todoRepository) positionOf(ctx context.Context, ownerID, id uuid.UUID, field string) (*todoPosition, error) {
+	p := &todoPosition{id: id}
+	err := r.q.QueryRow(ctx, `SELECT position FROM todos WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`, id, ownerID).
+		Scan(&p.position)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.NewValidationError("unknown todo", domain.FieldError{Field: field, Rule: "exists"})
+	}
 	if err != nil {
-		return fmt.Errorf("failed to delete todo: %w", err)
+		return nil, fmt.Errorf("failed to get todo position: %w", err)
 	}
+	return p, nil
+}
 
-	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("todo not found")
+// neighbourPosition returns the key of the live todo of ownerID right after
+// from (forward) or right before it, skipping the todo exclude. A nil from
+// is the start (forward) or end of the list. It returns "" when there is no
+// such todo.
+func (r *todoRepository) neighbourPosition(ctx context.Context, ownerID, exclude uuid.UUID, from *todoPosition, forward bool) (string, error) {
+	args := queryArgs{}
+	conds := []string{"user_id = " + args.add(ownerID), "deleted_at IS NULL", "id <> " + args.add(exclude)}
+	comparison, order := "<", "position DESC, id DESC"
+	if forward {
+		comparison, order = ">", "position, id"
+	}
+	if from != nil {
+		conds = append(conds, fmt.Sprintf("(position, id) %s (%s, %s)", comparison, args.add(from.position), args.add(from.id)))
 	}
 
-	return nil
+	var position string
+	query := fmt.Sprintf(`SELECT position FROM todos %s ORDER BY %s LIMIT 1`, whereClause(conds), order)
+	err := r.q.QueryRow(ctx, query, args...).Scan(&position)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("failed to get neighbouring todo: %w", err)
+	}
+	return position, nil
 }
 
-func (r *todoRepository) List(ctx context.Context, pagination domain.PaginationQuery) ([]domain.Todo, int64, error) {
-	// Count total records
-	var total int64
-	countQuery := `SELECT COUNT(*) FROM todos`
-	err := r.db.QueryRow(ctx, countQuery).Scan(&total)
+// RebalancePositions rewrites the position keys of every user who has a key
+// longer than maxKeyLength with short, evenly spaced ones, and returns how
+// many users it rebalanced. The order doesn't change, so neither do the
+// todo versions.
+func (r *todoRepository) RebalancePositions(ctx context.Context, maxKeyLength int) (int, error) {
+	rows, err := r.q.Query(ctx, `SELECT DISTINCT user_id FROM todos WHERE length(position) > $1`, maxKeyLength)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count todos: %w", err)
+		return 0, fmt.Errorf("failed to find long positions: %w", err)
+	}
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan user id: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to find long positions: %w", err)
+	}
+
+	// One transaction per user keeps the locks short
+	for i, userID := range userIDs {
+		err := r.withTx(ctx, func(tx *todoRepository) error {
+			return tx.rebalanceUser(ctx, userID)
+		})
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(userIDs), nil
+}
+
+// rebalanceUser spreads the keys of all of the user's todos, trashed ones
+// included so that they come back in place when restored
+func (r *todoRepository) rebalanceUser(ctx context.Context, userID uuid.UUID) error {
+	rows, err := r.q.Query(ctx, `SELECT id FROM todos WHERE user_id = $1 ORDER BY position, id FOR UPDATE`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to lock todos: %w", err)
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan todo id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to lock todos: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil
 	}
 
-	// Get paginated results
-	offset := (pagination.Page - 1) * pagination.PageSize
 	query := `
-		SELECT id, title, description, completed, user_id, created_at, updated_at
+		UPDATE todos t
+		SET position = k.position
+		FROM unnest($1::uuid[], $2::text[]) AS k(id, position)
+		WHERE t.id = k.id`
+
+	if _, err := r.q.Exec(ctx, query, ids, rank.Spread(len(ids))); err != nil {
+		return fmt.Errorf("failed to rebalance positions: %w", err)
+	}
+
+	return nil
+}
+
+// subtasksCTE is the body of a recursive CTE selecting the live subtasks of
+// the todo $1 at any depth
+const subtasksCTE = `
+	SELECT id FROM todos WHERE parent_id = $1 AND deleted_at IS NULL
+	UNION ALL
+	SELECT t.id FROM todos t JOIN subtasks s ON t.parent_id = s.id WHERE t.deleted_at IS NULL`
+
+// completeSubtasks completes the open subtasks of the todo at any depth.
+// Recurring subtasks stay open: completing one has to go through the
+// service, which creates its next occurrence, or their series would end.
+func (r *todoRepository) completeSubtasks(ctx context.Context, id uuid.UUID, now time.Time) ([]domain.TodoChange, error) {
+	query := `
+		WITH RECURSIVE subtasks AS (` + subtasksCTE + `)
+		SELECT ` + todoColumns + `
 		FROM todos
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2`
+		WHERE id IN (SELECT id FROM subtasks) AND NOT completed AND recurrence = ''`
 
-	rows, err := r.db.Query(ctx, query, pagination.PageSize, offset)
+	changes, err := r.changeTodos(ctx, query, []interface{}{id}, "completed = TRUE, completed_at = $2, updated_at = $2, version = version + 1", now)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get todos: %w", err)
+		return nil, fmt.Errorf("failed to complete subtasks: %w", err)
+	}
+
+	return changes, nil
+}
+
+// touchParents bumps the version of the todos in parentIDs, whose subtask
+// progress changed, so that their ETags change as well. Nil entries,
+// duplicates and the todos in changed, which the change bumped already, are
+// skipped.
+func (r *todoRepository) touchParents(ctx context.Context, parentIDs []*uuid.UUID, changed []uuid.UUID) error {
+	skip := make(map[uuid.UUID]bool, len(changed))
+	for _, id := range changed {
+		skip[id] = true
+	}
+
+	var ids []uuid.UUID
+	for _, id := range parentIDs {
+		if id != nil && !skip[*id] {
+			skip[*id] = true
+			ids = append(ids, *id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if _, err := r.q.Exec(ctx, `UPDATE todos SET version = version + 1 WHERE id = ANY($1)`, ids); err != nil {
+		return fmt.Errorf("failed to update parent todos: %w", err)
+	}
+
+	return nil
+}
+
+// changeTodos locks the todos selected by query, which reads todoColumns,
+// applies set to them and returns each as it was before and after. set
+// takes its arguments from $2 on; $1 is the list of ids.
+func (r *todoRepository) changeTodos(ctx context.Context, query string, selectArgs []interface{}, set string, setArgs ...interface{}) ([]domain.TodoChange, error) {
+	before, err := r.queryTodos(ctx, query+` ORDER BY id FOR UPDATE`, selectArgs...)
+	if err != nil || len(before) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(before))
+	for i, todo := range before {
+		ids[i] = todo.ID
+	}
+	after, err := r.queryTodos(ctx, `
+		UPDATE todos
+		SET `+set+`
+		WHERE id = ANY($1)
+		RETURNING `+todoColumns, append([]interface{}{ids}, setArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadListDetails(ctx, before); err != nil {
+		return nil, err
+	}
+	if err := r.loadListDetails(ctx, after); err != nil {
+		return nil, err
+	}
+
+	afterByID := make(map[uuid.UUID]domain.Todo, len(after))
+	for _, todo := range after {
+		afterByID[todo.ID] = todo
+	}
+	changes := make([]domain.TodoChange, len(before))
+	for i, todo := range before {
+		changes[i] = domain.TodoChange{Before: todo, After: afterByID[todo.ID]}
+	}
+	return changes, nil
+}
+
+// queryTodos runs query, which reads todoColumns, and scans the rows
+func (r *todoRepository) queryTodos(ctx context.Context, query string, args ...interface{}) ([]domain.Todo, error) {
+	rows, err := r.q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
 	}
 	defer rows.Close()
 
 	var todos []domain.Todo
 	for rows.Next() {
 		var todo domain.Todo
-		err := rows.Scan(&todo.ID, &todo.Title, &todo.Description, &todo.Completed, &todo.UserID, &todo.CreatedAt, &todo.UpdatedAt)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan todo: %w", err)
+		if err := scanTodo(rows, &todo); err != nil {
+			return nil, err
 		}
 		todos = append(todos, todo)
 	}
+	return todos, rows.Err()
+}
 
-	return todos, total, nil
+// checkParent rejects parentID as the parent of the todo id unless it is a
+// live todo of ownerID, is not the todo itself or one of its subtasks, and
+// the todo's subtree still fits within domain.MaxTodoDepth below it.
+func (r *todoRepository) checkParent(ctx context.Context, id, parentID, ownerID uuid.UUID) error {
+	// Walk up from the parent; the depth guard also stops the walk should the
+	// stored tree ever contain a cycle
+	var parentDepth int
+	var isAncestor bool
+	err := r.q.QueryRow(ctx, `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 1 AS depth FROM todos
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			UNION ALL
+			SELECT t.id, t.parent_id, a.depth + 1 FROM todos t
+			JOIN ancestors a ON t.id = a.parent_id
+			WHERE a.depth <= $4
+		)
+		SELECT COUNT(*), COALESCE(BOOL_OR(id = $3), FALSE) FROM ancestors`,
+		parentID, ownerID, id, domain.MaxTodoDepth).Scan(&parentDepth, &isAncestor)
+	if err != nil {
+		return fmt.Errorf("failed to check parent: %w", err)
+	}
+
+	if parentDepth == 0 || isAncestor {
+		return parentError(parentDepth, 0, isAncestor)
+	}
+
+	// Height of the subtree that moves along with the todo; 1 for a new todo
+	var height int
+	err = r.q.QueryRow(ctx, `
+		WITH RECURSIVE subtree AS (
+			SELECT $1::uuid AS id, 1 AS depth
+			UNION ALL
+			SELECT t.id, s.depth + 1 FROM todos t
+			JOIN subtree s ON t.parent_id = s.id
+			WHERE s.depth <= $2
+		)
+		SELECT MAX(depth) FROM subtree`, id, domain.MaxTodoDepth).Scan(&height)
+	if err != nil {
+		return fmt.Errorf("failed to check subtasks: %w", err)
+	}
+
+	return parentError(parentDepth, height, isAncestor)
+}
+
+// parentError judges a new parent from the walks of checkParent: the
+// parent's depth (0 if it is unknown), the height of the todo's subtree and
+// whether the todo is one of the parent's ancestors
+func parentError(parentDepth, height int, isAncestor bool) error {
+	switch {
+	case parentDepth == 0:
+		return domain.NewValidationError("unknown parent", domain.FieldError{Field: "parent_id", Rule: "exists"})
+	case isAncestor:
+		return domain.NewValidationError("todo cannot be its own subtask", domain.FieldError{Field: "parent_id", Rule: "cycle"})
+	case parentDepth+height > domain.MaxTodoDepth:
+		return domain.NewValidationError("subtasks are nested too deeply", domain.FieldError{
+			Field: "parent_id",
+			Rule:  "depth",
+			Param: strconv.Itoa(domain.MaxTodoDepth),
+		})
+	}
+
+	return nil
+}
+
+// ProjectRole returns the owner of a project and userID's role in it, which
+// is empty if they are not a member. Todos in a shared project belong to the
+// project's owner; members act on them through their role.
+func (r *todoRepository) ProjectRole(ctx context.Context, projectID, userID uuid.UUID) (uuid.UUID, domain.ProjectRole, error) {
+	var ownerID uuid.UUID
+	var role domain.ProjectRole
+	query := `
+		SELECT p.user_id, COALESCE(m.role, '')
+		FROM projects p
+		LEFT JOIN project_members m ON m.project_id = p.id AND m.user_id = $2
+		WHERE p.id = $1`
+
+	if err := r.q.QueryRow(ctx, query, projectID, userID).Scan(&ownerID, &role); err != nil {
+		return uuid.Nil, "", translateError(err, domain.ErrProjectNotFound, "failed to get project role")
+	}
+
+	return ownerID, role, nil
+}
+
+func (r *todoRepository) RecordAudit(ctx context.Context, event domain.AuditEvent) error {
+	return insertAuditEvent(ctx, r.q, event)
+}
+
+// checkProject rejects projectID unless it is a project of ownerID
+func (r *todoRepository) checkProject(ctx context.Context, projectID, ownerID uuid.UUID) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1 AND user_id = $2)`
+	if err := r.q.QueryRow(ctx, query, projectID, ownerID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check project: %w", err)
+	}
+
+	if !exists {
+		return domain.NewValidationError("unknown project", domain.FieldError{Field: "project_id", Rule: "exists"})
+	}
+
+	return nil
+}
+
+// setTags replaces the todo's tags with tagIDs. Tags that don't exist or
+// belong to someone other than ownerID are rejected.
+func (r *todoRepository) setTags(ctx context.Context, todoID, ownerID uuid.UUID, tagIDs []uuid.UUID) error {
+	if _, err := r.q.Exec(ctx, `DELETE FROM todo_tags WHERE todo_id = $1`, todoID); err != nil {
+		return fmt.Errorf("failed to clear todo tags: %w", err)
+	}
+
+	var unique []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range tagIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO todo_tags (todo_id, tag_id)
+		SELECT $1, id FROM tags
+		WHERE id = ANY($2) AND user_id = $3`
+
+	cmdTag, err := r.q.Exec(ctx, query, todoID, unique, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to set todo tags: %w", err)
+	}
+
+	if cmdTag.RowsAffected() != int64(len(unique)) {
+		return domain.NewValidationError("unknown tag", domain.FieldError{Field: "tag_ids", Rule: "exists"})
+	}
+
+	return nil
+}
+
+// loadTags fills in the tags of todos using a single query, however many
+// todos there are
+func (r *todoRepository) loadTags(ctx context.Context, todos ...*domain.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(todos))
+	byID := make(map[uuid.UUID]*domain.Todo, len(todos))
+	for i, todo := range todos {
+		todo.Tags = []domain.Tag{}
+		ids[i] = todo.ID
+		byID[todo.ID] = todo
+	}
+
+	query := `
+		SELECT tt.todo_id, t.id, t.user_id, t.name, t.color, t.created_at, t.updated_at
+		FROM todo_tags tt
+		JOIN tags t ON t.id = tt.tag_id
+		WHERE tt.todo_id = ANY($1)
+		ORDER BY lower(t.name), t.id`
+
+	rows, err := r.q.Query(ctx, query, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get todo tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID uuid.UUID
+		var tag domain.Tag
+		if err := rows.Scan(&todoID, &tag.ID, &tag.UserID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to scan todo tag: %w", err)
+		}
+		todo := byID[todoID]
+		todo.Tags = append(todo.Tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get todo tags: %w", err)
+	}
+
+	return nil
+}
+
+// loadProgress counts the direct subtasks of todos using a single query
+func (r *todoRepository) loadProgress(ctx context.Context, todos ...*domain.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(todos))
+	byID := make(map[uuid.UUID]*domain.Todo, len(todos))
+	for i, todo := range todos {
+		todo.Progress = domain.TodoProgress{}
+		ids[i] = todo.ID
+		byID[todo.ID] = todo
+	}
+
+	query := `
+		SELECT parent_id, COUNT(*) FILTER (WHERE completed), COUNT(*)
+		FROM todos
+		WHERE parent_id = ANY($1) AND deleted_at IS NULL
+		GROUP BY parent_id`
+
+	rows, err := r.q.Query(ctx, query, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get todo progress: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var parentID uuid.UUID
+		var progress domain.TodoProgress
+		if err := rows.Scan(&parentID, &progress.Completed, &progress.Total); err != nil {
+			return fmt.Errorf("failed to scan todo progress: %w", err)
+		}
+		byID[parentID].Progress = progress
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get todo progress: %w", err)
+	}
+
+	return nil
+}
+
+// loadDetails fills in what is stored outside the todos row: tags and
+// subtask progress
+func (r *todoRepository) loadDetails(ctx context.Context, todos ...*domain.Todo) error {
+	if err := r.loadTags(ctx, todos...); err != nil {
+		return err
+	}
+	return r.loadProgress(ctx, todos...)
+}
+
+func (r *todoRepository) loadListDetails(ctx context.Context, todos []domain.Todo) error {
+	ptrs := make([]*domain.Todo, len(todos))
+	for i := range todos {
+		ptrs[i] = &todos[i]
+	}
+	return r.loadDetails(ctx, ptrs...)
+}
+
+// queryArgs collects positional query arguments while a query is built
+type queryArgs []interface{}
+
+// add appends value and returns its placeholder
+func (a *queryArgs) add(value interface{}) string {
+	*a = append(*a, value)
+	return fmt.Sprintf("$%d", len(*a))
+}
+
+// todoFilterConditions translates filter into WHERE conditions, restricted to
+// ownerID unless it is nil. Filtering by a project also includes its todos
+// when ownerID is a member of it. Trashed todos are excluded unless
+// filter.Trashed asks for them exclusively. rank is the relevance expression
+// when filter contains a search query, and empty otherwise.
+func todoFilterConditions(args *queryArgs, ownerID *uuid.UUID, filter domain.TodoFilter) (conds []string, rank string) {
+	if filter.Trashed {
+		conds = append(conds, "deleted_at IS NOT NULL")
+	} else {
+		conds = append(conds, "deleted_at IS NULL")
+	}
+	owner := ""
+	if ownerID != nil {
+		owner = args.add(*ownerID)
+		if filter.ProjectID != nil {
+			conds = append(conds, "(user_id = "+owner+" OR project_id IN (SELECT project_id FROM project_members WHERE user_id = "+owner+"))")
+		} else {
+			conds = append(conds, "user_id = "+owner)
+		}
+	}
+	if filter.ProjectID != nil {
+		conds = append(conds, "project_id = "+args.add(*filter.ProjectID))
+	}
+	if filter.ParentID != nil {
+		conds = append(conds, "parent_id = "+args.add(*filter.ParentID))
+	}
+	if filter.Completed != nil {
+		conds = append(conds, "completed = "+args.add(*filter.Completed))
+	}
+	if filter.CreatedAfter != nil {
+		conds = append(conds, "created_at >= "+args.add(*filter.CreatedAfter))
+	}
+	if filter.CreatedBefore != nil {
+		conds = append(conds, "created_at < "+args.add(*filter.CreatedBefore))
+	}
+	if filter.Priority != "" {
+		conds = append(conds, "priority = "+args.add(string(filter.Priority)))
+	}
+	if filter.DueAfter != nil {
+		conds = append(conds, "due_at >= "+args.add(*filter.DueAfter))
+	}
+	if filter.DueBefore != nil {
+		conds = append(conds, "due_at < "+args.add(*filter.DueBefore))
+	}
+	if filter.Overdue != nil {
+		// due_at is NULL for todos without a due date, which are never
+		// overdue; the COALESCE keeps the negation from turning NULL
+		overdue := "(COALESCE(due_at < now(), FALSE) AND NOT completed)"
+		if !*filter.Overdue {
+			overdue = "NOT " + overdue
+		}
+		conds = append(conds, overdue)
+	}
+
+	if names := filter.TagNames(); len(names) > 0 {
+		tagged := "SELECT tt.todo_id FROM todo_tags tt JOIN tags t ON t.id = tt.tag_id " +
+			"WHERE lower(t.name) = ANY(" + args.add(names) + ")"
+		if owner != "" {
+			tagged += " AND t.user_id = " + owner
+		}
+		if filter.TagMode != domain.TagModeAny {
+			// Names are unique per user, so a todo with all of them has one
+			// matching tag per name
+			tagged += " GROUP BY tt.todo_id HAVING COUNT(*) = " + args.add(len(names))
+		}
+		conds = append(conds, "id IN ("+tagged+")")
+	}
+
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		tsquery := "websearch_to_tsquery('simple', " + args.add(q) + ")"
+		conds = append(conds, "search_vector @@ "+tsquery)
+		rank = "ts_rank(search_vector, " + tsquery + ")"
+	}
+
+	return conds, rank
+}
+
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conds, " AND ")
+}
+
+// todoSortColumns maps domain.TodoSortFields to SQL, so sort keys never
+// reach a query unchecked
+var todoSortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"title":      "title",
+	"completed":  "completed",
+	"priority":   "priority",
+	"due_at":     "due_at",
+	"position":   "position",
+}
+
+// todoOrderBy builds the ORDER BY clause. Without explicit sort fields,
+// search results are ranked by relevance and everything else is newest first,
+// or most recently deleted first in the trash. id is always the last key so
+// that pages are stable.
+func todoOrderBy(fields []domain.SortField, rank string, trashed bool) string {
+	var keys []string
+	for _, field := range fields {
+		column, ok := todoSortColumns[field.Field]
+		if !ok {
+			continue
+		}
+		if field.Desc {
+			column += " DESC"
+		}
+		if field.Field == "due_at" {
+			// Todos without a due date go last in either direction
+			column += " NULLS LAST"
+		}
+		keys = append(keys, column)
+	}
+
+	if len(keys) == 0 {
+		if rank != "" {
+			keys = append(keys, rank+" DESC")
+		}
+		if trashed {
+			keys = append(keys, "deleted_at DESC")
+		}
+		keys = append(keys, "created_at DESC")
+	}
+
+	return strings.Join(append(keys, "id"), ", ")
+}
+
+func scanTodo(row pgx.Row, todo *domain.Todo) error {
+	return row.Scan(&todo.ID, &todo.Title, &todo.Description, &todo.Completed, &todo.UserID, &todo.ProjectID, &todo.ParentID, &todo.Priority, &todo.DueAt, &todo.Recurrence, &todo.Timezone, &todo.CompletedAt, &todo.Position, &todo.Version, &todo.CreatedAt, &todo.UpdatedAt, &todo.DeletedAt)
 }
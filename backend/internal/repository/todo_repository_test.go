@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestTodoOrderBy(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []domain.SortField
+		rank    string
+		trashed bool
+		want    string
+	}{
+		{name: "newest first by default", want: "created_at DESC, id"},
+		{name: "trash is most recently deleted first", trashed: true, want: "deleted_at DESC, created_at DESC, id"},
+		{name: "search results by relevance", rank: "ts_rank(search, q)", want: "ts_rank(search, q) DESC, created_at DESC, id"},
+		{
+			name:   "explicit fields replace the default",
+			fields: []domain.SortField{{Field: "priority", Desc: true}, {Field: "title"}},
+			rank:   "ts_rank(search, q)",
+			want:   "priority DESC, title, id",
+		},
+		{
+			name:   "todos without a due date go last",
+			fields: []domain.SortField{{Field: "due_at", Desc: true}},
+			want:   "due_at DESC NULLS LAST, id",
+		},
+		{
+			name:   "unknown fields are never interpolated",
+			fields: []domain.SortField{{Field: "title; DROP TABLE todos"}},
+			want:   "created_at DESC, id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := todoOrderBy(tt.fields, tt.rank, tt.trashed); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTodoPatchSetsCompletedAt(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		req      domain.UpdateTodoRequest
+		wantSets []string
+	}{
+		{
+			// COALESCE keeps the time of the first completion
+			name:     "completing stamps completed_at once",
+			req:      domain.UpdateTodoRequest{Completed: domain.PatchValue(true)},
+			wantSets: []string{"completed = $2", "completed_at = COALESCE(completed_at, $3)"},
+		},
+		{
+			name:     "reopening clears completed_at",
+			req:      domain.UpdateTodoRequest{Completed: domain.PatchValue(false)},
+			wantSets: []string{"completed = $2", "completed_at = NULL"},
+		},
+		{
+			name:     "other fields leave completed_at alone",
+			req:      domain.UpdateTodoRequest{Title: domain.PatchValue("Ship it")},
+			wantSets: []string{"title = $2"},
+		},
+		{
+			name:     "a new due date gets a new reminder",
+			req:      domain.UpdateTodoRequest{DueAt: domain.PatchNull[time.Time]()},
+			wantSets: []string{"due_at = $2", "reminded_at = NULL"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := queryArgs{uuid.New()}
+			sets := todoPatchSets(&args, tt.req, now)
+			if !reflect.DeepEqual(sets, tt.wantSets) {
+				t.Fatalf("got %q, want %q", sets, tt.wantSets)
+			}
+		})
+	}
+}
+
+func TestTodoFilterConditionsOverdue(t *testing.T) {
+	overdue := "(COALESCE(due_at < now(), FALSE) AND NOT completed)"
+
+	for _, value := range []bool{true, false} {
+		var args queryArgs
+		conds, _ := todoFilterConditions(&args, nil, domain.TodoFilter{Overdue: &value})
+
+		want := overdue
+		if !value {
+			// Todos without a due date are not overdue, so they must match
+			want = "NOT " + overdue
+		}
+		if !containsString(conds, want) {
+			t.Fatalf("overdue=%v: got conditions %q, want %q", value, conds, want)
+		}
+	}
+}
+
+func TestTodoFilterConditionsTags(t *testing.T) {
+	tagged := "SELECT tt.todo_id FROM todo_tags tt JOIN tags t ON t.id = tt.tag_id WHERE lower(t.name) = ANY($1)"
+	tests := []struct {
+		mode     string
+		want     string
+		wantArgs queryArgs
+	}{
+		{mode: "", want: "id IN (" + tagged + " GROUP BY tt.todo_id HAVING COUNT(*) = $2)", wantArgs: queryArgs{[]string{"work", "home"}, 2}},
+		{mode: domain.TagModeAll, want: "id IN (" + tagged + " GROUP BY tt.todo_id HAVING COUNT(*) = $2)", wantArgs: queryArgs{[]string{"work", "home"}, 2}},
+		{mode: domain.TagModeAny, want: "id IN (" + tagged + ")", wantArgs: queryArgs{[]string{"work", "home"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			var args queryArgs
+			// Duplicates are counted once, or "all" could never match
+			conds, _ := todoFilterConditions(&args, nil, domain.TodoFilter{Tags: []string{"Work", "home", "work "}, TagMode: tt.mode})
+			if !containsString(conds, tt.want) {
+				t.Fatalf("got conditions %q, want %q", conds, tt.want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Fatalf("got args %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+
+	// Tags of other users must not match a todo of this one
+	owner := uuid.New()
+	var args queryArgs
+	conds, _ := todoFilterConditions(&args, &owner, domain.TodoFilter{Tags: []string{"work"}, TagMode: domain.TagModeAny})
+	for _, cond := range conds {
+		if strings.HasPrefix(cond, "id IN (") && !strings.Contains(cond, "AND t.user_id = $") {
+			t.Fatalf("tag condition %q is not restricted to the owner", cond)
+		}
+	}
+}
+
+// checkParent's walks and the subtask cascades of Update, Delete and
+// Restore are SQL that needs a database and is untested here; the service
+// tests cover the same rules against fakeTodoRepository.
+func TestParentError(t *testing.T) {
+	tests := []struct {
+		name        string
+		parentDepth int
+		height      int
+		isAncestor  bool
+		wantRule    string
+	}{
+		{name: "top-level parent", parentDepth: 1, height: 1},
+		{name: "deepest allowed", parentDepth: domain.MaxTodoDepth - 1, height: 1},
+		{name: "subtree fits", parentDepth: 2, height: domain.MaxTodoDepth - 2},
+		{name: "unknown parent", parentDepth: 0, height: 1, wantRule: "exists"},
+		{name: "cycle", parentDepth: 3, height: 2, isAncestor: true, wantRule: "cycle"},
+		{name: "too deep", parentDepth: domain.MaxTodoDepth, height: 1, wantRule: "depth"},
+		{name: "subtree too deep", parentDepth: 2, height: domain.MaxTodoDepth - 1, wantRule: "depth"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parentError(tt.parentDepth, tt.height, tt.isAncestor)
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Fatalf("got error %v, want none", err)
+				}
+				return
+			}
+
+			var domainErr *domain.Error
+			if !errors.As(err, &domainErr) || len(domainErr.Details) != 1 || domainErr.Details[0].Rule != tt.wantRule {
+				t.Fatalf("got error %v, want a %s validation error", err, tt.wantRule)
+			}
+		})
+	}
+}
+
+func containsString(values []string, want string) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
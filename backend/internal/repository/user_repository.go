@@ -12,28 +12,51 @@ import (
 )
 
 type UserRepository interface {
-	Create(ctx context.Context, req domain.CreateUserRequest) (*domain.User, error)
+	Create(ctx context.Context, req domain.CreateUserRequest, passwordHash string) (*domain.User, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
 	Update(ctx context.Context, id uuid.UUID, req domain.UpdateUserRequest) (*domain.User, error)
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	IncrementTokenVersion(ctx context.Context, id uuid.UUID) error
+	UpdateRole(ctx context.Context, id uuid.UUID, role domain.Role) (*domain.User, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, pagination domain.PaginationQuery) ([]domain.User, int64, error)
+	// RecordAudit adds an event to the audit log, in the repository's
+	// transaction when called inside Transaction
+	RecordAudit(ctx context.Context, event domain.AuditEvent) error
+	Transaction(ctx context.Context, fn func(repo UserRepository) error) error
 }
 
 type userRepository struct {
 	db *db.DB
+	// q runs the queries: the pool, or the transaction inside Transaction
+	q    db.Querier
+	inTx bool
 }
 
 func NewUserRepository(database *db.DB) UserRepository {
-	return &userRepository{db: database}
+	return &userRepository{db: database, q: database}
 }
 
-func (r *userRepository) Create(ctx context.Context, req domain.CreateUserRequest) (*domain.User, error) {
+// Transaction runs fn with a repository whose queries all run in a single
+// database transaction, committed if fn returns nil. Calling Transaction on
+// that repository again reuses the transaction.
+func (r *userRepository) Transaction(ctx context.Context, fn func(repo UserRepository) error) error {
+	if r.inTx {
+		return fn(r)
+	}
+
+	return r.db.Transaction(ctx, func(tx db.Querier) error {
+		return fn(&userRepository{db: r.db, q: tx, inTx: true})
+	})
+}
+
+func (r *userRepository) Create(ctx context.Context, req domain.CreateUserRequest, passwordHash string) (*domain.User, error) {
 	user := &domain.User{
 		ID:        uuid.New(),
 		Email:     req.Email,
 		Name:      req.Name,
-		Password:  req.Password, // In real app, this should be hashed
+		Password:  passwordHash,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -41,13 +64,16 @@ func (r *userRepository) Create(ctx context.Context, req domain.CreateUserReques
 	query := `
 		INSERT INTO users (id, email, name, password_hash, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, email, name, created_at, updated_at`
+		RETURNING id, email, name, role, created_at, updated_at`
 
-	err := r.db.QueryRow(ctx, query, user.ID, user.Email, user.Name, user.Password, user.CreatedAt, user.UpdatedAt).
-		Scan(&user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt)
+	err := r.q.QueryRow(ctx, query, user.ID, user.Email, user.Name, user.Password, user.CreatedAt, user.UpdatedAt).
+		Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 
+	if isUniqueViolation(err, "users_email_key") {
+		return nil, domain.ErrEmailExists
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, translateError(err, nil, "failed to create user")
 	}
 
 	return user, nil
@@ -56,15 +82,15 @@ func (r *userRepository) Create(ctx context.Context, req domain.CreateUserReques
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	user := &domain.User{}
 	query := `
-		SELECT id, email, name, password_hash, created_at, updated_at
+		SELECT id, email, name, role, password_hash, token_version, created_at, updated_at
 		FROM users
 		WHERE id = $1`
 
-	err := r.db.QueryRow(ctx, query, id).
-		Scan(&user.ID, &user.Email, &user.Name, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+	err := r.q.QueryRow(ctx, query, id).
+		Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.Password, &user.TokenVersion, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user by id: %w", err)
+		return nil, translateError(err, domain.ErrUserNotFound, "failed to get user by id")
 	}
 
 	return user, nil
@@ -73,15 +99,15 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Use
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	user := &domain.User{}
 	query := `
-		SELECT id, email, name, password_hash, created_at, updated_at
+		SELECT id, email, name, role, password_hash, token_version, created_at, updated_at
 		FROM users
 		WHERE email = $1`
 
-	err := r.db.QueryRow(ctx, query, email).
-		Scan(&user.ID, &user.Email, &user.Name, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+	err := r.q.QueryRow(ctx, query, email).
+		Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.Password, &user.TokenVersion, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user by email: %w", err)
+		return nil, translateError(err, domain.ErrUserNotFound, "failed to get user by email")
 	}
 
 	return user, nil
@@ -95,13 +121,68 @@ func (r *userRepository) Update(ctx context.Context, id uuid.UUID, req domain.Up
 		    email = COALESCE(NULLIF($3, ''), email),
 		    updated_at = $4
 		WHERE id = $1
-		RETURNING id, email, name, created_at, updated_at`
+		RETURNING id, email, name, role, created_at, updated_at`
+
+	err := r.q.QueryRow(ctx, query, id, req.Name, req.Email, time.Now()).
+		Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+
+	if isUniqueViolation(err, "users_email_key") {
+		return nil, domain.ErrEmailExists
+	}
+	if err != nil {
+		return nil, translateError(err, domain.ErrUserNotFound, "failed to update user")
+	}
+
+	return user, nil
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $2, updated_at = $3 WHERE id = $1`
+
+	cmdTag, err := r.q.Exec(ctx, query, id, passwordHash, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *userRepository) IncrementTokenVersion(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE users SET token_version = token_version + 1, updated_at = $2 WHERE id = $1`
+
+	cmdTag, err := r.q.Exec(ctx, query, id, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to increment token version: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateRole changes the user's role and bumps the token version, so tokens
+// carrying the old role stop working.
+func (r *userRepository) UpdateRole(ctx context.Context, id uuid.UUID, role domain.Role) (*domain.User, error) {
+	user := &domain.User{}
+	query := `
+		UPDATE users
+		SET role = $2,
+		    token_version = token_version + 1,
+		    updated_at = $3
+		WHERE id = $1
+		RETURNING id, email, name, role, created_at, updated_at`
 
-	err := r.db.QueryRow(ctx, query, id, req.Name, req.Email, time.Now()).
-		Scan(&user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt)
+	err := r.q.QueryRow(ctx, query, id, role, time.Now()).
+		Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to update user: %w", err)
+		return nil, translateError(err, domain.ErrUserNotFound, "failed to update user role")
 	}
 
 	return user, nil
@@ -110,13 +191,13 @@ func (r *userRepository) Update(ctx context.Context, id uuid.UUID, req domain.Up
 func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM users WHERE id = $1`
 
-	cmdTag, err := r.db.Exec(ctx, query, id)
+	cmdTag, err := r.q.Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
 	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("user not found")
+		return domain.ErrUserNotFound
 	}
 
 	return nil
@@ -126,7 +207,7 @@ func (r *userRepository) List(ctx context.Context, pagination domain.PaginationQ
 	// Count total records
 	var total int64
 	countQuery := `SELECT COUNT(*) FROM users`
-	err := r.db.QueryRow(ctx, countQuery).Scan(&total)
+	err := r.q.QueryRow(ctx, countQuery).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count users: %w", err)
 	}
@@ -134,12 +215,12 @@ func (r *userRepository) List(ctx context.Context, pagination domain.PaginationQ
 	// Get paginated results
 	offset := (pagination.Page - 1) * pagination.PageSize
 	query := `
-		SELECT id, email, name, created_at, updated_at
+		SELECT id, email, name, role, created_at, updated_at
 		FROM users
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2`
 
-	rows, err := r.db.Query(ctx, query, pagination.PageSize, offset)
+	rows, err := r.q.Query(ctx, query, pagination.PageSize, offset)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get users: %w", err)
 	}
@@ -148,7 +229,7 @@ func (r *userRepository) List(ctx context.Context, pagination domain.PaginationQ
 	var users []domain.User
 	for rows.Next() {
 		var user domain.User
-		err := rows.Scan(&user.ID, &user.Email, &user.Name, &user.CreatedAt, &user.UpdatedAt)
+		err := rows.Scan(&user.ID, &user.Email, &user.Name, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
 		}
@@ -157,3 +238,7 @@ func (r *userRepository) List(ctx context.Context, pagination domain.PaginationQ
 
 	return users, total, nil
 }
+
+func (r *userRepository) RecordAudit(ctx context.Context, event domain.AuditEvent) error {
+	return insertAuditEvent(ctx, r.q, event)
+}
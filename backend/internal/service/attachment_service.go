@@ -0,0 +1,246 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/pkg/cursor"
+	"template-fullstack/backend/internal/pkg/storage"
+	"template-fullstack/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// AttachmentOptions limits uploads and controls download URLs. Stores that
+// can't presign URLs are downloaded through the API at
+// DownloadBase/{id}/download, authorized by a signed token.
+type AttachmentOptions struct {
+	MaxSize      int64
+	AllowedTypes []string
+	URLTTL       time.Duration
+	DownloadBase string
+}
+
+type attachmentService struct {
+	attachmentRepo repository.AttachmentRepository
+	todoRepo       repository.TodoRepository
+	store          storage.BlobStore
+	links          *cursor.Codec
+	opts           AttachmentOptions
+}
+
+func NewAttachmentService(attachmentRepo repository.AttachmentRepository, todoRepo repository.TodoRepository, store storage.BlobStore, links *cursor.Codec, opts AttachmentOptions) AttachmentService {
+	return &attachmentService{attachmentRepo: attachmentRepo, todoRepo: todoRepo, store: store, links: links, opts: opts}
+}
+
+// downloadToken is the payload of the token in an API download URL
+type downloadToken struct {
+	ID      uuid.UUID `json:"id"`
+	Expires int64     `json:"exp"`
+}
+
+// Upload stores a file and attaches it to a todo, which requires write
+// access. The content type is detected from the contents rather than taken
+// from the client.
+func (s *attachmentService) Upload(ctx context.Context, actor domain.Actor, todoID uuid.UUID, file domain.AttachmentUpload) (*domain.Attachment, error) {
+	if file.Size <= 0 {
+		return nil, domain.NewValidationError("file is empty", domain.FieldError{Field: "file", Rule: "required"})
+	}
+	if file.Size > s.opts.MaxSize {
+		return nil, domain.NewAttachmentTooLargeError(s.opts.MaxSize)
+	}
+
+	todo, err := s.todoRepo.GetByID(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeTodo(ctx, s.todoRepo, actor, todo, true); err != nil {
+		return nil, err
+	}
+
+	content := bufio.NewReaderSize(io.LimitReader(file.Content, file.Size), 512)
+	head, _ := content.Peek(512)
+	contentType := http.DetectContentType(head)
+	if !s.allowedType(contentType) {
+		return nil, domain.NewValidationError("file type is not allowed",
+			domain.FieldError{Field: "file", Rule: "filetype", Param: strings.Join(s.opts.AllowedTypes, ", ")})
+	}
+
+	hash := sha256.New()
+	key := "todos/" + todo.ID.String() + "/" + uuid.New().String()
+	if err := s.store.Put(ctx, key, io.TeeReader(content, hash), file.Size, contentType); err != nil {
+		return nil, err
+	}
+
+	attachment, err := s.attachmentRepo.Create(ctx, domain.Attachment{
+		TodoID:      todo.ID,
+		UserID:      actor.UserID,
+		Filename:    attachmentFilename(file.Filename),
+		ContentType: contentType,
+		Size:        file.Size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  key,
+	})
+	if err != nil {
+		// Nothing refers to the blob yet
+		_ = s.store.Delete(context.WithoutCancel(ctx), key)
+		return nil, err
+	}
+
+	return s.withURL(ctx, attachment)
+}
+
+func (s *attachmentService) GetByTodoID(ctx context.Context, actor domain.Actor, todoID uuid.UUID) ([]domain.Attachment, error) {
+	if _, err := getTodo(ctx, s.todoRepo, actor, todoID); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.attachmentRepo.GetByTodoID(ctx, todoID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range attachments {
+		if _, err := s.withURL(ctx, &attachments[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return attachments, nil
+}
+
+func (s *attachmentService) GetByID(ctx context.Context, actor domain.Actor, id uuid.UUID) (*domain.Attachment, error) {
+	attachment, err := s.getAttachment(ctx, actor, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withURL(ctx, attachment)
+}
+
+// Delete requires write access to the attachment's todo. The blob is
+// removed in the background.
+func (s *attachmentService) Delete(ctx context.Context, actor domain.Actor, id uuid.UUID) error {
+	if _, err := s.getAttachment(ctx, actor, id, true); err != nil {
+		return err
+	}
+
+	return s.attachmentRepo.Delete(ctx, id)
+}
+
+// Download opens the contents of the attachment named by an API download
+// URL. The token is the authorization, so no actor is needed.
+func (s *attachmentService) Download(ctx context.Context, id uuid.UUID, token string) (*domain.Attachment, io.ReadCloser, error) {
+	var payload downloadToken
+	if err := s.links.Decode(token, &payload); err != nil || payload.ID != id || time.Now().Unix() > payload.Expires {
+		return nil, nil, domain.ErrDownloadLinkInvalid
+	}
+
+	attachment, err := s.attachmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contents, err := s.store.Open(ctx, attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, domain.ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attachment, contents, nil
+}
+
+// getAttachment returns the attachment if the actor can see its todo or,
+// with write, change it. Attachments of other todos are reported as not
+// found.
+func (s *attachmentService) getAttachment(ctx context.Context, actor domain.Actor, id uuid.UUID, write bool) (*domain.Attachment, error) {
+	attachment, err := s.attachmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := s.todoRepo.GetByID(ctx, attachment.TodoID)
+	if err == nil {
+		err = authorizeTodo(ctx, s.todoRepo, actor, todo, write)
+	}
+	if errors.Is(err, domain.ErrTodoNotFound) {
+		return nil, domain.ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// withURL fills in a download URL valid for URLTTL: presigned by the store
+// if it can, or pointing at the API otherwise
+func (s *attachmentService) withURL(ctx context.Context, attachment *domain.Attachment) (*domain.Attachment, error) {
+	expires := time.Now().Add(s.opts.URLTTL)
+
+	if presigner, ok := s.store.(storage.Presigner); ok {
+		u, err := presigner.PresignGet(ctx, attachment.StorageKey, attachment.Filename, s.opts.URLTTL)
+		if err != nil {
+			return nil, err
+		}
+		attachment.URL, attachment.URLExpiresAt = u, expires
+		return attachment, nil
+	}
+
+	token, err := s.links.Encode(downloadToken{ID: attachment.ID, Expires: expires.Unix()})
+	if err != nil {
+		return nil, err
+	}
+	attachment.URL = s.opts.DownloadBase + "/" + attachment.ID.String() + "/download?token=" + url.QueryEscape(token)
+	attachment.URLExpiresAt = expires
+	return attachment, nil
+}
+
+func (s *attachmentService) allowedType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range s.opts.AllowedTypes {
+		if strings.EqualFold(strings.TrimSpace(allowed), mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+const maxFilenameLength = 255
+
+// attachmentFilename keeps the base name of an uploaded file without
+// control characters, as some clients send the full path
+func attachmentFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	if runes := []rune(name); len(runes) > maxFilenameLength {
+		name = string(runes[:maxFilenameLength])
+	}
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	return name
+}
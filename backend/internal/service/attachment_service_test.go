@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/pkg/cursor"
+	"template-fullstack/backend/internal/pkg/storage"
+
+	"github.com/google/uuid"
+)
+
+// fakeAttachmentRepository is an in-memory repository.AttachmentRepository
+type fakeAttachmentRepository struct {
+	attachments map[uuid.UUID]domain.Attachment
+	orphaned    []string
+}
+
+func (r *fakeAttachmentRepository) Create(ctx context.Context, attachment domain.Attachment) (*domain.Attachment, error) {
+	attachment.ID = uuid.New()
+	attachment.CreatedAt = time.Now()
+	r.attachments[attachment.ID] = attachment
+	return &attachment, nil
+}
+
+func (r *fakeAttachmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Attachment, error) {
+	attachment, ok := r.attachments[id]
+	if !ok {
+		return nil, domain.ErrAttachmentNotFound
+	}
+	return &attachment, nil
+}
+
+func (r *fakeAttachmentRepository) GetByTodoID(ctx context.Context, todoID uuid.UUID) ([]domain.Attachment, error) {
+	attachments := []domain.Attachment{}
+	for _, attachment := range r.attachments {
+		if attachment.TodoID == todoID {
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments, nil
+}
+
+func (r *fakeAttachmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	attachment, ok := r.attachments[id]
+	if !ok {
+		return domain.ErrAttachmentNotFound
+	}
+	delete(r.attachments, id)
+	r.orphaned = append(r.orphaned, attachment.StorageKey)
+	return nil
+}
+
+func (r *fakeAttachmentRepository) OrphanedBlobs(ctx context.Context, limit, offset int) ([]string, error) {
+	return r.orphaned, nil
+}
+
+func (r *fakeAttachmentRepository) ForgetOrphanedBlob(ctx context.Context, storageKey string) error {
+	return nil
+}
+
+func TestAttachmentService(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	viewer := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	projectID := uuid.New()
+
+	newService := func(t *testing.T) (AttachmentService, domain.Todo) {
+		todo := domain.Todo{ID: uuid.New(), Title: "File taxes", UserID: owner.UserID, ProjectID: &projectID, Version: 1}
+		todoRepo := newFakeTodoRepository(todo)
+		todoRepo.projects[projectID] = fakeProject{owner: owner.UserID, members: map[uuid.UUID]domain.ProjectRole{
+			viewer.UserID: domain.ProjectRoleViewer,
+		}}
+
+		store, err := storage.NewLocalStore(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		svc := NewAttachmentService(&fakeAttachmentRepository{attachments: make(map[uuid.UUID]domain.Attachment)}, todoRepo, store,
+			cursor.NewCodec("test-secret"), AttachmentOptions{
+				MaxSize:      16,
+				AllowedTypes: []string{"text/plain", "image/png"},
+				URLTTL:       time.Minute,
+				DownloadBase: "/api/v1/attachments",
+			})
+		return svc, todo
+	}
+	upload := func(name, content string) domain.AttachmentUpload {
+		return domain.AttachmentUpload{Filename: name, Size: int64(len(content)), Content: strings.NewReader(content)}
+	}
+
+	t.Run("upload and download", func(t *testing.T) {
+		svc, todo := newService(t)
+		attachment, err := svc.Upload(context.Background(), owner, todo.ID, upload(`C:\Users\me\notes.txt`, "hello"))
+		if err != nil {
+			t.Fatalf("Upload: %v", err)
+		}
+		if attachment.Filename != "notes.txt" || attachment.ContentType != "text/plain; charset=utf-8" ||
+			attachment.Checksum != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+			t.Fatalf("got %s (%s, %s)", attachment.Filename, attachment.ContentType, attachment.Checksum)
+		}
+
+		link, err := url.Parse(attachment.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token := link.Query().Get("token")
+		if link.Path != "/api/v1/attachments/"+attachment.ID.String()+"/download" || token == "" {
+			t.Fatalf("got download URL %s", attachment.URL)
+		}
+
+		_, contents, err := svc.Download(context.Background(), attachment.ID, token)
+		if err != nil {
+			t.Fatalf("Download: %v", err)
+		}
+		data, _ := io.ReadAll(contents)
+		contents.Close()
+		if string(data) != "hello" {
+			t.Fatalf("downloaded %q, want %q", data, "hello")
+		}
+
+		// A token only opens the attachment it was issued for
+		if _, _, err := svc.Download(context.Background(), uuid.New(), token); !errors.Is(err, domain.ErrDownloadLinkInvalid) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrDownloadLinkInvalid)
+		}
+		if _, _, err := svc.Download(context.Background(), attachment.ID, token+"x"); !errors.Is(err, domain.ErrDownloadLinkInvalid) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrDownloadLinkInvalid)
+		}
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		svc, todo := newService(t)
+		_, err := svc.Upload(context.Background(), owner, todo.ID, upload("big.txt", strings.Repeat("x", 17)))
+		if !errors.Is(err, domain.ErrValidation) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrValidation)
+		}
+
+		// The type comes from the contents, not the name
+		_, err = svc.Upload(context.Background(), owner, todo.ID, upload("image.png", "%PDF-1.4"))
+		var domainErr *domain.Error
+		if !errors.As(err, &domainErr) || len(domainErr.Details) != 1 || domainErr.Details[0].Rule != "filetype" {
+			t.Fatalf("got error %v, want a filetype validation error", err)
+		}
+	})
+
+	t.Run("viewers can download but not upload or delete", func(t *testing.T) {
+		svc, todo := newService(t)
+		attachment, err := svc.Upload(context.Background(), owner, todo.ID, upload("notes.txt", "hello"))
+		if err != nil {
+			t.Fatalf("Upload: %v", err)
+		}
+
+		if _, err := svc.GetByID(context.Background(), viewer, attachment.ID); err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if _, err := svc.Upload(context.Background(), viewer, todo.ID, upload("notes.txt", "hello")); !errors.Is(err, domain.ErrProjectReadOnly) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrProjectReadOnly)
+		}
+		if err := svc.Delete(context.Background(), viewer, attachment.ID); !errors.Is(err, domain.ErrProjectReadOnly) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrProjectReadOnly)
+		}
+	})
+
+	t.Run("outsiders cannot see the todo", func(t *testing.T) {
+		svc, todo := newService(t)
+		attachment, err := svc.Upload(context.Background(), owner, todo.ID, upload("notes.txt", "hello"))
+		if err != nil {
+			t.Fatalf("Upload: %v", err)
+		}
+
+		outsider := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+		if _, err := svc.Upload(context.Background(), outsider, todo.ID, upload("notes.txt", "hello")); !errors.Is(err, domain.ErrTodoNotFound) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrTodoNotFound)
+		}
+		if err := svc.Delete(context.Background(), outsider, attachment.ID); !errors.Is(err, domain.ErrAttachmentNotFound) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrAttachmentNotFound)
+		}
+	})
+}
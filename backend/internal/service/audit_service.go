@@ -0,0 +1,188 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type auditService struct {
+	auditRepo repository.AuditRepository
+	todoRepo  repository.TodoRepository
+}
+
+func NewAuditService(auditRepo repository.AuditRepository, todoRepo repository.TodoRepository) AuditService {
+	return &auditService{auditRepo: auditRepo, todoRepo: todoRepo}
+}
+
+// TodoHistory lists the changes to a todo, newest first. The history of a
+// todo in the trash stays visible to everyone who could see the todo.
+func (s *auditService) TodoHistory(ctx context.Context, actor domain.Actor, todoID uuid.UUID, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error) {
+	todo, err := s.todoRepo.GetByID(ctx, todoID)
+	if errors.Is(err, domain.ErrTodoNotFound) {
+		todo, err = s.todoRepo.GetTrashedByID(ctx, todoID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeTodo(ctx, s.todoRepo, actor, todo, false); err != nil {
+		return nil, err
+	}
+
+	return s.search(ctx, domain.AuditFilter{EntityType: domain.AuditEntityTodo, EntityID: todoID.String()}, pagination)
+}
+
+func (s *auditService) Search(ctx context.Context, filter domain.AuditFilter, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.search(ctx, filter, pagination)
+}
+
+func (s *auditService) search(ctx context.Context, filter domain.AuditFilter, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error) {
+	events, total, err := s.auditRepo.Search(ctx, filter, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewPaginatedResponse(events, total, pagination), nil
+}
+
+// recordTodoAudit logs a change to a todo. repo must be the one inside the
+// change's transaction.
+func recordTodoAudit(ctx context.Context, repo repository.TodoRepository, action domain.AuditAction, before, after *domain.Todo) error {
+	id := entityID(before, after, func(todo *domain.Todo) uuid.UUID { return todo.ID })
+	event, err := newAuditEvent(ctx, domain.AuditEntityTodo, id, action, before, after)
+	if err != nil || event == nil {
+		return err
+	}
+	return repo.RecordAudit(ctx, *event)
+}
+
+// recordCascadeAudit logs the changes a write to a todo cascaded to its
+// subtasks, one event each. Deleted subtasks are logged without an after
+// state, like the todo itself.
+func recordCascadeAudit(ctx context.Context, repo repository.TodoRepository, action domain.AuditAction, changes []domain.TodoChange) error {
+	for i := range changes {
+		after := &changes[i].After
+		if action == domain.AuditActionDelete {
+			after = nil
+		}
+		if err := recordTodoAudit(ctx, repo, action, &changes[i].Before, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordUserAudit logs a change to a user. repo must be the one inside the
+// change's transaction.
+func recordUserAudit(ctx context.Context, repo repository.UserRepository, action domain.AuditAction, before, after *domain.User) error {
+	id := entityID(before, after, func(user *domain.User) uuid.UUID { return user.ID })
+	event, err := newAuditEvent(ctx, domain.AuditEntityUser, id, action, before, after)
+	if err != nil || event == nil {
+		return err
+	}
+	return repo.RecordAudit(ctx, *event)
+}
+
+func entityID[T any](before, after *T, id func(*T) uuid.UUID) uuid.UUID {
+	if after != nil {
+		return id(after)
+	}
+	return id(before)
+}
+
+// auditIgnoredFields change with every write or are derived from other
+// records, so they are left out of the diff
+var auditIgnoredFields = []string{"updated_at", "version", "progress"}
+
+// newAuditEvent describes the change from before to after, either of which
+// is nil for creates and deletes, made in the request carried by ctx. It
+// returns nil for an update that changed nothing.
+func newAuditEvent[T any](ctx context.Context, entity domain.AuditEntity, id uuid.UUID, action domain.AuditAction, before, after *T) (*domain.AuditEvent, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep only the fields that differ, as null on the side that lacks them
+	if before != nil && after != nil {
+		for field, value := range beforeFields {
+			if other, ok := afterFields[field]; ok && bytes.Equal(value, other) {
+				delete(beforeFields, field)
+				delete(afterFields, field)
+			}
+		}
+		for field := range afterFields {
+			if _, ok := beforeFields[field]; !ok {
+				beforeFields[field] = json.RawMessage("null")
+			}
+		}
+		for field := range beforeFields {
+			if _, ok := afterFields[field]; !ok {
+				afterFields[field] = json.RawMessage("null")
+			}
+		}
+		if len(beforeFields) == 0 {
+			return nil, nil
+		}
+	}
+
+	info := domain.RequestInfoFromContext(ctx)
+	event := &domain.AuditEvent{
+		ActorID:    info.ActorID,
+		EntityType: entity,
+		EntityID:   id,
+		Action:     action,
+		RequestID:  info.RequestID,
+		IP:         info.IP,
+	}
+	if event.Before, err = marshalAuditFields(beforeFields); err != nil {
+		return nil, err
+	}
+	if event.After, err = marshalAuditFields(afterFields); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// auditFields returns the top-level fields of v's JSON representation
+func auditFields[T any](v *T) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, field := range auditIgnoredFields {
+		delete(fields, field)
+	}
+
+	return fields, nil
+}
+
+func marshalAuditFields(fields map[string]json.RawMessage) (json.RawMessage, error) {
+	if fields == nil {
+		return nil, nil
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/pkg/cursor"
+
+	"github.com/google/uuid"
+)
+
+// fakeAuditRepository searches the events recorded by a fakeTodoRepository
+type fakeAuditRepository struct {
+	todoRepo *fakeTodoRepository
+}
+
+func (r *fakeAuditRepository) Search(ctx context.Context, filter domain.AuditFilter, pagination domain.PaginationQuery) ([]domain.AuditEvent, int64, error) {
+	events := []domain.AuditEvent{}
+	for i := len(r.todoRepo.audit) - 1; i >= 0; i-- {
+		event := r.todoRepo.audit[i]
+		if (filter.EntityType == "" || event.EntityType == filter.EntityType) &&
+			(filter.EntityID == "" || event.EntityID.String() == filter.EntityID) {
+			events = append(events, event)
+		}
+	}
+	return events, int64(len(events)), nil
+}
+
+// auditChanges decodes the before and after of an event
+func auditChanges(t *testing.T, event domain.AuditEvent) (before, after map[string]interface{}) {
+	t.Helper()
+	if event.Before != nil {
+		if err := json.Unmarshal(event.Before, &before); err != nil {
+			t.Fatalf("before: %v", err)
+		}
+	}
+	if event.After != nil {
+		if err := json.Unmarshal(event.After, &after); err != nil {
+			t.Fatalf("after: %v", err)
+		}
+	}
+	return before, after
+}
+
+func TestTodoServiceAudit(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	ctx := domain.WithRequestInfo(context.Background(), domain.RequestInfo{
+		ActorID:   &owner.UserID,
+		RequestID: "req-1",
+		IP:        "192.0.2.1",
+	})
+
+	repo := newFakeTodoRepository()
+	svc := NewTodoService(repo, cursor.NewCodec("test-secret"), 10)
+
+	todo, err := svc.Create(ctx, domain.CreateTodoRequest{Title: "Write tests", UserID: owner.UserID})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := svc.Update(ctx, owner, todo.ID, domain.UpdateTodoRequest{Title: domain.PatchValue("Write more tests")}, 0); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	// Nothing changes, so nothing is recorded
+	if _, err := svc.Update(ctx, owner, todo.ID, domain.UpdateTodoRequest{Title: domain.PatchValue("Write more tests")}, 0); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := svc.Delete(ctx, owner, todo.ID, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := svc.Restore(ctx, owner, todo.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	wantActions := []domain.AuditAction{domain.AuditActionCreate, domain.AuditActionUpdate, domain.AuditActionDelete, domain.AuditActionRestore}
+	if len(repo.audit) != len(wantActions) {
+		t.Fatalf("got %d audit events, want %d", len(repo.audit), len(wantActions))
+	}
+	for i, event := range repo.audit {
+		if event.Action != wantActions[i] || event.EntityType != domain.AuditEntityTodo || event.EntityID != todo.ID {
+			t.Fatalf("event %d: got %s %s %s", i, event.Action, event.EntityType, event.EntityID)
+		}
+		if event.ActorID == nil || *event.ActorID != owner.UserID || event.RequestID != "req-1" || event.IP != "192.0.2.1" {
+			t.Fatalf("event %d is not attributed to the request", i)
+		}
+	}
+
+	before, after := auditChanges(t, repo.audit[0])
+	if before != nil || after["title"] != "Write tests" {
+		t.Fatalf("create: got before %v, after %v", before, after)
+	}
+
+	before, after = auditChanges(t, repo.audit[1])
+	if len(before) != 1 || before["title"] != "Write tests" || len(after) != 1 || after["title"] != "Write more tests" {
+		t.Fatalf("update: got before %v, after %v", before, after)
+	}
+
+	before, after = auditChanges(t, repo.audit[2])
+	if before["title"] != "Write more tests" || after != nil {
+		t.Fatalf("delete: got before %v, after %v", before, after)
+	}
+
+	before, after = auditChanges(t, repo.audit[3])
+	if _, ok := before["deleted_at"]; !ok || len(before) != 1 || after["deleted_at"] != nil {
+		t.Fatalf("restore: got before %v, after %v", before, after)
+	}
+
+	t.Run("subtasks changed through their parent", func(t *testing.T) {
+		parent, err := svc.Create(ctx, domain.CreateTodoRequest{Title: "Release", UserID: owner.UserID})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		subtask, err := svc.Create(ctx, domain.CreateTodoRequest{Title: "Tag it", UserID: owner.UserID, ParentID: &parent.ID})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		recorded := len(repo.audit)
+		if _, err := svc.Update(ctx, owner, parent.ID, domain.UpdateTodoRequest{Completed: domain.PatchValue(true)}, 0); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if err := svc.Delete(ctx, owner, parent.ID, 0); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := svc.Restore(ctx, owner, parent.ID); err != nil {
+			t.Fatalf("Restore: %v", err)
+		}
+
+		var events []domain.AuditEvent
+		for _, event := range repo.audit[recorded:] {
+			if event.EntityID == subtask.ID {
+				events = append(events, event)
+			}
+		}
+		wantActions := []domain.AuditAction{domain.AuditActionUpdate, domain.AuditActionDelete, domain.AuditActionRestore}
+		if len(events) != len(wantActions) {
+			t.Fatalf("got %d audit events for the subtask, want %d", len(events), len(wantActions))
+		}
+		for i, event := range events {
+			if event.Action != wantActions[i] || event.RequestID != "req-1" {
+				t.Fatalf("event %d: got %s in %q", i, event.Action, event.RequestID)
+			}
+		}
+
+		before, after := auditChanges(t, events[0])
+		if before["completed"] != false || after["completed"] != true {
+			t.Fatalf("completion: got before %v, after %v", before, after)
+		}
+		before, after = auditChanges(t, events[1])
+		if before["title"] != "Tag it" || after != nil {
+			t.Fatalf("delete: got before %v, after %v", before, after)
+		}
+	})
+
+	t.Run("rolled back with the change", func(t *testing.T) {
+		recorded := len(repo.audit)
+		resp, err := svc.Bulk(ctx, owner, domain.BulkTodoRequest{Operations: []domain.BulkTodoOperation{
+			{Op: domain.BulkOpCreate, Data: []byte(`{"title": "Ship it"}`)},
+			{Op: domain.BulkOpDelete, ID: uuid.New()},
+		}})
+		if err != nil {
+			t.Fatalf("Bulk: %v", err)
+		}
+		if resp.Committed {
+			t.Fatal("batch with a missing todo was committed")
+		}
+		if len(repo.audit) != recorded {
+			t.Fatalf("rolled back batch left %d audit events", len(repo.audit)-recorded)
+		}
+	})
+}
+
+func TestAuditServiceTodoHistory(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	stranger := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	ctx := context.Background()
+	pagination := domain.PaginationQuery{Page: 1, PageSize: 10}
+
+	repo := newFakeTodoRepository()
+	todoService := NewTodoService(repo, cursor.NewCodec("test-secret"), 10)
+	svc := NewAuditService(&fakeAuditRepository{todoRepo: repo}, repo)
+
+	todo, err := todoService.Create(ctx, domain.CreateTodoRequest{Title: "Write tests", UserID: owner.UserID})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := todoService.Create(ctx, domain.CreateTodoRequest{Title: "Other", UserID: owner.UserID}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := todoService.Delete(ctx, owner, todo.ID, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	// The history stays readable in the trash, newest first
+	resp, err := svc.TodoHistory(ctx, owner, todo.ID, pagination)
+	if err != nil {
+		t.Fatalf("TodoHistory: %v", err)
+	}
+	events := resp.Data.([]domain.AuditEvent)
+	if len(events) != 2 || events[0].Action != domain.AuditActionDelete || events[1].Action != domain.AuditActionCreate {
+		t.Fatalf("got %d events, want delete and create", len(events))
+	}
+
+	if _, err := svc.TodoHistory(ctx, stranger, todo.ID, pagination); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Fatalf("got error %v, want %v", err, domain.ErrTodoNotFound)
+	}
+}
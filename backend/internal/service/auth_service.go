@@ -2,7 +2,14 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"sync"
 	"time"
 
 	"template-fullstack/backend/internal/domain"
@@ -14,76 +21,346 @@ import (
 
 type AuthService interface {
 	Login(ctx context.Context, req domain.LoginRequest) (*domain.LoginResponse, error)
-	ValidateToken(tokenString string) (*Claims, error)
-	GenerateToken(userID uuid.UUID, email string) (string, error)
+	Refresh(ctx context.Context, refreshToken string) (*domain.LoginResponse, error)
+	IssueTokens(ctx context.Context, user *domain.User) (*domain.LoginResponse, error)
+	Logout(ctx context.Context, claims *Claims, refreshToken string) error
+	LogoutAll(ctx context.Context, userID uuid.UUID) error
+	ValidateToken(ctx context.Context, tokenString string) (*Claims, error)
+	GenerateToken(user *domain.User) (string, error)
 }
 
+// UserService records every change in the audit log, attributed to the
+// request in the context (see domain.RequestInfo)
 type UserService interface {
 	Create(ctx context.Context, req domain.CreateUserRequest) (*domain.User, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
 	Update(ctx context.Context, id uuid.UUID, req domain.UpdateUserRequest) (*domain.User, error)
+	SetRole(ctx context.Context, id uuid.UUID, role domain.Role) (*domain.User, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error)
 }
 
+// TodoService methods taking an actor let a todo's owner, admins and members
+// of the todo's project through; project viewers may only read. Every change
+// is recorded in the audit log, attributed to the request in the context.
 type TodoService interface {
 	Create(ctx context.Context, req domain.CreateTodoRequest) (*domain.Todo, error)
-	GetByID(ctx context.Context, id uuid.UUID) (*domain.Todo, error)
-	GetByUserID(ctx context.Context, userID uuid.UUID, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error)
-	Update(ctx context.Context, id uuid.UUID, req domain.UpdateTodoRequest) (*domain.Todo, error)
-	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error)
+	GetByID(ctx context.Context, actor domain.Actor, id uuid.UUID) (*domain.Todo, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID, filter domain.TodoFilter, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error)
+	// Update and Delete are conditional on expectedVersion unless it is zero.
+	// Delete moves the todo to the trash.
+	Update(ctx context.Context, actor domain.Actor, id uuid.UUID, req domain.UpdateTodoRequest, expectedVersion int) (*domain.Todo, error)
+	Delete(ctx context.Context, actor domain.Actor, id uuid.UUID, expectedVersion int) error
+	List(ctx context.Context, filter domain.TodoFilter, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error)
+	// GetByUserIDCursor and ListCursor are the keyset-paginated variants of
+	// GetByUserID and List
+	GetByUserIDCursor(ctx context.Context, userID uuid.UUID, filter domain.TodoFilter, page domain.CursorQuery) (*domain.CursorPaginatedResponse, error)
+	ListCursor(ctx context.Context, filter domain.TodoFilter, page domain.CursorQuery) (*domain.CursorPaginatedResponse, error)
+	// Move reorders a todo among the owner's todos, conditional on
+	// expectedVersion unless it is zero
+	Move(ctx context.Context, actor domain.Actor, id uuid.UUID, req domain.MoveTodoRequest, expectedVersion int) (*domain.Todo, error)
+	// GetSubtasks lists the direct subtasks of a todo, like GetByUserID
+	GetSubtasks(ctx context.Context, actor domain.Actor, id uuid.UUID, filter domain.TodoFilter, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error)
+	// GetOccurrences lists the upcoming due dates of a recurring todo
+	GetOccurrences(ctx context.Context, actor domain.Actor, id uuid.UUID, query domain.OccurrenceQuery) ([]time.Time, error)
+	// Restore takes a todo out of the trash
+	Restore(ctx context.Context, actor domain.Actor, id uuid.UUID) (*domain.Todo, error)
+	Bulk(ctx context.Context, actor domain.Actor, req domain.BulkTodoRequest) (*domain.BulkTodoResponse, error)
+}
+
+type TagService interface {
+	Create(ctx context.Context, req domain.CreateTagRequest) (*domain.Tag, error)
+	GetByID(ctx context.Context, actor domain.Actor, id uuid.UUID) (*domain.Tag, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]domain.Tag, error)
+	Update(ctx context.Context, actor domain.Actor, id uuid.UUID, req domain.UpdateTagRequest) (*domain.Tag, error)
+	// Delete detaches the tag from all todos
+	Delete(ctx context.Context, actor domain.Actor, id uuid.UUID) error
+}
+
+// ProjectService methods taking an actor let members of the project through.
+// Changing the project or its members requires the owner role; admins may do
+// anything.
+type ProjectService interface {
+	Create(ctx context.Context, req domain.CreateProjectRequest) (*domain.Project, error)
+	GetByID(ctx context.Context, actor domain.Actor, id uuid.UUID) (*domain.Project, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID, query domain.ProjectListQuery) ([]domain.Project, error)
+	Update(ctx context.Context, actor domain.Actor, id uuid.UUID, req domain.UpdateProjectRequest) (*domain.Project, error)
+	// Delete removes the project; its todos are kept without a project
+	Delete(ctx context.Context, actor domain.Actor, id uuid.UUID) error
+	// GetTodos lists the project's todos, like TodoService.GetByUserID
+	GetTodos(ctx context.Context, actor domain.Actor, id uuid.UUID, filter domain.TodoFilter, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error)
+	GetMembers(ctx context.Context, actor domain.Actor, id uuid.UUID) ([]domain.ProjectMember, error)
+	UpdateMember(ctx context.Context, actor domain.Actor, id, userID uuid.UUID, req domain.UpdateProjectMemberRequest) (*domain.ProjectMember, error)
+	// RemoveMember removes a member, or lets the actor leave the project
+	RemoveMember(ctx context.Context, actor domain.Actor, id, userID uuid.UUID) error
+	Invite(ctx context.Context, actor domain.Actor, id uuid.UUID, req domain.CreateProjectInvitationRequest) (*domain.ProjectInvitation, error)
+	GetInvitations(ctx context.Context, actor domain.Actor, id uuid.UUID) ([]domain.ProjectInvitation, error)
+	RevokeInvitation(ctx context.Context, actor domain.Actor, id, invitationID uuid.UUID) error
+	// GetMyInvitations, AcceptInvitation and DeclineInvitation act on the
+	// invitations sent to the actor's email
+	GetMyInvitations(ctx context.Context, actor domain.Actor) ([]domain.ProjectInvitation, error)
+	AcceptInvitation(ctx context.Context, actor domain.Actor, invitationID uuid.UUID) (*domain.Project, error)
+	DeclineInvitation(ctx context.Context, actor domain.Actor, invitationID uuid.UUID) error
 }
 
+// CommentService methods check that the actor can see the comment's todo,
+// like TodoService.GetByID
+type CommentService interface {
+	Create(ctx context.Context, actor domain.Actor, req domain.CreateCommentRequest) (*domain.Comment, error)
+	// GetByTodoID lists the todo's comments, oldest first
+	GetByTodoID(ctx context.Context, actor domain.Actor, todoID uuid.UUID, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error)
+	Update(ctx context.Context, actor domain.Actor, id uuid.UUID, req domain.UpdateCommentRequest) (*domain.Comment, error)
+	// Delete soft deletes the comment
+	Delete(ctx context.Context, actor domain.Actor, id uuid.UUID) error
+}
+
+// AttachmentService methods check that the actor can see the attachment's
+// todo, like TodoService.GetByID; uploading and deleting need write access.
+// Returned attachments carry a short-lived download URL.
+type AttachmentService interface {
+	Upload(ctx context.Context, actor domain.Actor, todoID uuid.UUID, file domain.AttachmentUpload) (*domain.Attachment, error)
+	GetByTodoID(ctx context.Context, actor domain.Actor, todoID uuid.UUID) ([]domain.Attachment, error)
+	GetByID(ctx context.Context, actor domain.Actor, id uuid.UUID) (*domain.Attachment, error)
+	Delete(ctx context.Context, actor domain.Actor, id uuid.UUID) error
+	// Download opens an attachment through a download URL served by the API;
+	// the caller closes the contents
+	Download(ctx context.Context, id uuid.UUID, token string) (*domain.Attachment, io.ReadCloser, error)
+}
+
+// AuditService reads the audit log that TodoService and UserService write
+// with every change
+type AuditService interface {
+	// TodoHistory lists a todo's changes, newest first, to those who can
+	// see the todo
+	TodoHistory(ctx context.Context, actor domain.Actor, todoID uuid.UUID, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error)
+	// Search lists the events matching filter, newest first. It is meant
+	// for admins.
+	Search(ctx context.Context, filter domain.AuditFilter, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error)
+}
+
+// Claims are the JWT claims of an access token. RegisteredClaims.ID is the
+// jti used for revocation; TokenVersion must match the user's current version.
 type Claims struct {
-	UserID uuid.UUID `json:"user_id"`
-	Email  string    `json:"email"`
+	UserID       uuid.UUID   `json:"user_id"`
+	Email        string      `json:"email"`
+	Role         domain.Role `json:"role"`
+	TokenVersion int         `json:"ver"`
 	jwt.RegisteredClaims
 }
 
 type authService struct {
-	userRepo  repository.UserRepository
-	jwtSecret string
-	jwtExpiry time.Duration
+	userRepo      repository.UserRepository
+	refreshRepo   repository.RefreshTokenRepository
+	revocations   TokenRevocationStore
+	hasher        PasswordHasher
+	jwtSecret     string
+	jwtExpiry     time.Duration
+	refreshExpiry time.Duration
+
+	// dummyHash is verified against for unknown emails, so that they take as
+	// long to reject as a wrong password
+	dummyOnce sync.Once
+	dummyHash string
 }
 
-func NewAuthService(userRepo repository.UserRepository, jwtSecret string, jwtExpiry time.Duration) AuthService {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	refreshRepo repository.RefreshTokenRepository,
+	revocations TokenRevocationStore,
+	hasher PasswordHasher,
+	jwtSecret string,
+	jwtExpiry time.Duration,
+	refreshExpiry time.Duration,
+) AuthService {
 	return &authService{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
-		jwtExpiry: jwtExpiry,
+		userRepo:      userRepo,
+		refreshRepo:   refreshRepo,
+		revocations:   revocations,
+		hasher:        hasher,
+		jwtSecret:     jwtSecret,
+		jwtExpiry:     jwtExpiry,
+		refreshExpiry: refreshExpiry,
 	}
 }
 
 func (s *authService) Login(ctx context.Context, req domain.LoginRequest) (*domain.LoginResponse, error) {
-	// In a real application, you would hash the password and compare
-	// For this template, we'll do a simple mock validation
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if errors.Is(err, domain.ErrNotFound) {
+		_, _ = s.hasher.Verify(s.unknownUserHash(), req.Password)
+		return nil, domain.ErrInvalidCredentials
+	}
 	if err != nil {
-		return nil, fmt.Errorf("invalid credentials")
+		return nil, err
+	}
+
+	ok, err := s.hasher.Verify(user.Password, req.Password)
+	if err != nil || !ok {
+		return nil, domain.ErrInvalidCredentials
 	}
 
-	// Simple password check (in real app, use bcrypt)
-	if user.Password != req.Password {
-		return nil, fmt.Errorf("invalid credentials")
+	// Transparently upgrade hashes made with an outdated algorithm or cost.
+	// This is best-effort: the old hash keeps working if the update fails.
+	if s.hasher.NeedsRehash(user.Password) {
+		if newHash, err := s.hasher.Hash(req.Password); err == nil {
+			_ = s.userRepo.UpdatePassword(ctx, user.ID, newHash)
+		}
 	}
 
-	token, err := s.GenerateToken(user.ID, user.Email)
+	return s.IssueTokens(ctx, user)
+}
+
+// unknownUserHash returns a hash of a random password made with the
+// preferred algorithm, so verifying against it costs as much as a real login
+func (s *authService) unknownUserHash() string {
+	s.dummyOnce.Do(func() {
+		password := make([]byte, 16)
+		_, _ = rand.Read(password)
+		s.dummyHash, _ = s.hasher.Hash(hex.EncodeToString(password))
+	})
+	return s.dummyHash
+}
+
+// IssueTokens starts a new session for user: a fresh access token and the
+// first refresh token of a new family.
+func (s *authService) IssueTokens(ctx context.Context, user *domain.User) (*domain.LoginResponse, error) {
+	plain, refresh, err := s.newRefreshToken(user.ID, uuid.New())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshRepo.Create(ctx, refresh); err != nil {
+		return nil, err
+	}
+
+	return s.buildResponse(user, plain, refresh)
+}
+
+// Refresh exchanges a refresh token for a new access/refresh token pair. Each
+// refresh token can be used once; presenting one that was already rotated
+// is treated as theft and revokes every token in its family.
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (*domain.LoginResponse, error) {
+	current, err := s.refreshRepo.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	if current.RevokedAt != nil {
+		if err := s.refreshRepo.RevokeFamily(ctx, current.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrRefreshTokenReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, current.UserID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	plain, next, err := s.newRefreshToken(user.ID, current.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshRepo.Rotate(ctx, current.ID, next); err != nil {
+		if errors.Is(err, domain.ErrRefreshTokenReused) {
+			_ = s.refreshRepo.RevokeFamily(ctx, current.FamilyID)
+		}
+		return nil, err
+	}
+
+	return s.buildResponse(user, plain, next)
+}
+
+// Logout revokes the presented access token and, if given, the refresh token
+// family it belongs to.
+func (s *authService) Logout(ctx context.Context, claims *Claims, refreshToken string) error {
+	if err := s.revocations.Revoke(ctx, claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	current, err := s.refreshRepo.GetByHash(ctx, hashRefreshToken(refreshToken))
+	if errors.Is(err, domain.ErrInvalidRefreshToken) || (err == nil && current.UserID != claims.UserID) {
+		// Nothing of the caller's to revoke
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.refreshRepo.RevokeFamily(ctx, current.FamilyID)
+}
+
+// LogoutAll invalidates every access and refresh token issued to the user.
+func (s *authService) LogoutAll(ctx context.Context, userID uuid.UUID) error {
+	if err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+		return err
+	}
+	s.revocations.ForgetTokenVersion(userID)
+
+	return s.refreshRepo.RevokeAllForUser(ctx, userID)
+}
+
+func (s *authService) buildResponse(user *domain.User, refreshToken string, refresh *domain.RefreshToken) (*domain.LoginResponse, error) {
+	token, err := s.GenerateToken(user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
 	return &domain.LoginResponse{
-		Token: token,
-		User:  *user,
+		Token:            token,
+		ExpiresAt:        time.Now().Add(s.jwtExpiry),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refresh.ExpiresAt,
+		User:             *user,
+	}, nil
+}
+
+// newRefreshToken returns an opaque token for the client and the record to
+// persist, which only holds the token's hash.
+func (s *authService) newRefreshToken(userID, familyID uuid.UUID) (string, *domain.RefreshToken, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	plain := base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now()
+	return plain, &domain.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashRefreshToken(plain),
+		ExpiresAt: now.Add(s.refreshExpiry),
+		CreatedAt: now,
 	}, nil
 }
 
-func (s *authService) GenerateToken(userID uuid.UUID, email string) (string, error) {
+// Refresh tokens are random and high-entropy, so a plain SHA-256 is enough
+// to keep them useless if the table leaks.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *authService) GenerateToken(user *domain.User) (string, error) {
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:       user.ID,
+		Email:        user.Email,
+		Role:         user.Role,
+		TokenVersion: user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.jwtExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -93,11 +370,11 @@ func (s *authService) GenerateToken(userID uuid.UUID, email string) (string, err
 	return token.SignedString([]byte(s.jwtSecret))
 }
 
-func (s *authService) ValidateToken(tokenString string) (*Claims, error) {
+func (s *authService) ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		return []byte(s.jwtSecret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, err
@@ -107,5 +384,20 @@ func (s *authService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, fmt.Errorf("invalid token")
 	}
 
+	// Every token must be individually revocable
+	if claims.ID == "" {
+		return nil, fmt.Errorf("invalid token: missing jti")
+	}
+
+	// Tokens of deleted accounts, or issued before "log out all sessions",
+	// must stop working before they expire
+	version, err := s.revocations.TokenVersion(ctx, claims.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	if version != claims.TokenVersion {
+		return nil, fmt.Errorf("invalid token: session was revoked")
+	}
+
 	return claims, nil
 }
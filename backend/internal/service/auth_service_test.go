@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakeRefreshTokenRepository is an in-memory repository.RefreshTokenRepository
+type fakeRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]domain.RefreshToken
+	// revokedFamilies counts RevokeFamily calls per family
+	revokedFamilies map[uuid.UUID]int
+	// lookups, if set, holds GetByHash until it is released, to let
+	// concurrent refreshes read the same token before either rotates it
+	lookups *sync.WaitGroup
+}
+
+func newFakeRefreshTokenRepository() *fakeRefreshTokenRepository {
+	return &fakeRefreshTokenRepository{
+		tokens:          make(map[uuid.UUID]domain.RefreshToken),
+		revokedFamilies: make(map[uuid.UUID]int),
+	}
+}
+
+func (r *fakeRefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *fakeRefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	if r.lookups != nil {
+		r.lookups.Done()
+		r.lookups.Wait()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+	return nil, domain.ErrInvalidRefreshToken
+}
+
+func (r *fakeRefreshTokenRepository) Rotate(ctx context.Context, oldID uuid.UUID, next *domain.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.tokens[oldID]
+	if old.RevokedAt != nil {
+		return domain.ErrRefreshTokenReused
+	}
+	now := time.Now()
+	old.RevokedAt, old.ReplacedBy = &now, &next.ID
+	r.tokens[oldID] = old
+	r.tokens[next.ID] = *next
+	return nil
+}
+
+func (r *fakeRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revokedFamilies[familyID]++
+	r.revokeWhere(func(token domain.RefreshToken) bool { return token.FamilyID == familyID })
+	return nil
+}
+
+func (r *fakeRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revokeWhere(func(token domain.RefreshToken) bool { return token.UserID == userID })
+	return nil
+}
+
+func (r *fakeRefreshTokenRepository) revokeWhere(match func(domain.RefreshToken) bool) {
+	now := time.Now()
+	for id, token := range r.tokens {
+		if match(token) && token.RevokedAt == nil {
+			token.RevokedAt = &now
+			r.tokens[id] = token
+		}
+	}
+}
+
+// authTestSetup is an auth service over fake repositories with a signed up user
+type authTestSetup struct {
+	users       *fakeUserRepository
+	refresh     *fakeRefreshTokenRepository
+	revocations TokenRevocationStore
+	svc         AuthService
+	user        domain.User
+}
+
+func newAuthTestSetup() *authTestSetup {
+	s := &authTestSetup{
+		users:   &fakeUserRepository{users: make(map[uuid.UUID]domain.User)},
+		refresh: newFakeRefreshTokenRepository(),
+		user:    domain.User{ID: uuid.New(), Email: "ada@example.com", Name: "Ada", Role: domain.RoleUser},
+	}
+	s.users.users[s.user.ID] = s.user
+	s.revocations = NewTokenRevocationStore(newFakeRevokedTokenRepository(), s.users, 10)
+	s.svc = NewAuthService(s.users, s.refresh, s.revocations, plainHasher{}, "test-secret", time.Minute, time.Hour)
+	return s
+}
+
+// tokenFor returns the stored record of a plain refresh token
+func (s *authTestSetup) tokenFor(t *testing.T, plain string) domain.RefreshToken {
+	t.Helper()
+	token, err := s.refresh.GetByHash(context.Background(), hashRefreshToken(plain))
+	if err != nil {
+		t.Fatalf("refresh token not stored: %v", err)
+	}
+	return *token
+}
+
+func TestAuthServiceRefresh(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rotation", func(t *testing.T) {
+		s := newAuthTestSetup()
+		login, err := s.svc.IssueTokens(ctx, &s.user)
+		if err != nil {
+			t.Fatalf("IssueTokens: %v", err)
+		}
+
+		refreshed, err := s.svc.Refresh(ctx, login.RefreshToken)
+		if err != nil {
+			t.Fatalf("Refresh: %v", err)
+		}
+		if refreshed.RefreshToken == login.RefreshToken || refreshed.Token == "" || refreshed.User.ID != s.user.ID {
+			t.Fatalf("got %+v, want a new token pair for the user", refreshed)
+		}
+
+		old, next := s.tokenFor(t, login.RefreshToken), s.tokenFor(t, refreshed.RefreshToken)
+		if next.FamilyID != old.FamilyID {
+			t.Fatalf("got family %s, want the old token's family %s", next.FamilyID, old.FamilyID)
+		}
+		if old.RevokedAt == nil || old.ReplacedBy == nil || *old.ReplacedBy != next.ID {
+			t.Fatalf("got old token %+v, want it replaced by %s", old, next.ID)
+		}
+		if next.RevokedAt != nil {
+			t.Fatal("the new refresh token is revoked")
+		}
+	})
+
+	t.Run("reuse revokes the family", func(t *testing.T) {
+		s := newAuthTestSetup()
+		login, _ := s.svc.IssueTokens(ctx, &s.user)
+		refreshed, err := s.svc.Refresh(ctx, login.RefreshToken)
+		if err != nil {
+			t.Fatalf("Refresh: %v", err)
+		}
+
+		if _, err := s.svc.Refresh(ctx, login.RefreshToken); !errors.Is(err, domain.ErrRefreshTokenReused) {
+			t.Fatalf("reusing the token: got error %v, want %v", err, domain.ErrRefreshTokenReused)
+		}
+		if family := s.tokenFor(t, login.RefreshToken).FamilyID; s.refresh.revokedFamilies[family] != 1 {
+			t.Fatalf("got %d family revocations, want 1", s.refresh.revokedFamilies[family])
+		}
+		// The token handed out by the legitimate rotation dies with its family
+		if _, err := s.svc.Refresh(ctx, refreshed.RefreshToken); !errors.Is(err, domain.ErrRefreshTokenReused) {
+			t.Fatalf("using the rotated token: got error %v, want %v", err, domain.ErrRefreshTokenReused)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		s := newAuthTestSetup()
+		login, _ := s.svc.IssueTokens(ctx, &s.user)
+		token := s.tokenFor(t, login.RefreshToken)
+		token.ExpiresAt = time.Now().Add(-time.Second)
+		s.refresh.tokens[token.ID] = token
+
+		if _, err := s.svc.Refresh(ctx, login.RefreshToken); !errors.Is(err, domain.ErrInvalidRefreshToken) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrInvalidRefreshToken)
+		}
+		if s.tokenFor(t, login.RefreshToken).RevokedAt != nil || len(s.refresh.tokens) != 1 {
+			t.Fatal("an expired token was rotated")
+		}
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		s := newAuthTestSetup()
+		if _, err := s.svc.Refresh(ctx, "not-a-token"); !errors.Is(err, domain.ErrInvalidRefreshToken) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrInvalidRefreshToken)
+		}
+	})
+
+	t.Run("concurrent rotation", func(t *testing.T) {
+		s := newAuthTestSetup()
+		login, _ := s.svc.IssueTokens(ctx, &s.user)
+
+		// Both requests see the token unrevoked, so the race is decided by Rotate
+		const requests = 2
+		s.refresh.lookups = &sync.WaitGroup{}
+		s.refresh.lookups.Add(requests)
+
+		errs := make([]error, requests)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, errs[i] = s.svc.Refresh(ctx, login.RefreshToken)
+			}(i)
+		}
+		wg.Wait()
+		s.refresh.lookups = nil
+
+		succeeded := 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				succeeded++
+			case !errors.Is(err, domain.ErrRefreshTokenReused):
+				t.Fatalf("got error %v, want %v", err, domain.ErrRefreshTokenReused)
+			}
+		}
+		if succeeded != 1 {
+			t.Fatalf("%d of %d concurrent refreshes succeeded, want 1", succeeded, requests)
+		}
+		if family := s.tokenFor(t, login.RefreshToken).FamilyID; s.refresh.revokedFamilies[family] != 1 {
+			t.Fatalf("got %d family revocations, want the losing request to revoke the family", s.refresh.revokedFamilies[family])
+		}
+	})
+}
+
+func TestAuthServiceLogout(t *testing.T) {
+	ctx := context.Background()
+	s := newAuthTestSetup()
+
+	login, _ := s.svc.IssueTokens(ctx, &s.user)
+	other, _ := s.svc.IssueTokens(ctx, &s.user)
+	claims, err := s.svc.ValidateToken(ctx, login.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+
+	if err := s.svc.Logout(ctx, claims, login.RefreshToken); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if revoked, err := s.revocations.IsRevoked(ctx, claims.ID); err != nil || !revoked {
+		t.Fatalf("IsRevoked after logout: got %v, %v", revoked, err)
+	}
+	if _, err := s.svc.Refresh(ctx, login.RefreshToken); !errors.Is(err, domain.ErrRefreshTokenReused) {
+		t.Fatalf("refreshing after logout: got error %v, want %v", err, domain.ErrRefreshTokenReused)
+	}
+
+	// Other sessions of the user are not affected
+	otherClaims, err := s.svc.ValidateToken(ctx, other.Token)
+	if err != nil {
+		t.Fatalf("ValidateToken of another session: %v", err)
+	}
+	if revoked, _ := s.revocations.IsRevoked(ctx, otherClaims.ID); revoked {
+		t.Fatal("another session's access token was revoked")
+	}
+	if _, err := s.svc.Refresh(ctx, other.RefreshToken); err != nil {
+		t.Fatalf("refreshing another session: %v", err)
+	}
+}
+
+func TestAuthServiceTokenVersion(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("log out all sessions", func(t *testing.T) {
+		s := newAuthTestSetup()
+		first, _ := s.svc.IssueTokens(ctx, &s.user)
+		second, _ := s.svc.IssueTokens(ctx, &s.user)
+		for _, login := range []*domain.LoginResponse{first, second} {
+			// Caches the user's token version
+			if _, err := s.svc.ValidateToken(ctx, login.Token); err != nil {
+				t.Fatalf("ValidateToken: %v", err)
+			}
+		}
+
+		if err := s.svc.LogoutAll(ctx, s.user.ID); err != nil {
+			t.Fatalf("LogoutAll: %v", err)
+		}
+		for _, login := range []*domain.LoginResponse{first, second} {
+			if _, err := s.svc.ValidateToken(ctx, login.Token); err == nil {
+				t.Fatal("an access token issued before LogoutAll is still valid")
+			}
+			if _, err := s.svc.Refresh(ctx, login.RefreshToken); err == nil {
+				t.Fatal("a refresh token issued before LogoutAll still works")
+			}
+		}
+
+		// Signing in again starts a valid session
+		user := s.users.users[s.user.ID]
+		login, _ := s.svc.IssueTokens(ctx, &user)
+		if _, err := s.svc.ValidateToken(ctx, login.Token); err != nil {
+			t.Fatalf("ValidateToken of a new session: %v", err)
+		}
+	})
+
+	t.Run("role change", func(t *testing.T) {
+		s := newAuthTestSetup()
+		users := NewUserService(s.users, plainHasher{}, s.revocations)
+		login, _ := s.svc.IssueTokens(ctx, &s.user)
+		if _, err := s.svc.ValidateToken(ctx, login.Token); err != nil {
+			t.Fatalf("ValidateToken: %v", err)
+		}
+
+		if _, err := users.SetRole(ctx, s.user.ID, domain.RoleAdmin); err != nil {
+			t.Fatalf("SetRole: %v", err)
+		}
+		if _, err := s.svc.ValidateToken(ctx, login.Token); err == nil {
+			t.Fatal("an access token with the old role is still valid")
+		}
+	})
+
+	t.Run("deleted user", func(t *testing.T) {
+		s := newAuthTestSetup()
+		users := NewUserService(s.users, plainHasher{}, s.revocations)
+		login, _ := s.svc.IssueTokens(ctx, &s.user)
+		if _, err := s.svc.ValidateToken(ctx, login.Token); err != nil {
+			t.Fatalf("ValidateToken: %v", err)
+		}
+
+		if err := users.Delete(ctx, s.user.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := s.svc.ValidateToken(ctx, login.Token); err == nil {
+			t.Fatal("an access token of a deleted user is still valid")
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type commentService struct {
+	commentRepo repository.CommentRepository
+	todoRepo    repository.TodoRepository
+}
+
+func NewCommentService(commentRepo repository.CommentRepository, todoRepo repository.TodoRepository) CommentService {
+	return &commentService{commentRepo: commentRepo, todoRepo: todoRepo}
+}
+
+// Create adds a comment to a todo. Everyone who can see the todo may
+// comment on it, including project viewers.
+func (s *commentService) Create(ctx context.Context, actor domain.Actor, req domain.CreateCommentRequest) (*domain.Comment, error) {
+	if err := validateCommentBody(req.Body); err != nil {
+		return nil, err
+	}
+
+	if _, err := getTodo(ctx, s.todoRepo, actor, req.TodoID); err != nil {
+		return nil, err
+	}
+
+	req.UserID = actor.UserID
+	return s.commentRepo.Create(ctx, req)
+}
+
+func (s *commentService) GetByTodoID(ctx context.Context, actor domain.Actor, todoID uuid.UUID, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error) {
+	if _, err := getTodo(ctx, s.todoRepo, actor, todoID); err != nil {
+		return nil, err
+	}
+
+	comments, total, err := s.commentRepo.GetByTodoID(ctx, todoID, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewPaginatedResponse(comments, total, pagination), nil
+}
+
+// Update changes the body of a comment; only its author may edit it.
+func (s *commentService) Update(ctx context.Context, actor domain.Actor, id uuid.UUID, req domain.UpdateCommentRequest) (*domain.Comment, error) {
+	if err := validateCommentBody(req.Body); err != nil {
+		return nil, err
+	}
+
+	comment, err := s.getComment(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != actor.UserID {
+		return nil, domain.ErrCommentNotAuthor
+	}
+
+	return s.commentRepo.Update(ctx, id, req.Body)
+}
+
+// Delete removes a comment on behalf of its author or an admin.
+func (s *commentService) Delete(ctx context.Context, actor domain.Actor, id uuid.UUID) error {
+	comment, err := s.getComment(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	if !actor.CanAccess(comment.UserID) {
+		return domain.ErrCommentNotAuthor
+	}
+
+	return s.commentRepo.Delete(ctx, id)
+}
+
+// getComment returns the comment if the actor can still see its todo.
+// Comments on other todos are reported as not found.
+func (s *commentService) getComment(ctx context.Context, actor domain.Actor, id uuid.UUID) (*domain.Comment, error) {
+	comment, err := s.commentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := getTodo(ctx, s.todoRepo, actor, comment.TodoID); err != nil {
+		if errors.Is(err, domain.ErrTodoNotFound) {
+			return nil, domain.ErrCommentNotFound
+		}
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// validateCommentBody rejects blank bodies. Bodies are otherwise stored as
+// written, since whitespace can be significant in Markdown; the binding
+// limits their length.
+func validateCommentBody(body string) error {
+	if strings.TrimSpace(body) == "" {
+		return domain.NewValidationError("body cannot be blank", domain.FieldError{Field: "body", Rule: "required"})
+	}
+	return nil
+}
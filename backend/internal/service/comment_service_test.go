@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakeCommentRepository is an in-memory repository.CommentRepository
+type fakeCommentRepository struct {
+	comments map[uuid.UUID]domain.Comment
+}
+
+func (r *fakeCommentRepository) Create(ctx context.Context, req domain.CreateCommentRequest) (*domain.Comment, error) {
+	comment := domain.Comment{ID: uuid.New(), TodoID: req.TodoID, UserID: req.UserID, Body: req.Body, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	r.comments[comment.ID] = comment
+	return &comment, nil
+}
+
+func (r *fakeCommentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Comment, error) {
+	comment, ok := r.comments[id]
+	if !ok {
+		return nil, domain.ErrCommentNotFound
+	}
+	return &comment, nil
+}
+
+func (r *fakeCommentRepository) GetByTodoID(ctx context.Context, todoID uuid.UUID, pagination domain.PaginationQuery) ([]domain.Comment, int64, error) {
+	comments := []domain.Comment{}
+	for _, comment := range r.comments {
+		if comment.TodoID == todoID {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, int64(len(comments)), nil
+}
+
+func (r *fakeCommentRepository) Update(ctx context.Context, id uuid.UUID, body string) (*domain.Comment, error) {
+	comment, ok := r.comments[id]
+	if !ok {
+		return nil, domain.ErrCommentNotFound
+	}
+	now := time.Now()
+	comment.Body, comment.EditedAt = body, &now
+	r.comments[id] = comment
+	return &comment, nil
+}
+
+func (r *fakeCommentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, ok := r.comments[id]; !ok {
+		return domain.ErrCommentNotFound
+	}
+	delete(r.comments, id)
+	return nil
+}
+
+func TestCommentService(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	viewer := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	stranger := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	admin := domain.Actor{UserID: uuid.New(), Role: domain.RoleAdmin}
+	projectID := uuid.New()
+	page := domain.PaginationQuery{Page: 1, PageSize: 10}
+
+	newService := func() (CommentService, domain.Todo) {
+		todo := domain.Todo{ID: uuid.New(), Title: "Plan sprint", UserID: owner.UserID, ProjectID: &projectID, Version: 1}
+		todoRepo := newFakeTodoRepository(todo)
+		todoRepo.projects[projectID] = fakeProject{owner: owner.UserID, members: map[uuid.UUID]domain.ProjectRole{
+			owner.UserID:  domain.ProjectRoleOwner,
+			viewer.UserID: domain.ProjectRoleViewer,
+		}}
+		return NewCommentService(&fakeCommentRepository{comments: make(map[uuid.UUID]domain.Comment)}, todoRepo), todo
+	}
+
+	t.Run("anyone who can see the todo comments", func(t *testing.T) {
+		svc, todo := newService()
+		comment, err := svc.Create(context.Background(), viewer, domain.CreateCommentRequest{TodoID: todo.ID, Body: "Looks **good**"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if comment.UserID != viewer.UserID {
+			t.Fatalf("got author %s, want %s", comment.UserID, viewer.UserID)
+		}
+
+		_, err = svc.Create(context.Background(), stranger, domain.CreateCommentRequest{TodoID: todo.ID, Body: "Hi"})
+		if !errors.Is(err, domain.ErrTodoNotFound) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrTodoNotFound)
+		}
+		if _, err := svc.GetByTodoID(context.Background(), stranger, todo.ID, page); !errors.Is(err, domain.ErrTodoNotFound) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrTodoNotFound)
+		}
+	})
+
+	t.Run("blank comments are rejected", func(t *testing.T) {
+		svc, todo := newService()
+		_, err := svc.Create(context.Background(), owner, domain.CreateCommentRequest{TodoID: todo.ID, Body: " \n "})
+		if !errors.Is(err, domain.ErrValidation) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrValidation)
+		}
+	})
+
+	t.Run("only the author edits", func(t *testing.T) {
+		svc, todo := newService()
+		comment, err := svc.Create(context.Background(), viewer, domain.CreateCommentRequest{TodoID: todo.ID, Body: "Draft"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		for _, actor := range []domain.Actor{owner, admin} {
+			_, err := svc.Update(context.Background(), actor, comment.ID, domain.UpdateCommentRequest{Body: "Changed"})
+			if !errors.Is(err, domain.ErrCommentNotAuthor) {
+				t.Fatalf("got error %v, want %v", err, domain.ErrCommentNotAuthor)
+			}
+		}
+		if _, err := svc.Update(context.Background(), stranger, comment.ID, domain.UpdateCommentRequest{Body: "Changed"}); !errors.Is(err, domain.ErrCommentNotFound) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrCommentNotFound)
+		}
+
+		updated, err := svc.Update(context.Background(), viewer, comment.ID, domain.UpdateCommentRequest{Body: "Final"})
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if updated.Body != "Final" || updated.EditedAt == nil {
+			t.Fatalf("got body %q edited at %v, want an edited comment", updated.Body, updated.EditedAt)
+		}
+	})
+
+	t.Run("the author or an admin deletes", func(t *testing.T) {
+		svc, todo := newService()
+		first, _ := svc.Create(context.Background(), viewer, domain.CreateCommentRequest{TodoID: todo.ID, Body: "First"})
+		second, _ := svc.Create(context.Background(), viewer, domain.CreateCommentRequest{TodoID: todo.ID, Body: "Second"})
+
+		if err := svc.Delete(context.Background(), owner, first.ID); !errors.Is(err, domain.ErrCommentNotAuthor) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrCommentNotAuthor)
+		}
+		if err := svc.Delete(context.Background(), viewer, first.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if err := svc.Delete(context.Background(), admin, second.ID); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+
+		resp, err := svc.GetByTodoID(context.Background(), owner, todo.ID, page)
+		if err != nil {
+			t.Fatalf("GetByTodoID: %v", err)
+		}
+		if resp.Pagination.Total != 0 {
+			t.Fatalf("got %d comments, want none", resp.Pagination.Total)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/rs/zerolog"
+)
+
+// InvitationNotifier delivers a project invitation to the invited email
+type InvitationNotifier interface {
+	Invite(ctx context.Context, invitation domain.ProjectInvitation, inviter domain.User) error
+}
+
+// LogInvitationNotifier emits invitations as structured log events, until an
+// email channel is plugged in. Invitees also find them under GET /invitations.
+type LogInvitationNotifier struct {
+	Log zerolog.Logger
+}
+
+func (n LogInvitationNotifier) Invite(ctx context.Context, invitation domain.ProjectInvitation, inviter domain.User) error {
+	n.Log.Info().
+		Str("event", "project.invitation").
+		Str("invitation_id", invitation.ID.String()).
+		Str("project_id", invitation.ProjectID.String()).
+		Str("project_name", invitation.ProjectName).
+		Str("email", invitation.Email).
+		Str("role", string(invitation.Role)).
+		Str("invited_by", inviter.Email).
+		Time("expires_at", invitation.ExpiresAt).
+		Msg("User invited to project")
+	return nil
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"template-fullstack/backend/internal/config"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownHashFormat is returned when a stored hash was not produced by any
+// of the supported algorithms.
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// PasswordHasher hashes and verifies user passwords.
+type PasswordHasher interface {
+	// Hash returns an encoded hash of password, including algorithm and parameters.
+	Hash(password string) (string, error)
+	// Verify reports whether password matches the encoded hash.
+	Verify(hash, password string) (bool, error)
+	// NeedsRehash reports whether hash was produced with an outdated algorithm or cost.
+	NeedsRehash(hash string) bool
+}
+
+// NewPasswordHasher returns a hasher that produces hashes with the configured
+// algorithm and can still verify hashes produced by any supported algorithm.
+func NewPasswordHasher(cfg config.PasswordConfig) PasswordHasher {
+	bcryptHasher := NewBcryptHasher(cfg.BcryptCost)
+	argon2Hasher := NewArgon2idHasher(Argon2Params{
+		Time:    uint32(cfg.Argon2Time),
+		Memory:  uint32(cfg.Argon2Memory),
+		Threads: uint8(cfg.Argon2Threads),
+	})
+
+	preferred := PasswordHasher(bcryptHasher)
+	if cfg.Algorithm == "argon2id" {
+		preferred = argon2Hasher
+	}
+
+	return &multiHasher{
+		preferred: preferred,
+		bcrypt:    bcryptHasher,
+		argon2id:  argon2Hasher,
+	}
+}
+
+type multiHasher struct {
+	preferred PasswordHasher
+	bcrypt    PasswordHasher
+	argon2id  PasswordHasher
+}
+
+func (h *multiHasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+func (h *multiHasher) Verify(hash, password string) (bool, error) {
+	hasher := h.hasherFor(hash)
+	if hasher == nil {
+		return false, ErrUnknownHashFormat
+	}
+	return hasher.Verify(hash, password)
+}
+
+func (h *multiHasher) NeedsRehash(hash string) bool {
+	if h.hasherFor(hash) != h.preferred {
+		return true
+	}
+	return h.preferred.NeedsRehash(hash)
+}
+
+func (h *multiHasher) hasherFor(hash string) PasswordHasher {
+	switch {
+	case isBcryptHash(hash):
+		return h.bcrypt
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return h.argon2id
+	default:
+		return nil
+	}
+}
+
+func isBcryptHash(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher returns a PasswordHasher using bcrypt with the given cost.
+func NewBcryptHasher(cost int) PasswordHasher {
+	return &bcryptHasher{cost: cost}
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+func (h *bcryptHasher) Verify(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to verify password: %w", err)
+	}
+	return true, nil
+}
+
+func (h *bcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	return cost != h.cost
+}
+
+// Argon2Params are the tunable argon2id parameters. Memory is in KiB.
+type Argon2Params struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+	// argon2MaxMemory bounds the memory a stored hash may ask for, 1 GiB
+	argon2MaxMemory = 1 << 20
+)
+
+type argon2idHasher struct {
+	params Argon2Params
+}
+
+// NewArgon2idHasher returns a PasswordHasher using argon2id with the given parameters.
+func NewArgon2idHasher(params Argon2Params) PasswordHasher {
+	return &argon2idHasher{params: params}
+}
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Time, h.params.Memory, h.params.Threads, argon2KeyLength)
+
+	// PHC string format, compatible with the reference implementation
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.params.Memory, h.params.Time, h.params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *argon2idHasher) Verify(hash, password string) (bool, error) {
+	params, salt, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (h *argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+	return params != h.params || len(key) != argon2KeyLength
+}
+
+func decodeArgon2idHash(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+	// argon2.IDKey panics on zero time or threads, so a corrupted row must not reach it
+	if params.Time == 0 || params.Threads == 0 || params.Memory == 0 || params.Memory > argon2MaxMemory {
+		return params, nil, nil, fmt.Errorf("invalid argon2 parameters %q", parts[3])
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownHashFormat
+	}
+
+	return params, salt, key, nil
+}
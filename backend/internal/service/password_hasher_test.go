@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"template-fullstack/backend/internal/config"
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Cheap parameters keep the tests fast
+var testArgon2Params = Argon2Params{Time: 1, Memory: 64, Threads: 1}
+
+func TestPasswordHasherVerify(t *testing.T) {
+	hashers := map[string]PasswordHasher{
+		"bcrypt":   NewBcryptHasher(bcrypt.MinCost),
+		"argon2id": NewArgon2idHasher(testArgon2Params),
+	}
+
+	for name, hasher := range hashers {
+		t.Run(name, func(t *testing.T) {
+			hash, err := hasher.Hash("correct horse")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			if ok, err := hasher.Verify(hash, "correct horse"); err != nil || !ok {
+				t.Fatalf("Verify of the right password = %v, %v", ok, err)
+			}
+			if ok, err := hasher.Verify(hash, "wrong horse"); err != nil || ok {
+				t.Fatalf("Verify of a wrong password = %v, %v", ok, err)
+			}
+			if hasher.NeedsRehash(hash) {
+				t.Fatal("a fresh hash needs a rehash")
+			}
+
+			other, _ := hasher.Hash("correct horse")
+			if other == hash {
+				t.Fatal("two hashes of the same password are equal; the salt is missing")
+			}
+		})
+	}
+}
+
+func TestPasswordHasherNeedsRehash(t *testing.T) {
+	oldBcrypt, _ := NewBcryptHasher(bcrypt.MinCost).Hash("secret")
+	oldArgon2, _ := NewArgon2idHasher(Argon2Params{Time: 1, Memory: 32, Threads: 1}).Hash("secret")
+
+	if !NewBcryptHasher(bcrypt.MinCost + 1).NeedsRehash(oldBcrypt) {
+		t.Error("bcrypt hash with an older cost does not need a rehash")
+	}
+	if !NewArgon2idHasher(testArgon2Params).NeedsRehash(oldArgon2) {
+		t.Error("argon2id hash with older parameters does not need a rehash")
+	}
+	if !NewArgon2idHasher(testArgon2Params).NeedsRehash("not a hash") {
+		t.Error("malformed hash does not need a rehash")
+	}
+}
+
+func TestMultiHasher(t *testing.T) {
+	hasher := NewPasswordHasher(config.PasswordConfig{
+		Algorithm:     "argon2id",
+		BcryptCost:    bcrypt.MinCost,
+		Argon2Time:    int(testArgon2Params.Time),
+		Argon2Memory:  int(testArgon2Params.Memory),
+		Argon2Threads: int(testArgon2Params.Threads),
+	})
+
+	hash, err := hasher.Hash("secret")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		t.Fatalf("Hash = %q, want an argon2id hash", hash)
+	}
+
+	// Hashes from before the switch to argon2id keep working until the
+	// next login upgrades them
+	legacy, _ := NewBcryptHasher(bcrypt.MinCost).Hash("secret")
+	if ok, err := hasher.Verify(legacy, "secret"); err != nil || !ok {
+		t.Fatalf("Verify of a bcrypt hash = %v, %v", ok, err)
+	}
+	if !hasher.NeedsRehash(legacy) {
+		t.Fatal("bcrypt hash does not need a rehash when argon2id is preferred")
+	}
+	if hasher.NeedsRehash(hash) {
+		t.Fatal("preferred hash needs a rehash")
+	}
+
+	if _, err := hasher.Verify("plaintext", "plaintext"); !errors.Is(err, ErrUnknownHashFormat) {
+		t.Fatalf("got error %v, want %v", err, ErrUnknownHashFormat)
+	}
+}
+
+func TestDecodeArgon2idHash(t *testing.T) {
+	valid, _ := NewArgon2idHasher(testArgon2Params).Hash("secret")
+	params, salt, key, err := decodeArgon2idHash(valid)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if params != testArgon2Params || len(salt) != argon2SaltLength || len(key) != argon2KeyLength {
+		t.Fatalf("got %+v with %d byte salt and %d byte key", params, len(salt), len(key))
+	}
+
+	parts := strings.Split(valid, "$")
+	malformed := []string{
+		"",
+		"$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA",
+		"$argon2id$version$m=64,t=1,p=1$" + parts[4] + "$" + parts[5],
+		"$argon2id$v=19$m=64$" + parts[4] + "$" + parts[5],
+		"$argon2id$v=19$m=64,t=1,p=1$!!!$" + parts[5],
+		"$argon2id$v=19$m=64,t=1,p=1$" + parts[4] + "$",
+	}
+	for _, hash := range malformed {
+		if _, _, _, err := decodeArgon2idHash(hash); err == nil {
+			t.Errorf("decoded malformed hash %q", hash)
+		}
+	}
+
+	if _, _, _, err := decodeArgon2idHash(strings.Replace(valid, "v=19", "v=16", 1)); err == nil {
+		t.Error("decoded a hash of an unsupported version")
+	}
+
+	// Verifying must fail instead of panicking in argon2.IDKey
+	hasher := NewArgon2idHasher(testArgon2Params)
+	for _, invalid := range []string{"m=64,t=0,p=1", "m=64,t=1,p=0", "m=0,t=1,p=1", "m=4294967295,t=1,p=1"} {
+		hash := strings.Replace(valid, parts[3], invalid, 1)
+		if _, _, _, err := decodeArgon2idHash(hash); err == nil {
+			t.Errorf("decoded hash with parameters %q", invalid)
+		}
+		if ok, err := hasher.Verify(hash, "secret"); ok || err == nil {
+			t.Errorf("verified hash with parameters %q: got %v, %v", invalid, ok, err)
+		}
+	}
+}
+
+// countingHasher counts the passwords it verifies
+type countingHasher struct {
+	plainHasher
+	verified int
+}
+
+func (h *countingHasher) Verify(hash, password string) (bool, error) {
+	h.verified++
+	return h.plainHasher.Verify(hash, password)
+}
+
+func TestAuthServiceLoginUnknownEmail(t *testing.T) {
+	hasher := &countingHasher{}
+	users := &fakeUserRepository{users: map[uuid.UUID]domain.User{}}
+	svc := NewAuthService(users, nil, nil, hasher, "test-secret", time.Minute, time.Hour)
+
+	_, err := svc.Login(context.Background(), domain.LoginRequest{Email: "nobody@example.com", Password: "secret"})
+	if !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("got error %v, want %v", err, domain.ErrInvalidCredentials)
+	}
+	// An unknown email costs a password verification like a wrong password
+	if hasher.verified != 1 {
+		t.Fatalf("verified %d passwords, want 1", hasher.verified)
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func (s *projectService) GetMembers(ctx context.Context, actor domain.Actor, id uuid.UUID) ([]domain.ProjectMember, error) {
+	if _, err := s.GetByID(ctx, actor, id); err != nil {
+		return nil, err
+	}
+
+	return s.memberRepo.GetMembers(ctx, id)
+}
+
+// UpdateMember changes a member's role. The project's creator owns its
+// todos and must stay an owner.
+func (s *projectService) UpdateMember(ctx context.Context, actor domain.Actor, id, userID uuid.UUID, req domain.UpdateProjectMemberRequest) (*domain.ProjectMember, error) {
+	project, err := s.manage(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID == project.UserID && req.Role != domain.ProjectRoleOwner {
+		return nil, domain.ErrProjectCreatorRole
+	}
+
+	return s.memberRepo.UpdateRole(ctx, id, userID, req.Role)
+}
+
+// RemoveMember removes a member on behalf of an owner, or lets a member
+// leave. The project's creator cannot be removed.
+func (s *projectService) RemoveMember(ctx context.Context, actor domain.Actor, id, userID uuid.UUID) error {
+	var project *domain.Project
+	var err error
+	if userID == actor.UserID {
+		project, err = s.GetByID(ctx, actor, id)
+	} else {
+		project, err = s.manage(ctx, actor, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if userID == project.UserID {
+		return domain.ErrProjectCreatorRole
+	}
+
+	return s.memberRepo.RemoveMember(ctx, id, userID)
+}
+
+// Invite invites email to join the project with the given role and hands
+// the invitation to the notifier. Inviting an address again renews its
+// invitation.
+func (s *projectService) Invite(ctx context.Context, actor domain.Actor, id uuid.UUID, req domain.CreateProjectInvitationRequest) (*domain.ProjectInvitation, error) {
+	project, err := s.manage(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	email := strings.TrimSpace(req.Email)
+	invitee, err := s.userRepo.GetByEmail(ctx, email)
+	switch {
+	case err == nil:
+		_, err := s.memberRepo.GetRole(ctx, id, invitee.ID)
+		if err == nil {
+			return nil, domain.ErrProjectMemberExists
+		}
+		if !errors.Is(err, domain.ErrProjectMemberNotFound) {
+			return nil, err
+		}
+	case !errors.Is(err, domain.ErrUserNotFound):
+		return nil, err
+	}
+
+	inviter, err := s.userRepo.GetByID(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.memberRepo.CreateInvitation(ctx, domain.ProjectInvitation{
+		ProjectID: project.ID,
+		Email:     email,
+		Role:      req.Role,
+		InvitedBy: &actor.UserID,
+		ExpiresAt: time.Now().Add(s.invitationTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.notifier.Invite(ctx, *invitation, *inviter); err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (s *projectService) GetInvitations(ctx context.Context, actor domain.Actor, id uuid.UUID) ([]domain.ProjectInvitation, error) {
+	if _, err := s.manage(ctx, actor, id); err != nil {
+		return nil, err
+	}
+
+	return s.memberRepo.GetInvitations(ctx, id)
+}
+
+func (s *projectService) RevokeInvitation(ctx context.Context, actor domain.Actor, id, invitationID uuid.UUID) error {
+	if _, err := s.manage(ctx, actor, id); err != nil {
+		return err
+	}
+
+	invitation, err := s.memberRepo.GetInvitationByID(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+	if invitation.ProjectID != id {
+		return domain.ErrInvitationNotFound
+	}
+
+	return s.memberRepo.DeleteInvitation(ctx, invitationID)
+}
+
+// GetMyInvitations lists the pending invitations sent to the actor's email
+func (s *projectService) GetMyInvitations(ctx context.Context, actor domain.Actor) ([]domain.ProjectInvitation, error) {
+	user, err := s.userRepo.GetByID(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.memberRepo.GetInvitationsByEmail(ctx, user.Email)
+}
+
+// AcceptInvitation makes the actor a member of the invitation's project and
+// returns the project
+func (s *projectService) AcceptInvitation(ctx context.Context, actor domain.Actor, invitationID uuid.UUID) (*domain.Project, error) {
+	invitation, err := s.invitationFor(ctx, actor, invitationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.memberRepo.AcceptInvitation(ctx, invitation.ID, actor.UserID); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(ctx, actor, invitation.ProjectID)
+}
+
+func (s *projectService) DeclineInvitation(ctx context.Context, actor domain.Actor, invitationID uuid.UUID) error {
+	invitation, err := s.invitationFor(ctx, actor, invitationID)
+	if err != nil {
+		return err
+	}
+
+	return s.memberRepo.DeleteInvitation(ctx, invitation.ID)
+}
+
+// invitationFor returns the invitation if it is still pending and was sent
+// to the actor's email. Other invitations are reported as not found.
+func (s *projectService) invitationFor(ctx context.Context, actor domain.Actor, invitationID uuid.UUID) (*domain.ProjectInvitation, error) {
+	invitation, err := s.memberRepo.GetInvitationByID(ctx, invitationID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(invitation.Email, user.Email) || !invitation.ExpiresAt.After(time.Now()) {
+		return nil, domain.ErrInvitationNotFound
+	}
+
+	return invitation, nil
+}
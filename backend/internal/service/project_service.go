@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type projectService struct {
+	projectRepo   repository.ProjectRepository
+	memberRepo    repository.ProjectMemberRepository
+	todoRepo      repository.TodoRepository
+	userRepo      repository.UserRepository
+	notifier      InvitationNotifier
+	invitationTTL time.Duration
+}
+
+// NewProjectService returns a ProjectService. Invitations are delivered by
+// notifier and can be accepted for invitationTTL.
+func NewProjectService(projectRepo repository.ProjectRepository, memberRepo repository.ProjectMemberRepository, todoRepo repository.TodoRepository,
+	userRepo repository.UserRepository, notifier InvitationNotifier, invitationTTL time.Duration) ProjectService {
+	return &projectService{
+		projectRepo:   projectRepo,
+		memberRepo:    memberRepo,
+		todoRepo:      todoRepo,
+		userRepo:      userRepo,
+		notifier:      notifier,
+		invitationTTL: invitationTTL,
+	}
+}
+
+func (s *projectService) Create(ctx context.Context, req domain.CreateProjectRequest) (*domain.Project, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validateProjectPatch(domain.UpdateProjectRequest{Name: domain.PatchValue(req.Name)}); err != nil {
+		return nil, err
+	}
+
+	return s.projectRepo.Create(ctx, req)
+}
+
+// GetByID returns the project with the actor's role if they are a member or
+// an admin. Other projects are reported as not found.
+func (s *projectService) GetByID(ctx context.Context, actor domain.Actor, id uuid.UUID) (*domain.Project, error) {
+	project, err := s.projectRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.memberRepo.GetRole(ctx, id, actor.UserID)
+	switch {
+	case err == nil:
+		project.Role = role
+	case !errors.Is(err, domain.ErrProjectMemberNotFound):
+		return nil, err
+	case actor.Role != domain.RoleAdmin:
+		return nil, domain.ErrProjectNotFound
+	}
+
+	return project, nil
+}
+
+// manage returns the project if the actor may change it and its members:
+// as one of its owners or an admin
+func (s *projectService) manage(ctx context.Context, actor domain.Actor, id uuid.UUID) (*domain.Project, error) {
+	project, err := s.GetByID(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if actor.Role != domain.RoleAdmin && !project.Role.CanManage() {
+		return nil, domain.ErrProjectNotManager
+	}
+
+	return project, nil
+}
+
+func (s *projectService) GetByUserID(ctx context.Context, userID uuid.UUID, query domain.ProjectListQuery) ([]domain.Project, error) {
+	return s.projectRepo.GetByUserID(ctx, userID, query)
+}
+
+func (s *projectService) Update(ctx context.Context, actor domain.Actor, id uuid.UUID, req domain.UpdateProjectRequest) (*domain.Project, error) {
+	if req.Name.Set {
+		req.Name.Value = strings.TrimSpace(req.Name.Value)
+	}
+	if err := validateProjectPatch(req); err != nil {
+		return nil, err
+	}
+
+	project, err := s.manage(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.projectRepo.Update(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	updated.Role = project.Role
+	return updated, nil
+}
+
+func (s *projectService) Delete(ctx context.Context, actor domain.Actor, id uuid.UUID) error {
+	if _, err := s.manage(ctx, actor, id); err != nil {
+		return err
+	}
+
+	return s.projectRepo.Delete(ctx, id)
+}
+
+func (s *projectService) GetTodos(ctx context.Context, actor domain.Actor, id uuid.UUID, filter domain.TodoFilter, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error) {
+	project, err := s.GetByID(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	filter.ProjectID = &project.ID
+
+	// A project only holds todos of its owner, also when it is shared
+	todos, total, err := s.todoRepo.GetByUserID(ctx, project.UserID, filter, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewPaginatedResponse(todos, total, pagination), nil
+}
+
+const maxProjectNameLength = 100
+
+func validateProjectPatch(req domain.UpdateProjectRequest) error {
+	if req.Name.Set {
+		switch {
+		case req.Name.Null || req.Name.Value == "":
+			return domain.NewValidationError("name cannot be cleared", domain.FieldError{Field: "name", Rule: "required"})
+		case utf8.RuneCountInString(req.Name.Value) > maxProjectNameLength:
+			return domain.NewValidationError("name is too long", domain.FieldError{Field: "name", Rule: "max", Param: strconv.Itoa(maxProjectNameLength)})
+		}
+	}
+
+	if req.Position.Set {
+		switch {
+		case req.Position.Null:
+			return domain.NewValidationError("position cannot be cleared", domain.FieldError{Field: "position", Rule: "required"})
+		case req.Position.Value < 0:
+			return domain.NewValidationError("invalid position", domain.FieldError{Field: "position", Rule: "gte", Param: "0"})
+		}
+	}
+
+	return nil
+}
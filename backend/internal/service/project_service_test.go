@@ -0,0 +1,567 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakeProjectRepository is an in-memory repository.ProjectRepository and
+// repository.ProjectMemberRepository, which share the membership tables
+type fakeProjectRepository struct {
+	projects    map[uuid.UUID]domain.Project
+	members     map[uuid.UUID]map[uuid.UUID]domain.ProjectRole
+	invitations map[uuid.UUID]domain.ProjectInvitation
+}
+
+func newFakeProjectRepository() *fakeProjectRepository {
+	return &fakeProjectRepository{
+		projects:    make(map[uuid.UUID]domain.Project),
+		members:     make(map[uuid.UUID]map[uuid.UUID]domain.ProjectRole),
+		invitations: make(map[uuid.UUID]domain.ProjectInvitation),
+	}
+}
+
+func (r *fakeProjectRepository) Create(ctx context.Context, req domain.CreateProjectRequest) (*domain.Project, error) {
+	position := 0
+	for _, project := range r.projects {
+		if project.UserID == req.UserID && project.Position >= position {
+			position = project.Position + 1
+		}
+	}
+	project := domain.Project{
+		ID:          uuid.New(),
+		UserID:      req.UserID,
+		Name:        req.Name,
+		Description: req.Description,
+		Position:    position,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	r.projects[project.ID] = project
+	r.members[project.ID] = map[uuid.UUID]domain.ProjectRole{req.UserID: domain.ProjectRoleOwner}
+	project.Role = domain.ProjectRoleOwner
+	return &project, nil
+}
+
+func (r *fakeProjectRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Project, error) {
+	project, ok := r.projects[id]
+	if !ok {
+		return nil, domain.ErrProjectNotFound
+	}
+	return &project, nil
+}
+
+func (r *fakeProjectRepository) GetByUserID(ctx context.Context, userID uuid.UUID, query domain.ProjectListQuery) ([]domain.Project, error) {
+	projects := []domain.Project{}
+	for id, project := range r.projects {
+		role, ok := r.members[id][userID]
+		if !ok || (project.ArchivedAt != nil) != query.Archived {
+			continue
+		}
+		project.Role = role
+		projects = append(projects, project)
+	}
+	return projects, nil
+}
+
+func (r *fakeProjectRepository) Update(ctx context.Context, id uuid.UUID, req domain.UpdateProjectRequest) (*domain.Project, error) {
+	project, ok := r.projects[id]
+	if !ok {
+		return nil, domain.ErrProjectNotFound
+	}
+	if req.Name.Set {
+		project.Name = req.Name.Value
+	}
+	if req.Description.Set {
+		project.Description = req.Description.Value
+	}
+	if req.Archived.Set {
+		switch {
+		case !req.Archived.Value:
+			project.ArchivedAt = nil
+		case project.ArchivedAt == nil:
+			now := time.Now()
+			project.ArchivedAt = &now
+		}
+	}
+	if req.Position.Set {
+		project.Position = req.Position.Value
+	}
+	project.UpdatedAt = time.Now()
+	r.projects[id] = project
+	return &project, nil
+}
+
+func (r *fakeProjectRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, ok := r.projects[id]; !ok {
+		return domain.ErrProjectNotFound
+	}
+	delete(r.projects, id)
+	delete(r.members, id)
+	return nil
+}
+
+func (r *fakeProjectRepository) GetRole(ctx context.Context, projectID, userID uuid.UUID) (domain.ProjectRole, error) {
+	role, ok := r.members[projectID][userID]
+	if !ok {
+		return "", domain.ErrProjectMemberNotFound
+	}
+	return role, nil
+}
+
+func (r *fakeProjectRepository) GetMembers(ctx context.Context, projectID uuid.UUID) ([]domain.ProjectMember, error) {
+	members := []domain.ProjectMember{}
+	for userID, role := range r.members[projectID] {
+		members = append(members, domain.ProjectMember{ProjectID: projectID, UserID: userID, Role: role})
+	}
+	return members, nil
+}
+
+func (r *fakeProjectRepository) UpdateRole(ctx context.Context, projectID, userID uuid.UUID, role domain.ProjectRole) (*domain.ProjectMember, error) {
+	if _, ok := r.members[projectID][userID]; !ok {
+		return nil, domain.ErrProjectMemberNotFound
+	}
+	r.members[projectID][userID] = role
+	return &domain.ProjectMember{ProjectID: projectID, UserID: userID, Role: role}, nil
+}
+
+func (r *fakeProjectRepository) RemoveMember(ctx context.Context, projectID, userID uuid.UUID) error {
+	if _, ok := r.members[projectID][userID]; !ok {
+		return domain.ErrProjectMemberNotFound
+	}
+	delete(r.members[projectID], userID)
+	return nil
+}
+
+func (r *fakeProjectRepository) CreateInvitation(ctx context.Context, invitation domain.ProjectInvitation) (*domain.ProjectInvitation, error) {
+	for id, existing := range r.invitations {
+		if existing.ProjectID == invitation.ProjectID && strings.EqualFold(existing.Email, invitation.Email) {
+			delete(r.invitations, id)
+		}
+	}
+	invitation.ID = uuid.New()
+	invitation.ProjectName = r.projects[invitation.ProjectID].Name
+	invitation.CreatedAt = time.Now()
+	r.invitations[invitation.ID] = invitation
+	return &invitation, nil
+}
+
+func (r *fakeProjectRepository) GetInvitationByID(ctx context.Context, id uuid.UUID) (*domain.ProjectInvitation, error) {
+	invitation, ok := r.invitations[id]
+	if !ok {
+		return nil, domain.ErrInvitationNotFound
+	}
+	return &invitation, nil
+}
+
+func (r *fakeProjectRepository) GetInvitations(ctx context.Context, projectID uuid.UUID) ([]domain.ProjectInvitation, error) {
+	return r.listInvitations(func(invitation domain.ProjectInvitation) bool { return invitation.ProjectID == projectID }), nil
+}
+
+func (r *fakeProjectRepository) GetInvitationsByEmail(ctx context.Context, email string) ([]domain.ProjectInvitation, error) {
+	return r.listInvitations(func(invitation domain.ProjectInvitation) bool { return strings.EqualFold(invitation.Email, email) }), nil
+}
+
+func (r *fakeProjectRepository) listInvitations(match func(domain.ProjectInvitation) bool) []domain.ProjectInvitation {
+	invitations := []domain.ProjectInvitation{}
+	for _, invitation := range r.invitations {
+		if match(invitation) && invitation.ExpiresAt.After(time.Now()) {
+			invitations = append(invitations, invitation)
+		}
+	}
+	return invitations
+}
+
+func (r *fakeProjectRepository) AcceptInvitation(ctx context.Context, id, userID uuid.UUID) error {
+	invitation, ok := r.invitations[id]
+	if !ok {
+		return domain.ErrInvitationNotFound
+	}
+	if _, ok := r.members[invitation.ProjectID][userID]; ok {
+		return domain.ErrProjectMemberExists
+	}
+	r.members[invitation.ProjectID][userID] = invitation.Role
+	delete(r.invitations, id)
+	return nil
+}
+
+func (r *fakeProjectRepository) DeleteInvitation(ctx context.Context, id uuid.UUID) error {
+	if _, ok := r.invitations[id]; !ok {
+		return domain.ErrInvitationNotFound
+	}
+	delete(r.invitations, id)
+	return nil
+}
+
+// fakeInvitationNotifier records the invitations it delivers
+type fakeInvitationNotifier struct {
+	invitations []domain.ProjectInvitation
+}
+
+func (n *fakeInvitationNotifier) Invite(ctx context.Context, invitation domain.ProjectInvitation, inviter domain.User) error {
+	n.invitations = append(n.invitations, invitation)
+	return nil
+}
+
+// projectTestSetup creates a project of owner shared with a viewer
+type projectTestSetup struct {
+	projects                       *fakeProjectRepository
+	todos                          *fakeTodoRepository
+	users                          *fakeUserRepository
+	notifier                       *fakeInvitationNotifier
+	svc                            ProjectService
+	project                        *domain.Project
+	owner, viewer, stranger, admin domain.Actor
+}
+
+func newProjectTestSetup(t *testing.T) *projectTestSetup {
+	t.Helper()
+
+	s := &projectTestSetup{
+		projects: newFakeProjectRepository(),
+		todos:    newFakeTodoRepository(),
+		users:    &fakeUserRepository{users: make(map[uuid.UUID]domain.User)},
+		notifier: &fakeInvitationNotifier{},
+		owner:    domain.Actor{UserID: uuid.New(), Role: domain.RoleUser},
+		viewer:   domain.Actor{UserID: uuid.New(), Role: domain.RoleUser},
+		stranger: domain.Actor{UserID: uuid.New(), Role: domain.RoleUser},
+		admin:    domain.Actor{UserID: uuid.New(), Role: domain.RoleAdmin},
+	}
+	s.svc = NewProjectService(s.projects, s.projects, s.todos, s.users, s.notifier, time.Hour)
+
+	project, err := s.svc.Create(context.Background(), domain.CreateProjectRequest{Name: "  Home ", UserID: s.owner.UserID})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	s.project = project
+	s.projects.members[project.ID][s.viewer.UserID] = domain.ProjectRoleViewer
+	return s
+}
+
+func TestValidateProjectPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      domain.UpdateProjectRequest
+		wantRule string
+	}{
+		{name: "empty patch"},
+		{name: "new name", req: domain.UpdateProjectRequest{Name: domain.PatchValue("Work")}},
+		{name: "null name", req: domain.UpdateProjectRequest{Name: domain.PatchNull[string]()}, wantRule: "required"},
+		{name: "blank name", req: domain.UpdateProjectRequest{Name: domain.PatchValue("")}, wantRule: "required"},
+		{name: "longest name", req: domain.UpdateProjectRequest{Name: domain.PatchValue(strings.Repeat("é", maxProjectNameLength))}},
+		{name: "name too long", req: domain.UpdateProjectRequest{Name: domain.PatchValue(strings.Repeat("é", maxProjectNameLength+1))}, wantRule: "max"},
+		{name: "null description", req: domain.UpdateProjectRequest{Description: domain.PatchNull[string]()}},
+		{name: "first position", req: domain.UpdateProjectRequest{Position: domain.PatchValue(0)}},
+		{name: "null position", req: domain.UpdateProjectRequest{Position: domain.PatchNull[int]()}, wantRule: "required"},
+		{name: "negative position", req: domain.UpdateProjectRequest{Position: domain.PatchValue(-1)}, wantRule: "gte"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateProjectPatch(tt.req)
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Fatalf("got error %v, want none", err)
+				}
+				return
+			}
+
+			var domainErr *domain.Error
+			if !errors.As(err, &domainErr) || len(domainErr.Details) != 1 || domainErr.Details[0].Rule != tt.wantRule {
+				t.Fatalf("got error %v, want a %s validation error", err, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestProjectServiceAccess(t *testing.T) {
+	s := newProjectTestSetup(t)
+	ctx := context.Background()
+
+	if s.project.Name != "Home" {
+		t.Fatalf("got name %q, want it trimmed", s.project.Name)
+	}
+	if _, err := s.svc.Create(ctx, domain.CreateProjectRequest{Name: "  ", UserID: s.owner.UserID}); !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("Create with blank name: got error %v, want validation error", err)
+	}
+
+	roles := map[string]struct {
+		actor domain.Actor
+		want  domain.ProjectRole
+	}{
+		"owner":  {actor: s.owner, want: domain.ProjectRoleOwner},
+		"viewer": {actor: s.viewer, want: domain.ProjectRoleViewer},
+		"admin":  {actor: s.admin, want: ""},
+	}
+	for name, tt := range roles {
+		project, err := s.svc.GetByID(ctx, tt.actor, s.project.ID)
+		if err != nil {
+			t.Fatalf("GetByID by %s: %v", name, err)
+		}
+		if project.Role != tt.want {
+			t.Fatalf("GetByID by %s: got role %q, want %q", name, project.Role, tt.want)
+		}
+	}
+
+	if _, err := s.svc.GetByID(ctx, s.stranger, s.project.ID); !errors.Is(err, domain.ErrProjectNotFound) {
+		t.Fatalf("GetByID by other user: got error %v, want %v", err, domain.ErrProjectNotFound)
+	}
+	if _, err := s.svc.GetByID(ctx, s.owner, uuid.New()); !errors.Is(err, domain.ErrProjectNotFound) {
+		t.Fatalf("GetByID of unknown project: got error %v, want %v", err, domain.ErrProjectNotFound)
+	}
+
+	rename := domain.UpdateProjectRequest{Name: domain.PatchValue("Work")}
+	if _, err := s.svc.Update(ctx, s.viewer, s.project.ID, rename); !errors.Is(err, domain.ErrProjectNotManager) {
+		t.Fatalf("Update by viewer: got error %v, want %v", err, domain.ErrProjectNotManager)
+	}
+	if _, err := s.svc.Update(ctx, s.stranger, s.project.ID, rename); !errors.Is(err, domain.ErrProjectNotFound) {
+		t.Fatalf("Update by other user: got error %v, want %v", err, domain.ErrProjectNotFound)
+	}
+	if err := s.svc.Delete(ctx, s.viewer, s.project.ID); !errors.Is(err, domain.ErrProjectNotManager) {
+		t.Fatalf("Delete by viewer: got error %v, want %v", err, domain.ErrProjectNotManager)
+	}
+
+	updated, err := s.svc.Update(ctx, s.owner, s.project.ID, rename)
+	if err != nil {
+		t.Fatalf("Update by owner: %v", err)
+	}
+	if updated.Name != "Work" || updated.Role != domain.ProjectRoleOwner {
+		t.Fatalf("got %q with role %q, want the new name and the owner's role", updated.Name, updated.Role)
+	}
+
+	if err := s.svc.Delete(ctx, s.admin, s.project.ID); err != nil {
+		t.Fatalf("Delete by admin: %v", err)
+	}
+	if _, err := s.svc.GetByID(ctx, s.owner, s.project.ID); !errors.Is(err, domain.ErrProjectNotFound) {
+		t.Fatalf("GetByID after Delete: got error %v, want %v", err, domain.ErrProjectNotFound)
+	}
+}
+
+func TestProjectServiceGetTodos(t *testing.T) {
+	s := newProjectTestSetup(t)
+	ctx := context.Background()
+	other := uuid.New()
+
+	inProject, _ := s.todos.Create(ctx, domain.CreateTodoRequest{Title: "In project", UserID: s.owner.UserID, ProjectID: &s.project.ID})
+	s.todos.Create(ctx, domain.CreateTodoRequest{Title: "In other project", UserID: s.owner.UserID, ProjectID: &other})
+	s.todos.Create(ctx, domain.CreateTodoRequest{Title: "Without project", UserID: s.owner.UserID})
+
+	pagination := domain.PaginationQuery{Page: 1, PageSize: 10}
+	// A viewer sees the project's todos, which belong to its owner
+	resp, err := s.svc.GetTodos(ctx, s.viewer, s.project.ID, domain.TodoFilter{}, pagination)
+	if err != nil {
+		t.Fatalf("GetTodos: %v", err)
+	}
+	todos := resp.Data.([]domain.Todo)
+	if len(todos) != 1 || todos[0].ID != inProject.ID {
+		t.Fatalf("got %d todos, want only the project's todo", len(todos))
+	}
+	if resp.Pagination.Total != 1 || resp.Pagination.TotalPages != 1 {
+		t.Fatalf("got pagination %+v, want one page of one todo", resp.Pagination)
+	}
+
+	if _, err := s.svc.GetTodos(ctx, s.stranger, s.project.ID, domain.TodoFilter{}, pagination); !errors.Is(err, domain.ErrProjectNotFound) {
+		t.Fatalf("GetTodos by other user: got error %v, want %v", err, domain.ErrProjectNotFound)
+	}
+	if _, err := s.svc.GetTodos(ctx, s.owner, s.project.ID, domain.TodoFilter{Sort: "secret"}, pagination); !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("GetTodos with invalid sort: got error %v, want validation error", err)
+	}
+}
+
+func TestProjectServiceArchive(t *testing.T) {
+	s := newProjectTestSetup(t)
+	ctx := context.Background()
+	archive := domain.UpdateProjectRequest{Archived: domain.PatchValue(true)}
+
+	if _, err := s.svc.Update(ctx, s.viewer, s.project.ID, archive); !errors.Is(err, domain.ErrProjectNotManager) {
+		t.Fatalf("archive by viewer: got error %v, want %v", err, domain.ErrProjectNotManager)
+	}
+
+	archived, err := s.svc.Update(ctx, s.owner, s.project.ID, archive)
+	if err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	if archived.ArchivedAt == nil {
+		t.Fatal("archived project has no archived_at")
+	}
+
+	// Archiving again keeps the original time
+	again, err := s.svc.Update(ctx, s.owner, s.project.ID, archive)
+	if err != nil {
+		t.Fatalf("archive again: %v", err)
+	}
+	if !again.ArchivedAt.Equal(*archived.ArchivedAt) {
+		t.Fatalf("archiving again changed archived_at from %v to %v", archived.ArchivedAt, again.ArchivedAt)
+	}
+
+	// Archived projects are only listed on request, also for other members
+	for _, archivedList := range []bool{false, true} {
+		projects, err := s.svc.GetByUserID(ctx, s.viewer.UserID, domain.ProjectListQuery{Archived: archivedList})
+		if err != nil {
+			t.Fatalf("GetByUserID: %v", err)
+		}
+		if listed := len(projects) == 1; listed != archivedList {
+			t.Fatalf("archived=%v: got %d projects", archivedList, len(projects))
+		}
+	}
+
+	unarchived, err := s.svc.Update(ctx, s.owner, s.project.ID, domain.UpdateProjectRequest{Archived: domain.PatchValue(false)})
+	if err != nil {
+		t.Fatalf("unarchive: %v", err)
+	}
+	if unarchived.ArchivedAt != nil {
+		t.Fatalf("unarchived project has archived_at %v", unarchived.ArchivedAt)
+	}
+}
+
+func TestProjectServiceMembers(t *testing.T) {
+	s := newProjectTestSetup(t)
+	ctx := context.Background()
+	editor := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	s.projects.members[s.project.ID][editor.UserID] = domain.ProjectRoleEditor
+
+	if _, err := s.svc.GetMembers(ctx, s.stranger, s.project.ID); !errors.Is(err, domain.ErrProjectNotFound) {
+		t.Fatalf("GetMembers by other user: got error %v, want %v", err, domain.ErrProjectNotFound)
+	}
+	members, err := s.svc.GetMembers(ctx, s.viewer, s.project.ID)
+	if err != nil {
+		t.Fatalf("GetMembers: %v", err)
+	}
+	if len(members) != 3 {
+		t.Fatalf("got %d members, want 3", len(members))
+	}
+
+	// Only owners change roles
+	promote := domain.UpdateProjectMemberRequest{Role: domain.ProjectRoleOwner}
+	if _, err := s.svc.UpdateMember(ctx, editor, s.project.ID, s.viewer.UserID, promote); !errors.Is(err, domain.ErrProjectNotManager) {
+		t.Fatalf("UpdateMember by editor: got error %v, want %v", err, domain.ErrProjectNotManager)
+	}
+	if _, err := s.svc.UpdateMember(ctx, s.owner, s.project.ID, editor.UserID, promote); err != nil {
+		t.Fatalf("UpdateMember: %v", err)
+	}
+
+	// The promoted owner manages the project too, but the creator stays an
+	// owner and a member
+	demote := domain.UpdateProjectMemberRequest{Role: domain.ProjectRoleViewer}
+	if _, err := s.svc.UpdateMember(ctx, editor, s.project.ID, s.owner.UserID, demote); !errors.Is(err, domain.ErrProjectCreatorRole) {
+		t.Fatalf("demoting the creator: got error %v, want %v", err, domain.ErrProjectCreatorRole)
+	}
+	if err := s.svc.RemoveMember(ctx, editor, s.project.ID, s.owner.UserID); !errors.Is(err, domain.ErrProjectCreatorRole) {
+		t.Fatalf("removing the creator: got error %v, want %v", err, domain.ErrProjectCreatorRole)
+	}
+	if err := s.svc.RemoveMember(ctx, s.owner, s.project.ID, s.owner.UserID); !errors.Is(err, domain.ErrProjectCreatorRole) {
+		t.Fatalf("creator leaving: got error %v, want %v", err, domain.ErrProjectCreatorRole)
+	}
+	if role := s.projects.members[s.project.ID][s.owner.UserID]; role != domain.ProjectRoleOwner {
+		t.Fatalf("creator has role %q, want owner", role)
+	}
+
+	// Members leave on their own, but only owners remove others
+	other := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	s.projects.members[s.project.ID][other.UserID] = domain.ProjectRoleViewer
+	if err := s.svc.RemoveMember(ctx, s.viewer, s.project.ID, other.UserID); !errors.Is(err, domain.ErrProjectNotManager) {
+		t.Fatalf("viewer removing another member: got error %v, want %v", err, domain.ErrProjectNotManager)
+	}
+	if err := s.svc.RemoveMember(ctx, s.viewer, s.project.ID, s.viewer.UserID); err != nil {
+		t.Fatalf("viewer leaving: %v", err)
+	}
+	if err := s.svc.RemoveMember(ctx, editor, s.project.ID, other.UserID); err != nil {
+		t.Fatalf("owner removing a member: %v", err)
+	}
+	if _, err := s.svc.GetByID(ctx, s.viewer, s.project.ID); !errors.Is(err, domain.ErrProjectNotFound) {
+		t.Fatalf("GetByID after leaving: got error %v, want %v", err, domain.ErrProjectNotFound)
+	}
+}
+
+func TestProjectServiceInvitations(t *testing.T) {
+	s := newProjectTestSetup(t)
+	ctx := context.Background()
+	invitee := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	for _, user := range []domain.User{
+		{ID: s.owner.UserID, Email: "owner@example.com"},
+		{ID: s.viewer.UserID, Email: "viewer@example.com"},
+		{ID: s.stranger.UserID, Email: "stranger@example.com"},
+		{ID: invitee.UserID, Email: "invitee@example.com"},
+	} {
+		s.users.users[user.ID] = user
+	}
+
+	invite := domain.CreateProjectInvitationRequest{Email: " Invitee@Example.com ", Role: domain.ProjectRoleEditor}
+	if _, err := s.svc.Invite(ctx, s.viewer, s.project.ID, invite); !errors.Is(err, domain.ErrProjectNotManager) {
+		t.Fatalf("Invite by viewer: got error %v, want %v", err, domain.ErrProjectNotManager)
+	}
+	if _, err := s.svc.Invite(ctx, s.owner, s.project.ID, domain.CreateProjectInvitationRequest{Email: "viewer@example.com", Role: domain.ProjectRoleEditor}); !errors.Is(err, domain.ErrProjectMemberExists) {
+		t.Fatalf("inviting a member: got error %v, want %v", err, domain.ErrProjectMemberExists)
+	}
+
+	invitation, err := s.svc.Invite(ctx, s.owner, s.project.ID, invite)
+	if err != nil {
+		t.Fatalf("Invite: %v", err)
+	}
+	if invitation.Email != "Invitee@Example.com" || *invitation.InvitedBy != s.owner.UserID {
+		t.Fatalf("got invitation for %q by %v", invitation.Email, invitation.InvitedBy)
+	}
+	if len(s.notifier.invitations) != 1 || s.notifier.invitations[0].ID != invitation.ID {
+		t.Fatal("the invitation was not delivered")
+	}
+	if _, err := s.svc.GetInvitations(ctx, s.viewer, s.project.ID); !errors.Is(err, domain.ErrProjectNotManager) {
+		t.Fatalf("GetInvitations by viewer: got error %v, want %v", err, domain.ErrProjectNotManager)
+	}
+
+	// The invitation is matched by email, ignoring case
+	mine, err := s.svc.GetMyInvitations(ctx, invitee)
+	if err != nil {
+		t.Fatalf("GetMyInvitations: %v", err)
+	}
+	if len(mine) != 1 || mine[0].ID != invitation.ID {
+		t.Fatalf("invitee got %d invitations, want theirs", len(mine))
+	}
+	if _, err := s.svc.AcceptInvitation(ctx, s.stranger, invitation.ID); !errors.Is(err, domain.ErrInvitationNotFound) {
+		t.Fatalf("accepting someone else's invitation: got error %v, want %v", err, domain.ErrInvitationNotFound)
+	}
+	if err := s.svc.DeclineInvitation(ctx, s.stranger, invitation.ID); !errors.Is(err, domain.ErrInvitationNotFound) {
+		t.Fatalf("declining someone else's invitation: got error %v, want %v", err, domain.ErrInvitationNotFound)
+	}
+
+	project, err := s.svc.AcceptInvitation(ctx, invitee, invitation.ID)
+	if err != nil {
+		t.Fatalf("AcceptInvitation: %v", err)
+	}
+	if project.ID != s.project.ID || project.Role != domain.ProjectRoleEditor {
+		t.Fatalf("got project %s with role %q, want the invitation's project and role", project.ID, project.Role)
+	}
+	if _, ok := s.projects.invitations[invitation.ID]; ok {
+		t.Fatal("accepted invitation is still pending")
+	}
+
+	// Expired invitations can't be accepted
+	expired, _ := s.projects.CreateInvitation(ctx, domain.ProjectInvitation{
+		ProjectID: s.project.ID, Email: "stranger@example.com", Role: domain.ProjectRoleViewer, ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	if _, err := s.svc.AcceptInvitation(ctx, s.stranger, expired.ID); !errors.Is(err, domain.ErrInvitationNotFound) {
+		t.Fatalf("accepting an expired invitation: got error %v, want %v", err, domain.ErrInvitationNotFound)
+	}
+
+	// Invitations are revoked through their own project only
+	pending, err := s.svc.Invite(ctx, s.owner, s.project.ID, domain.CreateProjectInvitationRequest{Email: "stranger@example.com", Role: domain.ProjectRoleViewer})
+	if err != nil {
+		t.Fatalf("Invite: %v", err)
+	}
+	otherProject, _ := s.svc.Create(ctx, domain.CreateProjectRequest{Name: "Other", UserID: s.owner.UserID})
+	if err := s.svc.RevokeInvitation(ctx, s.owner, otherProject.ID, pending.ID); !errors.Is(err, domain.ErrInvitationNotFound) {
+		t.Fatalf("revoking through another project: got error %v, want %v", err, domain.ErrInvitationNotFound)
+	}
+	if err := s.svc.DeclineInvitation(ctx, s.stranger, pending.ID); err != nil {
+		t.Fatalf("DeclineInvitation: %v", err)
+	}
+	if _, err := s.svc.GetByID(ctx, s.stranger, s.project.ID); !errors.Is(err, domain.ErrProjectNotFound) {
+		t.Fatalf("GetByID after declining: got error %v, want %v", err, domain.ErrProjectNotFound)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type tagService struct {
+	tagRepo repository.TagRepository
+}
+
+func NewTagService(tagRepo repository.TagRepository) TagService {
+	return &tagService{tagRepo: tagRepo}
+}
+
+func (s *tagService) Create(ctx context.Context, req domain.CreateTagRequest) (*domain.Tag, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	patch := domain.UpdateTagRequest{Name: domain.PatchValue(req.Name), Color: domain.PatchValue(req.Color)}
+	if err := validateTagPatch(patch); err != nil {
+		return nil, err
+	}
+
+	return s.tagRepo.Create(ctx, req)
+}
+
+// GetByID returns the tag if the actor may see it. As with todos, tags of
+// other users are reported as not found.
+func (s *tagService) GetByID(ctx context.Context, actor domain.Actor, id uuid.UUID) (*domain.Tag, error) {
+	tag, err := s.tagRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.CanAccess(tag.UserID) {
+		return nil, domain.ErrTagNotFound
+	}
+
+	return tag, nil
+}
+
+func (s *tagService) GetByUserID(ctx context.Context, userID uuid.UUID) ([]domain.Tag, error) {
+	return s.tagRepo.GetByUserID(ctx, userID)
+}
+
+func (s *tagService) Update(ctx context.Context, actor domain.Actor, id uuid.UUID, req domain.UpdateTagRequest) (*domain.Tag, error) {
+	if req.Name.Set {
+		req.Name.Value = strings.TrimSpace(req.Name.Value)
+	}
+	if err := validateTagPatch(req); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetByID(ctx, actor, id); err != nil {
+		return nil, err
+	}
+
+	return s.tagRepo.Update(ctx, id, req)
+}
+
+func (s *tagService) Delete(ctx context.Context, actor domain.Actor, id uuid.UUID) error {
+	if _, err := s.GetByID(ctx, actor, id); err != nil {
+		return err
+	}
+
+	return s.tagRepo.Delete(ctx, id)
+}
+
+const maxTagNameLength = 50
+
+// tagColorPattern matches CSS hex colors in short (#f80) or long (#ff8800) form
+var tagColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+func validateTagPatch(req domain.UpdateTagRequest) error {
+	if req.Name.Set {
+		switch {
+		case req.Name.Null || req.Name.Value == "":
+			return domain.NewValidationError("name cannot be cleared", domain.FieldError{Field: "name", Rule: "required"})
+		case utf8.RuneCountInString(req.Name.Value) > maxTagNameLength:
+			return domain.NewValidationError("name is too long", domain.FieldError{Field: "name", Rule: "max", Param: strconv.Itoa(maxTagNameLength)})
+		}
+	}
+
+	if req.Color.Set && req.Color.Value != "" && !tagColorPattern.MatchString(req.Color.Value) {
+		return domain.NewValidationError("invalid color", domain.FieldError{Field: "color", Rule: "hexcolor"})
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakeTagRepository is an in-memory repository.TagRepository
+type fakeTagRepository struct {
+	tags map[uuid.UUID]domain.Tag
+}
+
+func (r *fakeTagRepository) Create(ctx context.Context, req domain.CreateTagRequest) (*domain.Tag, error) {
+	tag := domain.Tag{ID: uuid.New(), UserID: req.UserID, Name: req.Name, Color: req.Color, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	r.tags[tag.ID] = tag
+	return &tag, nil
+}
+
+func (r *fakeTagRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Tag, error) {
+	tag, ok := r.tags[id]
+	if !ok {
+		return nil, domain.ErrTagNotFound
+	}
+	return &tag, nil
+}
+
+func (r *fakeTagRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]domain.Tag, error) {
+	var tags []domain.Tag
+	for _, tag := range r.tags {
+		if tag.UserID == userID {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (r *fakeTagRepository) Update(ctx context.Context, id uuid.UUID, req domain.UpdateTagRequest) (*domain.Tag, error) {
+	tag, ok := r.tags[id]
+	if !ok {
+		return nil, domain.ErrTagNotFound
+	}
+	if req.Name.Set {
+		tag.Name = req.Name.Value
+	}
+	if req.Color.Set {
+		tag.Color = req.Color.Value
+	}
+	r.tags[id] = tag
+	return &tag, nil
+}
+
+func (r *fakeTagRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, ok := r.tags[id]; !ok {
+		return domain.ErrTagNotFound
+	}
+	delete(r.tags, id)
+	return nil
+}
+
+func TestValidateTagPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      domain.UpdateTagRequest
+		wantRule string
+	}{
+		{name: "empty patch"},
+		{name: "new name", req: domain.UpdateTagRequest{Name: domain.PatchValue("work")}},
+		{name: "null name", req: domain.UpdateTagRequest{Name: domain.PatchNull[string]()}, wantRule: "required"},
+		{name: "blank name", req: domain.UpdateTagRequest{Name: domain.PatchValue("")}, wantRule: "required"},
+		{name: "name too long", req: domain.UpdateTagRequest{Name: domain.PatchValue(strings.Repeat("x", maxTagNameLength+1))}, wantRule: "max"},
+		{name: "long color", req: domain.UpdateTagRequest{Color: domain.PatchValue("#FF8800")}},
+		{name: "short color", req: domain.UpdateTagRequest{Color: domain.PatchValue("#f80")}},
+		{name: "null color removes it", req: domain.UpdateTagRequest{Color: domain.PatchNull[string]()}},
+		{name: "color without #", req: domain.UpdateTagRequest{Color: domain.PatchValue("ff8800")}, wantRule: "hexcolor"},
+		{name: "color of wrong length", req: domain.UpdateTagRequest{Color: domain.PatchValue("#ff88")}, wantRule: "hexcolor"},
+		{name: "color name", req: domain.UpdateTagRequest{Color: domain.PatchValue("red")}, wantRule: "hexcolor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTagPatch(tt.req)
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Fatalf("got error %v, want none", err)
+				}
+				return
+			}
+
+			var domainErr *domain.Error
+			if !errors.As(err, &domainErr) || len(domainErr.Details) != 1 || domainErr.Details[0].Rule != tt.wantRule {
+				t.Fatalf("got error %v, want a %s validation error", err, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestTagService(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	stranger := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	admin := domain.Actor{UserID: uuid.New(), Role: domain.RoleAdmin}
+	ctx := context.Background()
+
+	repo := &fakeTagRepository{tags: make(map[uuid.UUID]domain.Tag)}
+	svc := NewTagService(repo)
+
+	// Names are stored trimmed, and blank ones are rejected after trimming
+	tag, err := svc.Create(ctx, domain.CreateTagRequest{Name: "  work ", Color: "#f80", UserID: owner.UserID})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if tag.Name != "work" {
+		t.Fatalf("got name %q, want %q", tag.Name, "work")
+	}
+	if _, err := svc.Create(ctx, domain.CreateTagRequest{Name: "   ", UserID: owner.UserID}); !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("Create with blank name: got error %v, want validation error", err)
+	}
+	if _, err := svc.Create(ctx, domain.CreateTagRequest{Name: "home", Color: "orange", UserID: owner.UserID}); !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("Create with invalid color: got error %v, want validation error", err)
+	}
+
+	rename := domain.UpdateTagRequest{Name: domain.PatchValue("office")}
+	if _, err := svc.GetByID(ctx, stranger, tag.ID); !errors.Is(err, domain.ErrTagNotFound) {
+		t.Fatalf("GetByID by other user: got error %v, want %v", err, domain.ErrTagNotFound)
+	}
+	if _, err := svc.Update(ctx, stranger, tag.ID, rename); !errors.Is(err, domain.ErrTagNotFound) {
+		t.Fatalf("Update by other user: got error %v, want %v", err, domain.ErrTagNotFound)
+	}
+	if err := svc.Delete(ctx, stranger, tag.ID); !errors.Is(err, domain.ErrTagNotFound) {
+		t.Fatalf("Delete by other user: got error %v, want %v", err, domain.ErrTagNotFound)
+	}
+	if repo.tags[tag.ID].Name != "work" {
+		t.Fatal("another user changed the tag")
+	}
+
+	updated, err := svc.Update(ctx, owner, tag.ID, domain.UpdateTagRequest{Name: domain.PatchValue(" office "), Color: domain.PatchNull[string]()})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Name != "office" || updated.Color != "" {
+		t.Fatalf("got %q %q, want the new name without a color", updated.Name, updated.Color)
+	}
+
+	if err := svc.Delete(ctx, admin, tag.ID); err != nil {
+		t.Fatalf("Delete by admin: %v", err)
+	}
+	if _, err := svc.GetByID(ctx, owner, tag.ID); !errors.Is(err, domain.ErrTagNotFound) {
+		t.Fatalf("GetByID after Delete: got error %v, want %v", err, domain.ErrTagNotFound)
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Bulk applies a batch of create/update/delete operations on behalf of actor.
+// Each operation is subject to the same rules as the single-item methods.
+//
+// In atomic mode (the default) the batch runs in one transaction that is
+// rolled back at the first failure; the response is then not committed and
+// every other operation reports domain.ErrBulkAborted. In best-effort mode
+// each operation runs in its own transaction and failures don't affect the
+// rest of the batch.
+func (s *todoService) Bulk(ctx context.Context, actor domain.Actor, req domain.BulkTodoRequest) (*domain.BulkTodoResponse, error) {
+	if len(req.Operations) > s.maxBulkOperations {
+		return nil, domain.NewValidationError("too many operations", domain.FieldError{
+			Field: "operations",
+			Rule:  "lte",
+			Param: strconv.Itoa(s.maxBulkOperations),
+		})
+	}
+
+	if req.Mode == "" {
+		req.Mode = domain.BulkModeAtomic
+	}
+
+	resp := &domain.BulkTodoResponse{
+		Mode:    req.Mode,
+		Results: make([]domain.BulkTodoResult, len(req.Operations)),
+	}
+	for i, op := range req.Operations {
+		resp.Results[i] = domain.BulkTodoResult{Index: i, Op: op.Op}
+	}
+
+	if req.Mode == domain.BulkModeBestEffort {
+		for i, op := range req.Operations {
+			result := &resp.Results[i]
+			err := s.todoRepo.Transaction(ctx, func(repo repository.TodoRepository) error {
+				todo, err := applyBulkOperation(ctx, repo, actor, op)
+				result.Todo = todo
+				return err
+			})
+			result.Success = err == nil
+			result.Err = err
+			if err != nil {
+				result.Todo = nil
+			}
+		}
+
+		resp.Committed = true
+		return resp, nil
+	}
+
+	failed := -1
+	err := s.todoRepo.Transaction(ctx, func(repo repository.TodoRepository) error {
+		for i, op := range req.Operations {
+			todo, err := applyBulkOperation(ctx, repo, actor, op)
+			if err != nil {
+				failed = i
+				return err
+			}
+			resp.Results[i].Todo = todo
+		}
+		return nil
+	})
+
+	if err != nil {
+		if failed < 0 {
+			// The operations succeeded but the commit did not
+			return nil, err
+		}
+
+		resp.FailedIndex = &failed
+		for i := range resp.Results {
+			resp.Results[i].Todo = nil
+			resp.Results[i].Err = domain.ErrBulkAborted
+		}
+		resp.Results[failed].Err = err
+		return resp, nil
+	}
+
+	for i := range resp.Results {
+		resp.Results[i].Success = true
+	}
+	resp.Committed = true
+	return resp, nil
+}
+
+func applyBulkOperation(ctx context.Context, repo repository.TodoRepository, actor domain.Actor, op domain.BulkTodoOperation) (*domain.Todo, error) {
+	switch op.Op {
+	case domain.BulkOpCreate:
+		var req domain.CreateTodoRequest
+		if err := decodeBulkData(op.Data, &req); err != nil {
+			return nil, err
+		}
+		req.UserID = actor.UserID
+		return createTodo(ctx, repo, req)
+
+	case domain.BulkOpUpdate:
+		if op.ID == uuid.Nil {
+			return nil, bulkIDRequired()
+		}
+		var req domain.UpdateTodoRequest
+		if err := decodeBulkData(op.Data, &req); err != nil {
+			return nil, err
+		}
+		return updateTodo(ctx, repo, actor, op.ID, req, op.Version)
+
+	case domain.BulkOpDelete:
+		if op.ID == uuid.Nil {
+			return nil, bulkIDRequired()
+		}
+		return nil, deleteTodo(ctx, repo, actor, op.ID, op.Version)
+
+	default:
+		return nil, domain.NewValidationError("unknown operation", domain.FieldError{
+			Field: "op",
+			Rule:  "oneof",
+			Param: "create update delete",
+		})
+	}
+}
+
+func decodeBulkData(data json.RawMessage, v interface{}) error {
+	if len(data) == 0 {
+		return domain.NewValidationError("data is required", domain.FieldError{Field: "data", Rule: "required"})
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return domain.NewValidationError("data is malformed", domain.FieldError{Field: "data", Rule: "json"})
+	}
+	return nil
+}
+
+func bulkIDRequired() error {
+	return domain.NewValidationError("id is required", domain.FieldError{Field: "id", Rule: "required"})
+}
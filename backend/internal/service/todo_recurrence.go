@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/pkg/rrule"
+
+	"github.com/google/uuid"
+)
+
+// GetOccurrences lists when the upcoming occurrences of a recurring todo will
+// be due: those after both the todo's own due date and now, up to and
+// including query.Until. Rules are evaluated in the todo's time zone.
+func (s *todoService) GetOccurrences(ctx context.Context, actor domain.Actor, id uuid.UUID, query domain.OccurrenceQuery) ([]time.Time, error) {
+	todo, err := getTodo(ctx, s.todoRepo, actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences := []time.Time{}
+	if todo.Recurrence == "" || todo.DueAt == nil {
+		return occurrences, nil
+	}
+
+	rule, err := rrule.Parse(todo.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	from := time.Now()
+	if todo.DueAt.After(from) {
+		from = *todo.DueAt
+	}
+
+	it := rule.Iterator(todo.DueAt.In(location(todo.Timezone)))
+	it.SkipTo(from)
+	for len(occurrences) < query.Limit {
+		next, ok := it.Next()
+		if !ok || next.After(query.Until) {
+			break
+		}
+		if next.After(from) {
+			occurrences = append(occurrences, next)
+		}
+	}
+
+	return occurrences, nil
+}
+
+// occurrence is the next todo of a series
+type occurrence struct {
+	dueAt      time.Time
+	recurrence string
+}
+
+// normalizeRecurrence validates an RRULE and returns it in canonical form
+func normalizeRecurrence(recurrence string) (string, error) {
+	if recurrence == "" {
+		return "", nil
+	}
+
+	rule, err := rrule.Parse(recurrence)
+	if err != nil {
+		return "", domain.NewValidationError("invalid recurrence rule", domain.FieldError{Field: "recurrence", Rule: "rrule"})
+	}
+	return rule.String(), nil
+}
+
+// normalizeTimezone validates an IANA time zone name; empty means UTC
+func normalizeTimezone(timezone string) (string, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return "", domain.NewValidationError("unknown time zone", domain.FieldError{Field: "timezone", Rule: "timezone"})
+	}
+	return loc.String(), nil
+}
+
+// location returns the time zone of a stored todo, which was validated when
+// it was written
+func location(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func errRecurrenceNeedsDueAt() error {
+	return domain.NewValidationError("recurring todos need a due date",
+		domain.FieldError{Field: "due_at", Rule: "required_with", Param: "recurrence"})
+}
+
+// planRecurrence validates the recurrence todo has after req is applied.
+// When req completes a recurring todo it returns the next occurrence, and
+// changes req to clear the rule on the completed todo since the next one
+// takes it over. Completing while clearing the rule stops the series.
+func planRecurrence(todo *domain.Todo, req *domain.UpdateTodoRequest, now time.Time) (*occurrence, error) {
+	recurrence, dueAt, timezone := todo.Recurrence, todo.DueAt, todo.Timezone
+	if req.Timezone.Set {
+		normalized, err := normalizeTimezone(req.Timezone.Value)
+		if err != nil {
+			return nil, err
+		}
+		req.Timezone = domain.PatchValue(normalized)
+		timezone = normalized
+	}
+	if req.Recurrence.Set {
+		normalized, err := normalizeRecurrence(req.Recurrence.Value)
+		if err != nil {
+			return nil, err
+		}
+		req.Recurrence = domain.PatchValue(normalized)
+		recurrence = normalized
+	}
+	if req.DueAt.Set {
+		dueAt = nil
+		if !req.DueAt.Null {
+			dueAt = &req.DueAt.Value
+		}
+	}
+
+	if recurrence == "" {
+		return nil, nil
+	}
+	if dueAt == nil {
+		return nil, errRecurrenceNeedsDueAt()
+	}
+	if !req.Completed.Set || !req.Completed.Value || todo.Completed {
+		return nil, nil
+	}
+
+	rule, err := rrule.Parse(recurrence)
+	if err != nil {
+		return nil, err
+	}
+	req.Recurrence = domain.PatchValue("")
+
+	return nextOccurrence(rule, dueAt.In(location(timezone)), now), nil
+}
+
+// nextOccurrence returns the first occurrence after both dueAt and now, so
+// that occurrences missed while the todo was overdue are skipped. The rule is
+// evaluated in dueAt's location. A counted rule carries the number of
+// occurrences left. It returns nil once the series is over.
+func nextOccurrence(rule *rrule.Rule, dueAt, now time.Time) *occurrence {
+	it := rule.Iterator(dueAt)
+	it.Next() // dueAt itself
+	// Counted rules are not skipped, so i below stays their index
+	it.SkipTo(now)
+
+	for i := 1; ; i++ {
+		next, ok := it.Next()
+		if !ok {
+			return nil
+		}
+		if !next.After(now) {
+			continue
+		}
+
+		carried := *rule
+		if rule.Count > 0 {
+			carried.Count = rule.Count - i
+		}
+		return &occurrence{dueAt: next, recurrence: carried.String()}
+	}
+}
+
+// nextTodo is the request that creates the next occurrence of completed
+func nextTodo(completed *domain.Todo, next *occurrence) domain.CreateTodoRequest {
+	tagIDs := make([]uuid.UUID, 0, len(completed.Tags))
+	for _, tag := range completed.Tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+
+	return domain.CreateTodoRequest{
+		Title:       completed.Title,
+		Description: completed.Description,
+		Priority:    completed.Priority,
+		DueAt:       &next.dueAt,
+		Recurrence:  next.recurrence,
+		Timezone:    completed.Timezone,
+		TagIDs:      tagIDs,
+		ProjectID:   completed.ProjectID,
+		ParentID:    completed.ParentID,
+		UserID:      completed.UserID,
+	}
+}
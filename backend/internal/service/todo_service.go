@@ -2,72 +2,493 @@ package service
 
 import (
 	"context"
-	"math"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/pkg/cursor"
 	"template-fullstack/backend/internal/repository"
 
 	"github.com/google/uuid"
 )
 
 type todoService struct {
-	todoRepo repository.TodoRepository
+	todoRepo          repository.TodoRepository
+	cursors           *cursor.Codec
+	maxBulkOperations int
 }
 
-func NewTodoService(todoRepo repository.TodoRepository) TodoService {
-	return &todoService{todoRepo: todoRepo}
+// NewTodoService returns a TodoService. maxBulkOperations caps the size of a
+// Bulk request.
+func NewTodoService(todoRepo repository.TodoRepository, cursors *cursor.Codec, maxBulkOperations int) TodoService {
+	return &todoService{todoRepo: todoRepo, cursors: cursors, maxBulkOperations: maxBulkOperations}
 }
 
 func (s *todoService) Create(ctx context.Context, req domain.CreateTodoRequest) (*domain.Todo, error) {
-	return s.todoRepo.Create(ctx, req)
+	return createTodo(ctx, s.todoRepo, req)
 }
 
-func (s *todoService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
-	return s.todoRepo.GetByID(ctx, id)
+func createTodo(ctx context.Context, repo repository.TodoRepository, req domain.CreateTodoRequest) (*domain.Todo, error) {
+	patch := domain.UpdateTodoRequest{Title: domain.PatchValue(req.Title)}
+	if req.Priority != "" {
+		patch.Priority = domain.PatchValue(req.Priority)
+	}
+	if len(req.TagIDs) > 0 {
+		patch.TagIDs = domain.PatchValue(req.TagIDs)
+	}
+	if err := validateTodoPatch(patch); err != nil {
+		return nil, err
+	}
+
+	if req.Priority == "" {
+		req.Priority = domain.PriorityMedium
+	}
+
+	// Todos created in a shared project belong to the project's owner. An
+	// unknown project is left for the repository to report.
+	if req.ProjectID != nil {
+		ownerID, role, err := repo.ProjectRole(ctx, *req.ProjectID, req.UserID)
+		switch {
+		case errors.Is(err, domain.ErrProjectNotFound):
+		case err != nil:
+			return nil, err
+		case ownerID != req.UserID && role.CanEdit():
+			req.UserID = ownerID
+		case ownerID != req.UserID && role != "":
+			return nil, domain.ErrProjectReadOnly
+		}
+	}
+
+	recurrence, err := normalizeRecurrence(req.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	if recurrence != "" && req.DueAt == nil {
+		return nil, errRecurrenceNeedsDueAt()
+	}
+	req.Recurrence = recurrence
+
+	timezone, err := normalizeTimezone(req.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	req.Timezone = timezone
+
+	var created *domain.Todo
+	err = repo.Transaction(ctx, func(repo repository.TodoRepository) error {
+		created, err = repo.Create(ctx, req)
+		if err != nil {
+			return err
+		}
+		return recordTodoAudit(ctx, repo, domain.AuditActionCreate, nil, created)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// GetByID returns the todo if the actor may see it: as its owner, an admin
+// or a member of its project. Other todos are reported as not found so
+// their existence doesn't leak.
+func (s *todoService) GetByID(ctx context.Context, actor domain.Actor, id uuid.UUID) (*domain.Todo, error) {
+	return getTodo(ctx, s.todoRepo, actor, id)
+}
+
+func getTodo(ctx context.Context, repo repository.TodoRepository, actor domain.Actor, id uuid.UUID) (*domain.Todo, error) {
+	todo, err := repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeTodo(ctx, repo, actor, todo, false); err != nil {
+		return nil, err
+	}
+
+	return todo, nil
+}
+
+// authorizeTodo checks that actor may see todo or, with write, change it.
+// Besides its owner and admins, members of the todo's project have access
+// according to their role; viewers get domain.ErrProjectReadOnly on writes.
+func authorizeTodo(ctx context.Context, repo repository.TodoRepository, actor domain.Actor, todo *domain.Todo, write bool) error {
+	if actor.CanAccess(todo.UserID) {
+		return nil
+	}
+	if todo.ProjectID == nil {
+		return domain.ErrTodoNotFound
+	}
+
+	_, role, err := repo.ProjectRole(ctx, *todo.ProjectID, actor.UserID)
+	switch {
+	case errors.Is(err, domain.ErrProjectNotFound) || (err == nil && role == ""):
+		return domain.ErrTodoNotFound
+	case err != nil:
+		return err
+	case write && !role.CanEdit():
+		return domain.ErrProjectReadOnly
+	}
+
+	return nil
+}
+
+func (s *todoService) GetByUserID(ctx context.Context, userID uuid.UUID, filter domain.TodoFilter, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	todos, total, err := s.todoRepo.GetByUserID(ctx, userID, filter, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewPaginatedResponse(todos, total, pagination), nil
+}
+
+func (s *todoService) GetSubtasks(ctx context.Context, actor domain.Actor, id uuid.UUID, filter domain.TodoFilter, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error) {
+	todo, err := getTodo(ctx, s.todoRepo, actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	filter.ParentID = &todo.ID
+	// Project members only see the subtasks in the parent's project; the
+	// others are private todos of its owner
+	if !actor.CanAccess(todo.UserID) {
+		filter.ProjectID = todo.ProjectID
+	}
+
+	// Subtasks always belong to the owner of their parent
+	todos, total, err := s.todoRepo.GetByUserID(ctx, todo.UserID, filter, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewPaginatedResponse(todos, total, pagination), nil
+}
+
+// Update applies req as a merge patch. PUT requests arrive here as a patch
+// that sets every field. A non-zero expectedVersion makes the update fail
+// with domain.ErrTodoVersionMismatch if the todo changed in the meantime.
+// Completing a recurring todo creates its next occurrence in the same
+// transaction.
+func (s *todoService) Update(ctx context.Context, actor domain.Actor, id uuid.UUID, req domain.UpdateTodoRequest, expectedVersion int) (*domain.Todo, error) {
+	return updateTodo(ctx, s.todoRepo, actor, id, req, expectedVersion)
+}
+
+func updateTodo(ctx context.Context, repo repository.TodoRepository, actor domain.Actor, id uuid.UUID, req domain.UpdateTodoRequest, expectedVersion int) (*domain.Todo, error) {
+	if err := validateTodoPatch(req); err != nil {
+		return nil, err
+	}
+	if req.Priority.Null {
+		req.Priority = domain.PatchValue(domain.PriorityMedium)
+	}
+
+	var updated *domain.Todo
+	err := repo.Transaction(ctx, func(repo repository.TodoRepository) error {
+		// The lock keeps a concurrent completion from creating the next
+		// occurrence twice
+		todo, err := lockTodo(ctx, repo, actor, id, expectedVersion)
+		if err != nil {
+			return err
+		}
+
+		// Members may edit a todo but not take it out of the project
+		if req.ProjectID.Set && !actor.CanAccess(todo.UserID) &&
+			(req.ProjectID.Null || todo.ProjectID == nil || req.ProjectID.Value != *todo.ProjectID) {
+			return domain.ErrTodoProjectOwner
+		}
+
+		next, err := planRecurrence(todo, &req, time.Now())
+		if err != nil {
+			return err
+		}
+
+		var changes []domain.TodoChange
+		updated, changes, err = repo.Update(ctx, id, req, expectedVersion)
+		if err != nil {
+			return err
+		}
+		if err := recordTodoAudit(ctx, repo, domain.AuditActionUpdate, todo, updated); err != nil {
+			return err
+		}
+		if err := recordCascadeAudit(ctx, repo, domain.AuditActionUpdate, changes); err != nil {
+			return err
+		}
+
+		if next == nil {
+			return nil
+		}
+		created, err := repo.Create(ctx, nextTodo(updated, next))
+		if err != nil {
+			return err
+		}
+		return recordTodoAudit(ctx, repo, domain.AuditActionCreate, nil, created)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (s *todoService) Delete(ctx context.Context, actor domain.Actor, id uuid.UUID, expectedVersion int) error {
+	return deleteTodo(ctx, s.todoRepo, actor, id, expectedVersion)
+}
+
+func deleteTodo(ctx context.Context, repo repository.TodoRepository, actor domain.Actor, id uuid.UUID, expectedVersion int) error {
+	return repo.Transaction(ctx, func(repo repository.TodoRepository) error {
+		todo, err := lockTodo(ctx, repo, actor, id, expectedVersion)
+		if err != nil {
+			return err
+		}
+
+		changes, err := repo.Delete(ctx, id, expectedVersion)
+		if err != nil {
+			return err
+		}
+		if err := recordTodoAudit(ctx, repo, domain.AuditActionDelete, todo, nil); err != nil {
+			return err
+		}
+		return recordCascadeAudit(ctx, repo, domain.AuditActionDelete, changes)
+	})
 }
 
-func (s *todoService) GetByUserID(ctx context.Context, userID uuid.UUID, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error) {
-	todos, total, err := s.todoRepo.GetByUserID(ctx, userID, pagination)
+func (s *todoService) Move(ctx context.Context, actor domain.Actor, id uuid.UUID, req domain.MoveTodoRequest, expectedVersion int) (*domain.Todo, error) {
+	switch {
+	case req.Before == nil && req.After == nil:
+		return nil, domain.NewValidationError("before or after is required",
+			domain.FieldError{Field: "before", Rule: "required_without", Param: "after"})
+	case req.Before != nil && *req.Before == id:
+		return nil, domain.NewValidationError("a todo cannot be moved next to itself",
+			domain.FieldError{Field: "before", Rule: "nefield", Param: "id"})
+	case req.After != nil && *req.After == id:
+		return nil, domain.NewValidationError("a todo cannot be moved next to itself",
+			domain.FieldError{Field: "after", Rule: "nefield", Param: "id"})
+	}
+
+	var moved *domain.Todo
+	err := s.todoRepo.Transaction(ctx, func(repo repository.TodoRepository) error {
+		todo, err := lockTodo(ctx, repo, actor, id, expectedVersion)
+		if err != nil {
+			return err
+		}
+
+		moved, err = repo.Move(ctx, id, req, expectedVersion)
+		if err != nil {
+			return err
+		}
+		return recordTodoAudit(ctx, repo, domain.AuditActionUpdate, todo, moved)
+	})
+
 	if err != nil {
 		return nil, err
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(pagination.PageSize)))
+	return moved, nil
+}
+
+// Restore applies the same access rules as Delete, to the trash
+func (s *todoService) Restore(ctx context.Context, actor domain.Actor, id uuid.UUID) (*domain.Todo, error) {
+	var restored *domain.Todo
+	err := s.todoRepo.Transaction(ctx, func(repo repository.TodoRepository) error {
+		todo, err := repo.GetTrashedByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := authorizeTodo(ctx, repo, actor, todo, true); err != nil {
+			return err
+		}
+
+		var changes []domain.TodoChange
+		restored, changes, err = repo.Restore(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := recordTodoAudit(ctx, repo, domain.AuditActionRestore, todo, restored); err != nil {
+			return err
+		}
+		return recordCascadeAudit(ctx, repo, domain.AuditActionRestore, changes)
+	})
 
-	return &domain.PaginatedResponse{
-		Data: todos,
-		Pagination: domain.Pagination{
-			Page:       pagination.Page,
-			PageSize:   pagination.PageSize,
-			Total:      total,
-			TotalPages: totalPages,
-		},
-	}, nil
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
 }
 
-func (s *todoService) Update(ctx context.Context, id uuid.UUID, req domain.UpdateTodoRequest) (*domain.Todo, error) {
-	return s.todoRepo.Update(ctx, id, req)
+// lockTodo loads the todo for a write access check and rejects stale
+// versions. The todo stays locked until the surrounding transaction ends, so
+// the version the repository re-checks and the audit log's view of the todo
+// cannot change in between.
+func lockTodo(ctx context.Context, repo repository.TodoRepository, actor domain.Actor, id uuid.UUID, expectedVersion int) (*domain.Todo, error) {
+	todo, err := repo.GetByIDForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeTodo(ctx, repo, actor, todo, true); err != nil {
+		return nil, err
+	}
+	if expectedVersion > 0 && todo.Version != expectedVersion {
+		return nil, domain.ErrTodoVersionMismatch
+	}
+
+	return todo, nil
 }
 
-func (s *todoService) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.todoRepo.Delete(ctx, id)
+func (s *todoService) List(ctx context.Context, filter domain.TodoFilter, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	todos, total, err := s.todoRepo.List(ctx, filter, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	return domain.NewPaginatedResponse(todos, total, pagination), nil
 }
 
-func (s *todoService) List(ctx context.Context, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error) {
-	todos, total, err := s.todoRepo.List(ctx, pagination)
+func (s *todoService) GetByUserIDCursor(ctx context.Context, userID uuid.UUID, filter domain.TodoFilter, query domain.CursorQuery) (*domain.CursorPaginatedResponse, error) {
+	page, err := s.keysetPage(filter, query)
+	if err != nil {
+		return nil, err
+	}
+
+	todos, hasMore, err := s.todoRepo.GetByUserIDKeyset(ctx, userID, filter, page)
 	if err != nil {
 		return nil, err
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(pagination.PageSize)))
+	return s.cursorResponse(todos, hasMore, page)
+}
+
+func (s *todoService) ListCursor(ctx context.Context, filter domain.TodoFilter, query domain.CursorQuery) (*domain.CursorPaginatedResponse, error) {
+	page, err := s.keysetPage(filter, query)
+	if err != nil {
+		return nil, err
+	}
+
+	todos, hasMore, err := s.todoRepo.ListKeyset(ctx, filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.cursorResponse(todos, hasMore, page)
+}
+
+// todoCursor is the signed payload of a todo listing cursor
+type todoCursor struct {
+	domain.KeysetPosition
+	Backward bool `json:"b,omitempty"`
+}
+
+// keysetPage validates the listing parameters and decodes the cursor.
+// Cursors follow (created_at, id) order, so a custom sort is rejected.
+func (s *todoService) keysetPage(filter domain.TodoFilter, query domain.CursorQuery) (domain.KeysetPage, error) {
+	page := domain.KeysetPage{Limit: query.Limit}
+
+	if err := filter.Validate(); err != nil {
+		return page, err
+	}
+	if strings.TrimSpace(filter.Sort) != "" {
+		return page, domain.NewValidationError("sort is not supported with cursor pagination",
+			domain.FieldError{Field: "sort", Rule: "excluded_with", Param: "cursor"})
+	}
+
+	if query.Cursor == "" {
+		return page, nil
+	}
+
+	var c todoCursor
+	if err := s.cursors.Decode(query.Cursor, &c); err != nil {
+		return page, domain.NewValidationError("invalid cursor", domain.FieldError{Field: "cursor", Rule: "cursor"})
+	}
+	page.Position = &c.KeysetPosition
+	page.Backward = c.Backward
+
+	return page, nil
+}
+
+func (s *todoService) cursorResponse(todos []domain.Todo, hasMore bool, page domain.KeysetPage) (*domain.CursorPaginatedResponse, error) {
+	resp := &domain.CursorPaginatedResponse{
+		Data:       todos,
+		Pagination: domain.CursorPagination{Limit: page.Limit},
+	}
+	if len(todos) == 0 {
+		return resp, nil
+	}
+
+	// The repository only knows about rows in the paging direction; rows in
+	// the other direction exist whenever we started from a cursor
+	hasNext, hasPrev := hasMore, page.Position != nil
+	if page.Backward {
+		hasNext, hasPrev = page.Position != nil, hasMore
+	}
+
+	var err error
+	if hasNext {
+		last := todos[len(todos)-1]
+		resp.Pagination.NextCursor, err = s.cursors.Encode(todoCursor{
+			KeysetPosition: domain.KeysetPosition{CreatedAt: last.CreatedAt, ID: last.ID},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if hasPrev {
+		first := todos[0]
+		resp.Pagination.PrevCursor, err = s.cursors.Encode(todoCursor{
+			KeysetPosition: domain.KeysetPosition{CreatedAt: first.CreatedAt, ID: first.ID},
+			Backward:       true,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+const (
+	maxTodoTitleLength = 255
+	maxTodoTags        = 20
+)
+
+func validateTodoPatch(req domain.UpdateTodoRequest) error {
+	if req.Title.Set {
+		title := strings.TrimSpace(req.Title.Value)
+		switch {
+		case req.Title.Null || title == "":
+			return domain.NewValidationError("title cannot be cleared", domain.FieldError{Field: "title", Rule: "required"})
+		case utf8.RuneCountInString(req.Title.Value) > maxTodoTitleLength:
+			return domain.NewValidationError("title is too long", domain.FieldError{Field: "title", Rule: "max", Param: strconv.Itoa(maxTodoTitleLength)})
+		}
+	}
+
+	if req.Priority.Set && !req.Priority.Null {
+		switch req.Priority.Value {
+		case domain.PriorityLow, domain.PriorityMedium, domain.PriorityHigh:
+		default:
+			return domain.NewValidationError("invalid priority", domain.FieldError{Field: "priority", Rule: "oneof", Param: "low medium high"})
+		}
+	}
+
+	if req.TagIDs.Set && len(req.TagIDs.Value) > maxTodoTags {
+		return domain.NewValidationError("too many tags", domain.FieldError{Field: "tag_ids", Rule: "max_items", Param: strconv.Itoa(maxTodoTags)})
+	}
 
-	return &domain.PaginatedResponse{
-		Data: todos,
-		Pagination: domain.Pagination{
-			Page:       pagination.Page,
-			PageSize:   pagination.PageSize,
-			Total:      total,
-			TotalPages: totalPages,
-		},
-	}, nil
+	return nil
 }
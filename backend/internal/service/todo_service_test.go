@@ -0,0 +1,1211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/pkg/cursor"
+	"template-fullstack/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// fakeTodoRepository is an in-memory repository.TodoRepository. Its parent
+// checks and subtask cascades mirror the SQL of the real repository, which
+// needs a database and has no tests of its own.
+type fakeTodoRepository struct {
+	todos    map[uuid.UUID]domain.Todo
+	projects map[uuid.UUID]fakeProject
+	audit    []domain.AuditEvent
+}
+
+// fakeProject is a project's owner and its members' roles
+type fakeProject struct {
+	owner   uuid.UUID
+	members map[uuid.UUID]domain.ProjectRole
+}
+
+func newFakeTodoRepository(todos ...domain.Todo) *fakeTodoRepository {
+	repo := &fakeTodoRepository{todos: make(map[uuid.UUID]domain.Todo), projects: make(map[uuid.UUID]fakeProject)}
+	for _, todo := range todos {
+		repo.todos[todo.ID] = todo
+	}
+	return repo
+}
+
+func (r *fakeTodoRepository) Create(ctx context.Context, req domain.CreateTodoRequest) (*domain.Todo, error) {
+	todo := domain.Todo{
+		ID:          uuid.New(),
+		Title:       req.Title,
+		Description: req.Description,
+		UserID:      req.UserID,
+		ProjectID:   req.ProjectID,
+		ParentID:    req.ParentID,
+		Priority:    req.Priority,
+		DueAt:       req.DueAt,
+		Recurrence:  req.Recurrence,
+		Timezone:    req.Timezone,
+		Version:     1,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if todo.ParentID != nil {
+		if err := r.checkParent(todo.ID, *todo.ParentID, todo.UserID); err != nil {
+			return nil, err
+		}
+	}
+	r.todos[todo.ID] = todo
+	return &todo, nil
+}
+
+func (r *fakeTodoRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt != nil {
+		return nil, domain.ErrTodoNotFound
+	}
+	return &todo, nil
+}
+
+func (r *fakeTodoRepository) GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *fakeTodoRepository) GetByUserID(ctx context.Context, userID uuid.UUID, filter domain.TodoFilter, pagination domain.PaginationQuery) ([]domain.Todo, int64, error) {
+	var todos []domain.Todo
+	for _, todo := range r.todos {
+		if todo.UserID != userID {
+			continue
+		}
+		if filter.ProjectID != nil && (todo.ProjectID == nil || *todo.ProjectID != *filter.ProjectID) {
+			continue
+		}
+		if filter.ParentID != nil && (todo.ParentID == nil || *todo.ParentID != *filter.ParentID) {
+			continue
+		}
+		todos = append(todos, todo)
+	}
+	return todos, int64(len(todos)), nil
+}
+
+func (r *fakeTodoRepository) Update(ctx context.Context, id uuid.UUID, req domain.UpdateTodoRequest, expectedVersion int) (*domain.Todo, []domain.TodoChange, error) {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt != nil {
+		return nil, nil, domain.ErrTodoNotFound
+	}
+	if expectedVersion > 0 && todo.Version != expectedVersion {
+		return nil, nil, domain.ErrTodoVersionMismatch
+	}
+	if req.ParentID.Set {
+		todo.ParentID = nil
+		if !req.ParentID.Null {
+			if err := r.checkParent(id, req.ParentID.Value, todo.UserID); err != nil {
+				return nil, nil, err
+			}
+			parentID := req.ParentID.Value
+			todo.ParentID = &parentID
+		}
+	}
+	if req.Title.Set {
+		todo.Title = req.Title.Value
+	}
+	if req.Description.Set {
+		todo.Description = req.Description.Value
+	}
+	if req.Completed.Set {
+		todo.Completed = req.Completed.Value
+		// Like the repository, the first completion time is kept
+		if !todo.Completed {
+			todo.CompletedAt = nil
+		} else if todo.CompletedAt == nil {
+			now := time.Now()
+			todo.CompletedAt = &now
+		}
+	}
+	if req.Recurrence.Set {
+		todo.Recurrence = req.Recurrence.Value
+	}
+	if req.Timezone.Set {
+		todo.Timezone = req.Timezone.Value
+	}
+	if req.Priority.Set {
+		todo.Priority = req.Priority.Value
+	}
+	if req.DueAt.Set {
+		todo.DueAt = nil
+		if !req.DueAt.Null {
+			dueAt := req.DueAt.Value
+			todo.DueAt = &dueAt
+		}
+	}
+	todo.Version++
+	r.todos[id] = todo
+
+	var changes []domain.TodoChange
+	if req.Completed.Set && req.Completed.Value {
+		var open []uuid.UUID
+		for _, subtaskID := range r.subtasks(id, nil) {
+			if subtask := r.todos[subtaskID]; !subtask.Completed && subtask.Recurrence == "" {
+				open = append(open, subtaskID)
+			}
+		}
+		changes = r.change(open, func(subtask *domain.Todo) {
+			subtask.Completed = true
+			subtask.CompletedAt = todo.CompletedAt
+		})
+	}
+	return &todo, changes, nil
+}
+
+func (r *fakeTodoRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersion int) ([]domain.TodoChange, error) {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt != nil {
+		return nil, domain.ErrTodoNotFound
+	}
+	if expectedVersion > 0 && todo.Version != expectedVersion {
+		return nil, domain.ErrTodoVersionMismatch
+	}
+	now := time.Now()
+	// The subtasks share the parent's deletion time, like in the repository
+	changes := r.change(r.subtasks(id, nil), func(todo *domain.Todo) { todo.DeletedAt = &now })
+	todo.DeletedAt = &now
+	todo.Version++
+	r.todos[id] = todo
+	return changes, nil
+}
+
+func (r *fakeTodoRepository) GetTrashedByID(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt == nil {
+		return nil, domain.ErrTodoNotFound
+	}
+	return &todo, nil
+}
+
+func (r *fakeTodoRepository) Restore(ctx context.Context, id uuid.UUID) (*domain.Todo, []domain.TodoChange, error) {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt == nil {
+		return nil, nil, domain.ErrTodoNotFound
+	}
+	if todo.ParentID != nil && r.todos[*todo.ParentID].DeletedAt != nil {
+		return nil, nil, domain.ErrTodoParentTrashed
+	}
+	changes := r.change(r.subtasks(id, todo.DeletedAt), func(todo *domain.Todo) { todo.DeletedAt = nil })
+	todo.DeletedAt = nil
+	todo.Version++
+	r.todos[id] = todo
+	return &todo, changes, nil
+}
+
+// change applies fn to the todos with the given ids and returns them as they
+// were before and after, like the repository's cascades
+func (r *fakeTodoRepository) change(ids []uuid.UUID, fn func(todo *domain.Todo)) []domain.TodoChange {
+	var changes []domain.TodoChange
+	for _, id := range ids {
+		change := domain.TodoChange{Before: r.todos[id]}
+		todo := r.todos[id]
+		fn(&todo)
+		todo.Version++
+		r.todos[id] = todo
+		change.After = todo
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// subtasks returns the subtasks of the todo at any depth that are live, or
+// with deletedAt, that were trashed at that time
+func (r *fakeTodoRepository) subtasks(id uuid.UUID, deletedAt *time.Time) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, todo := range r.todos {
+		if todo.ParentID == nil || *todo.ParentID != id {
+			continue
+		}
+		if (deletedAt == nil && todo.DeletedAt != nil) || (deletedAt != nil && (todo.DeletedAt == nil || !todo.DeletedAt.Equal(*deletedAt))) {
+			continue
+		}
+		ids = append(ids, todo.ID)
+		ids = append(ids, r.subtasks(todo.ID, deletedAt)...)
+	}
+	return ids
+}
+
+// checkParent mirrors the repository's rules for a new parent: a live todo
+// of the same owner, not the todo or one of its subtasks, and at most
+// domain.MaxTodoDepth levels in total
+func (r *fakeTodoRepository) checkParent(id, parentID, ownerID uuid.UUID) error {
+	parent, ok := r.todos[parentID]
+	if !ok || parent.DeletedAt != nil || parent.UserID != ownerID {
+		return domain.NewValidationError("unknown parent", domain.FieldError{Field: "parent_id", Rule: "exists"})
+	}
+
+	depth := 0
+	for ancestor, ok := parent, true; ok; {
+		if ancestor.ID == id {
+			return domain.NewValidationError("todo cannot be its own subtask", domain.FieldError{Field: "parent_id", Rule: "cycle"})
+		}
+		depth++
+		if ancestor.ParentID == nil {
+			break
+		}
+		ancestor, ok = r.todos[*ancestor.ParentID]
+	}
+
+	if depth+r.height(id) > domain.MaxTodoDepth {
+		return domain.NewValidationError("subtasks are nested too deeply", domain.FieldError{
+			Field: "parent_id",
+			Rule:  "depth",
+			Param: strconv.Itoa(domain.MaxTodoDepth),
+		})
+	}
+	return nil
+}
+
+// height is the number of levels of the todo's subtree, trashed subtasks
+// included
+func (r *fakeTodoRepository) height(id uuid.UUID) int {
+	height := 1
+	for _, todo := range r.todos {
+		if todo.ParentID != nil && *todo.ParentID == id {
+			if h := r.height(todo.ID) + 1; h > height {
+				height = h
+			}
+		}
+	}
+	return height
+}
+
+func (r *fakeTodoRepository) ClaimDueReminders(ctx context.Context, dueBefore time.Time, limit int) ([]domain.Todo, error) {
+	return nil, nil
+}
+
+func (r *fakeTodoRepository) Move(ctx context.Context, id uuid.UUID, req domain.MoveTodoRequest, expectedVersion int) (*domain.Todo, error) {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt != nil {
+		return nil, domain.ErrTodoNotFound
+	}
+	if expectedVersion > 0 && todo.Version != expectedVersion {
+		return nil, domain.ErrTodoVersionMismatch
+	}
+	todo.Version++
+	r.todos[id] = todo
+	return &todo, nil
+}
+
+func (r *fakeTodoRepository) RebalancePositions(ctx context.Context, maxKeyLength int) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeTodoRepository) PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	for id, todo := range r.todos {
+		if todo.DeletedAt != nil && todo.DeletedAt.Before(cutoff) {
+			delete(r.todos, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (r *fakeTodoRepository) List(ctx context.Context, filter domain.TodoFilter, pagination domain.PaginationQuery) ([]domain.Todo, int64, error) {
+	var todos []domain.Todo
+	for _, todo := range r.todos {
+		todos = append(todos, todo)
+	}
+	return todos, int64(len(todos)), nil
+}
+
+func (r *fakeTodoRepository) GetByUserIDKeyset(ctx context.Context, userID uuid.UUID, filter domain.TodoFilter, page domain.KeysetPage) ([]domain.Todo, bool, error) {
+	var todos []domain.Todo
+	for _, todo := range r.todos {
+		if todo.UserID == userID {
+			todos = append(todos, todo)
+		}
+	}
+	return keysetSlice(todos, page)
+}
+
+func (r *fakeTodoRepository) ListKeyset(ctx context.Context, filter domain.TodoFilter, page domain.KeysetPage) ([]domain.Todo, bool, error) {
+	var todos []domain.Todo
+	for _, todo := range r.todos {
+		todos = append(todos, todo)
+	}
+	return keysetSlice(todos, page)
+}
+
+// keysetSlice mimics the repository's newest-first keyset paging in memory
+func keysetSlice(todos []domain.Todo, page domain.KeysetPage) ([]domain.Todo, bool, error) {
+	newer := func(a, b domain.KeysetPosition) bool {
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return a.ID.String() > b.ID.String()
+		}
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	position := func(todo domain.Todo) domain.KeysetPosition {
+		return domain.KeysetPosition{CreatedAt: todo.CreatedAt, ID: todo.ID}
+	}
+
+	sort.Slice(todos, func(i, j int) bool { return newer(position(todos[i]), position(todos[j])) })
+
+	var window []domain.Todo
+	for _, todo := range todos {
+		switch {
+		case page.Position == nil,
+			!page.Backward && newer(*page.Position, position(todo)),
+			page.Backward && newer(position(todo), *page.Position):
+			window = append(window, todo)
+		}
+	}
+
+	if !page.Backward {
+		if len(window) > page.Limit {
+			return window[:page.Limit], true, nil
+		}
+		return window, false, nil
+	}
+	if len(window) > page.Limit {
+		return window[len(window)-page.Limit:], true, nil
+	}
+	return window, false, nil
+}
+
+func (r *fakeTodoRepository) ProjectRole(ctx context.Context, projectID, userID uuid.UUID) (uuid.UUID, domain.ProjectRole, error) {
+	project, ok := r.projects[projectID]
+	if !ok {
+		return uuid.Nil, "", domain.ErrProjectNotFound
+	}
+	return project.owner, project.members[userID], nil
+}
+
+func (r *fakeTodoRepository) RecordAudit(ctx context.Context, event domain.AuditEvent) error {
+	event.ID = uuid.New()
+	event.CreatedAt = time.Now()
+	r.audit = append(r.audit, event)
+	return nil
+}
+
+// Transaction restores the previous contents if fn fails, like a rollback
+func (r *fakeTodoRepository) Transaction(ctx context.Context, fn func(repo repository.TodoRepository) error) error {
+	snapshot := make(map[uuid.UUID]domain.Todo, len(r.todos))
+	for id, todo := range r.todos {
+		snapshot[id] = todo
+	}
+	audited := len(r.audit)
+
+	if err := fn(r); err != nil {
+		r.todos = snapshot
+		r.audit = r.audit[:audited]
+		return err
+	}
+	return nil
+}
+
+func TestTodoServiceOwnership(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	stranger := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	admin := domain.Actor{UserID: uuid.New(), Role: domain.RoleAdmin}
+
+	tests := []struct {
+		name    string
+		actor   domain.Actor
+		missing bool
+		wantErr error
+	}{
+		{name: "owner", actor: owner},
+		{name: "admin bypasses ownership", actor: admin},
+		{name: "other user sees not found", actor: stranger, wantErr: domain.ErrTodoNotFound},
+		{name: "missing todo", actor: owner, missing: true, wantErr: domain.ErrTodoNotFound},
+	}
+
+	operations := []struct {
+		name string
+		run  func(svc TodoService, actor domain.Actor, id uuid.UUID) error
+	}{
+		{
+			name: "GetByID",
+			run: func(svc TodoService, actor domain.Actor, id uuid.UUID) error {
+				_, err := svc.GetByID(context.Background(), actor, id)
+				return err
+			},
+		},
+		{
+			name: "Update",
+			run: func(svc TodoService, actor domain.Actor, id uuid.UUID) error {
+				_, err := svc.Update(context.Background(), actor, id, domain.UpdateTodoRequest{Completed: domain.PatchValue(true)}, 0)
+				return err
+			},
+		},
+		{
+			name: "Delete",
+			run: func(svc TodoService, actor domain.Actor, id uuid.UUID) error {
+				return svc.Delete(context.Background(), actor, id, 0)
+			},
+		},
+		{
+			name: "Move",
+			run: func(svc TodoService, actor domain.Actor, id uuid.UUID) error {
+				before := uuid.New()
+				_, err := svc.Move(context.Background(), actor, id, domain.MoveTodoRequest{Before: &before}, 0)
+				return err
+			},
+		},
+		{
+			name: "GetOccurrences",
+			run: func(svc TodoService, actor domain.Actor, id uuid.UUID) error {
+				_, err := svc.GetOccurrences(context.Background(), actor, id, domain.OccurrenceQuery{Until: time.Now().AddDate(1, 0, 0), Limit: 10})
+				return err
+			},
+		},
+		{
+			name: "GetSubtasks",
+			run: func(svc TodoService, actor domain.Actor, id uuid.UUID) error {
+				_, err := svc.GetSubtasks(context.Background(), actor, id, domain.TodoFilter{}, domain.PaginationQuery{Page: 1, PageSize: 10})
+				return err
+			},
+		},
+	}
+
+	for _, op := range operations {
+		for _, tt := range tests {
+			t.Run(op.name+"/"+tt.name, func(t *testing.T) {
+				todo := domain.Todo{ID: uuid.New(), Title: "Write tests", UserID: owner.UserID, Version: 1}
+				repo := newFakeTodoRepository(todo)
+				svc := NewTodoService(repo, cursor.NewCodec("test-secret"), 10)
+
+				id := todo.ID
+				if tt.missing {
+					id = uuid.New()
+				}
+
+				err := op.run(svc, tt.actor, id)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+
+				// A rejected call must not have touched the stored todo
+				if tt.wantErr != nil {
+					if stored, ok := repo.todos[todo.ID]; !ok || !reflect.DeepEqual(stored, todo) {
+						t.Fatalf("todo was modified by a rejected %s", op.name)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestTodoServiceProjectMembers(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	editor := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	viewer := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	stranger := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	projectID := uuid.New()
+
+	newService := func() (TodoService, *fakeTodoRepository, domain.Todo) {
+		todo := domain.Todo{ID: uuid.New(), Title: "Plan sprint", UserID: owner.UserID, ProjectID: &projectID, Version: 1}
+		repo := newFakeTodoRepository(todo)
+		repo.projects[projectID] = fakeProject{owner: owner.UserID, members: map[uuid.UUID]domain.ProjectRole{
+			owner.UserID:  domain.ProjectRoleOwner,
+			editor.UserID: domain.ProjectRoleEditor,
+			viewer.UserID: domain.ProjectRoleViewer,
+		}}
+		return NewTodoService(repo, cursor.NewCodec("test-secret"), 10), repo, todo
+	}
+	complete := domain.UpdateTodoRequest{Completed: domain.PatchValue(true)}
+
+	t.Run("members can read", func(t *testing.T) {
+		svc, _, todo := newService()
+		for _, actor := range []domain.Actor{editor, viewer} {
+			if _, err := svc.GetByID(context.Background(), actor, todo.ID); err != nil {
+				t.Fatalf("GetByID: %v", err)
+			}
+		}
+		if _, err := svc.GetByID(context.Background(), stranger, todo.ID); !errors.Is(err, domain.ErrTodoNotFound) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrTodoNotFound)
+		}
+	})
+
+	t.Run("viewers cannot change todos", func(t *testing.T) {
+		svc, repo, todo := newService()
+		_, err := svc.Update(context.Background(), viewer, todo.ID, complete, 0)
+		if !errors.Is(err, domain.ErrProjectReadOnly) || !errors.Is(err, domain.ErrForbidden) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrProjectReadOnly)
+		}
+		if err := svc.Delete(context.Background(), viewer, todo.ID, 0); !errors.Is(err, domain.ErrProjectReadOnly) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrProjectReadOnly)
+		}
+		if !reflect.DeepEqual(repo.todos[todo.ID], todo) {
+			t.Fatal("todo was modified by a viewer")
+		}
+	})
+
+	t.Run("editors change todos", func(t *testing.T) {
+		svc, _, todo := newService()
+		updated, err := svc.Update(context.Background(), editor, todo.ID, complete, 0)
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if !updated.Completed || updated.UserID != owner.UserID {
+			t.Fatalf("got completed %v owned by %s, want completed and still owned by the project owner", updated.Completed, updated.UserID)
+		}
+	})
+
+	t.Run("editors cannot move todos out of the project", func(t *testing.T) {
+		svc, _, todo := newService()
+		_, err := svc.Update(context.Background(), editor, todo.ID, domain.UpdateTodoRequest{ProjectID: domain.Patch[uuid.UUID]{Set: true, Null: true}}, 0)
+		if !errors.Is(err, domain.ErrTodoProjectOwner) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrTodoProjectOwner)
+		}
+	})
+
+	t.Run("todos created in the project belong to its owner", func(t *testing.T) {
+		svc, _, _ := newService()
+		created, err := svc.Create(context.Background(), domain.CreateTodoRequest{Title: "Retro", ProjectID: &projectID, UserID: editor.UserID})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if created.UserID != owner.UserID {
+			t.Fatalf("got owner %s, want %s", created.UserID, owner.UserID)
+		}
+
+		_, err = svc.Create(context.Background(), domain.CreateTodoRequest{Title: "Retro", ProjectID: &projectID, UserID: viewer.UserID})
+		if !errors.Is(err, domain.ErrProjectReadOnly) {
+			t.Fatalf("got error %v, want %v", err, domain.ErrProjectReadOnly)
+		}
+	})
+
+	t.Run("members only see the subtasks in the project", func(t *testing.T) {
+		svc, repo, todo := newService()
+		shared, _ := repo.Create(context.Background(), domain.CreateTodoRequest{Title: "Agenda", UserID: owner.UserID, ProjectID: &projectID, ParentID: &todo.ID})
+		repo.Create(context.Background(), domain.CreateTodoRequest{Title: "Private note", UserID: owner.UserID, ParentID: &todo.ID})
+		pagination := domain.PaginationQuery{Page: 1, PageSize: 10}
+
+		resp, err := svc.GetSubtasks(context.Background(), viewer, todo.ID, domain.TodoFilter{}, pagination)
+		if err != nil {
+			t.Fatalf("GetSubtasks: %v", err)
+		}
+		if subtasks := resp.Data.([]domain.Todo); len(subtasks) != 1 || subtasks[0].ID != shared.ID {
+			t.Fatalf("viewer got %d subtasks, want only the one in the project", len(subtasks))
+		}
+
+		resp, err = svc.GetSubtasks(context.Background(), owner, todo.ID, domain.TodoFilter{}, pagination)
+		if err != nil {
+			t.Fatalf("GetSubtasks: %v", err)
+		}
+		if subtasks := resp.Data.([]domain.Todo); len(subtasks) != 2 {
+			t.Fatalf("owner got %d subtasks, want 2", len(subtasks))
+		}
+	})
+}
+
+func TestTodoServiceVersionCheck(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	patch := domain.UpdateTodoRequest{Completed: domain.PatchValue(true)}
+
+	tests := []struct {
+		name            string
+		expectedVersion int
+		wantErr         error
+	}{
+		{name: "unconditional", expectedVersion: 0},
+		{name: "current version", expectedVersion: 2},
+		{name: "stale version", expectedVersion: 1, wantErr: domain.ErrTodoVersionMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todo := domain.Todo{ID: uuid.New(), Title: "Write tests", UserID: owner.UserID, Version: 2}
+			repo := newFakeTodoRepository(todo)
+			svc := NewTodoService(repo, cursor.NewCodec("test-secret"), 10)
+
+			updated, err := svc.Update(context.Background(), owner, todo.ID, patch, tt.expectedVersion)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update: got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && updated.Version != todo.Version+1 {
+				t.Fatalf("Update: got version %d, want %d", updated.Version, todo.Version+1)
+			}
+
+			repo = newFakeTodoRepository(todo)
+			svc = NewTodoService(repo, cursor.NewCodec("test-secret"), 10)
+
+			err = svc.Delete(context.Background(), owner, todo.ID, tt.expectedVersion)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Delete: got error %v, want %v", err, tt.wantErr)
+			}
+			if trashed := repo.todos[todo.ID].DeletedAt != nil; trashed != (tt.wantErr == nil) {
+				t.Fatalf("Delete: todo trashed = %v after %s delete", trashed, tt.name)
+			}
+		})
+	}
+}
+
+func TestTodoServiceCursorPagination(t *testing.T) {
+	userID := uuid.New()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Five todos, the last two created at the same instant
+	var todos []domain.Todo
+	for i, offset := range []int{0, 1, 2, 3, 3} {
+		todos = append(todos, domain.Todo{
+			ID:        uuid.New(),
+			Title:     fmt.Sprintf("todo %d", i),
+			UserID:    userID,
+			CreatedAt: start.Add(time.Duration(offset) * time.Hour),
+		})
+	}
+	svc := NewTodoService(newFakeTodoRepository(todos...), cursor.NewCodec("test-secret"), 10)
+	ctx := context.Background()
+
+	fetch := func(c string) *domain.CursorPaginatedResponse {
+		t.Helper()
+		resp, err := svc.GetByUserIDCursor(ctx, userID, domain.TodoFilter{}, domain.CursorQuery{Cursor: c, Limit: 2})
+		if err != nil {
+			t.Fatalf("GetByUserIDCursor: %v", err)
+		}
+		return resp
+	}
+	ids := func(resp *domain.CursorPaginatedResponse) []uuid.UUID {
+		var ids []uuid.UUID
+		for _, todo := range resp.Data.([]domain.Todo) {
+			ids = append(ids, todo.ID)
+		}
+		return ids
+	}
+
+	// Walk forward, then back again
+	var forward [][]uuid.UUID
+	pages := []*domain.CursorPaginatedResponse{fetch("")}
+	for pages[len(pages)-1].Pagination.NextCursor != "" {
+		pages = append(pages, fetch(pages[len(pages)-1].Pagination.NextCursor))
+	}
+	seen := make(map[uuid.UUID]bool)
+	for _, page := range pages {
+		forward = append(forward, ids(page))
+		for _, id := range ids(page) {
+			if seen[id] {
+				t.Fatalf("todo %s returned twice", id)
+			}
+			seen[id] = true
+		}
+	}
+	if len(seen) != len(todos) || len(pages) != 3 {
+		t.Fatalf("got %d todos on %d pages, want %d on 3", len(seen), len(pages), len(todos))
+	}
+	if pages[0].Pagination.PrevCursor != "" {
+		t.Fatal("first page has a prev cursor")
+	}
+
+	page := pages[len(pages)-1]
+	for i := len(pages) - 2; i >= 0; i-- {
+		page = fetch(page.Pagination.PrevCursor)
+		if fmt.Sprint(ids(page)) != fmt.Sprint(forward[i]) {
+			t.Fatalf("page %d going back: got %v, want %v", i, ids(page), forward[i])
+		}
+	}
+	if page.Pagination.PrevCursor != "" {
+		t.Fatal("first page reached going back has a prev cursor")
+	}
+
+	// Cursors signed with another key are rejected
+	forged, err := cursor.NewCodec("other-secret").Encode(todoCursor{
+		KeysetPosition: domain.KeysetPosition{CreatedAt: start, ID: todos[0].ID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = svc.GetByUserIDCursor(ctx, userID, domain.TodoFilter{}, domain.CursorQuery{Cursor: forged, Limit: 2})
+	if !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("forged cursor: got error %v, want validation error", err)
+	}
+}
+
+func TestTodoServiceTrash(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	stranger := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	ctx := context.Background()
+
+	todo := domain.Todo{ID: uuid.New(), Title: "Write tests", UserID: owner.UserID, Version: 1}
+	repo := newFakeTodoRepository(todo)
+	svc := NewTodoService(repo, cursor.NewCodec("test-secret"), 10)
+
+	if err := svc.Delete(ctx, owner, todo.ID, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := svc.GetByID(ctx, owner, todo.ID); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Fatalf("GetByID of trashed todo: got error %v, want %v", err, domain.ErrTodoNotFound)
+	}
+	if err := svc.Delete(ctx, owner, todo.ID, 0); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Fatalf("Delete of trashed todo: got error %v, want %v", err, domain.ErrTodoNotFound)
+	}
+
+	if _, err := svc.Restore(ctx, stranger, todo.ID); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Fatalf("Restore by other user: got error %v, want %v", err, domain.ErrTodoNotFound)
+	}
+
+	restored, err := svc.Restore(ctx, owner, todo.ID)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.DeletedAt != nil {
+		t.Fatal("restored todo is still marked deleted")
+	}
+	if _, err := svc.Restore(ctx, owner, todo.ID); !errors.Is(err, domain.ErrTodoNotFound) {
+		t.Fatalf("Restore of live todo: got error %v, want %v", err, domain.ErrTodoNotFound)
+	}
+}
+
+func TestTodoServiceSubtasks(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	ctx := context.Background()
+
+	repo := newFakeTodoRepository()
+	other, _ := repo.Create(ctx, domain.CreateTodoRequest{Title: "Other user's", UserID: uuid.New()})
+	svc := NewTodoService(repo, cursor.NewCodec("test-secret"), 10)
+
+	// A chain of todos as deep as allowed
+	chain := make([]*domain.Todo, domain.MaxTodoDepth)
+	for i := range chain {
+		req := domain.CreateTodoRequest{Title: fmt.Sprintf("Level %d", i+1), UserID: owner.UserID}
+		if i > 0 {
+			req.ParentID = &chain[i-1].ID
+		}
+		todo, err := svc.Create(ctx, req)
+		if err != nil {
+			t.Fatalf("Create level %d: %v", i+1, err)
+		}
+		chain[i] = todo
+	}
+	root, deepest := chain[0], chain[len(chain)-1]
+	single, _ := svc.Create(ctx, domain.CreateTodoRequest{Title: "Single", UserID: owner.UserID})
+
+	rejections := []struct {
+		name     string
+		id       uuid.UUID
+		parentID uuid.UUID
+		wantRule string
+	}{
+		{name: "own subtask", id: root.ID, parentID: root.ID, wantRule: "cycle"},
+		{name: "below a subtask", id: root.ID, parentID: deepest.ID, wantRule: "cycle"},
+		{name: "other user's parent", id: single.ID, parentID: other.ID, wantRule: "exists"},
+		{name: "unknown parent", id: single.ID, parentID: uuid.New(), wantRule: "exists"},
+		{name: "too deep", id: single.ID, parentID: deepest.ID, wantRule: "depth"},
+		// The subtree moves along, so its height counts
+		{name: "subtree too deep", id: root.ID, parentID: single.ID, wantRule: "depth"},
+	}
+	for _, tt := range rejections {
+		t.Run(tt.name, func(t *testing.T) {
+			audited := len(repo.audit)
+			before := repo.todos[tt.id]
+
+			_, err := svc.Update(ctx, owner, tt.id, domain.UpdateTodoRequest{ParentID: domain.PatchValue(tt.parentID)}, 0)
+			if rule := validationRule(err); rule != tt.wantRule {
+				t.Fatalf("got error %v, want a %s validation error", err, tt.wantRule)
+			}
+			if !reflect.DeepEqual(repo.todos[tt.id], before) || len(repo.audit) != audited {
+				t.Fatal("the rejected update was not rolled back")
+			}
+		})
+	}
+	if _, err := svc.Create(ctx, domain.CreateTodoRequest{Title: "Too deep", UserID: owner.UserID, ParentID: &deepest.ID}); validationRule(err) != "depth" {
+		t.Fatalf("Create below the deepest level: got error %v, want a depth validation error", err)
+	}
+
+	// Completing a todo completes its subtasks at any depth
+	if _, err := svc.Update(ctx, owner, chain[1].ID, domain.UpdateTodoRequest{Completed: domain.PatchValue(true)}, 0); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	for i, todo := range chain {
+		if completed := repo.todos[todo.ID].Completed; completed != (i >= 1) {
+			t.Fatalf("level %d: completed = %v", i+1, completed)
+		}
+	}
+
+	// Trashing a todo trashes its subtasks, which can't come back on their own
+	if err := svc.Delete(ctx, owner, chain[2].ID, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	for _, todo := range chain[2:] {
+		if _, err := svc.GetByID(ctx, owner, todo.ID); !errors.Is(err, domain.ErrTodoNotFound) {
+			t.Fatalf("GetByID of trashed subtask: got error %v, want %v", err, domain.ErrTodoNotFound)
+		}
+	}
+	if _, err := svc.Restore(ctx, owner, deepest.ID); !errors.Is(err, domain.ErrTodoParentTrashed) {
+		t.Fatalf("Restore below a trashed parent: got error %v, want %v", err, domain.ErrTodoParentTrashed)
+	}
+
+	// Restoring the todo brings back the subtasks trashed along with it
+	if _, err := svc.Restore(ctx, owner, chain[2].ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	for _, todo := range chain[2:] {
+		if _, err := svc.GetByID(ctx, owner, todo.ID); err != nil {
+			t.Fatalf("GetByID of restored subtask: %v", err)
+		}
+	}
+}
+
+// validationRule returns the rule of a validation error with one detail
+func validationRule(err error) string {
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) || len(domainErr.Details) != 1 {
+		return ""
+	}
+	return domainErr.Details[0].Rule
+}
+
+func TestTodoServiceBulk(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	ctx := context.Background()
+
+	todo := domain.Todo{ID: uuid.New(), Title: "Write tests", UserID: owner.UserID, Version: 1}
+	operations := []domain.BulkTodoOperation{
+		{Op: domain.BulkOpCreate, Data: []byte(`{"title": "Ship it"}`)},
+		{Op: domain.BulkOpUpdate, ID: todo.ID, Data: []byte(`{"completed": true}`)},
+		{Op: domain.BulkOpDelete, ID: uuid.New()},
+	}
+
+	t.Run("atomic rolls back", func(t *testing.T) {
+		repo := newFakeTodoRepository(todo)
+		svc := NewTodoService(repo, cursor.NewCodec("test-secret"), 10)
+
+		resp, err := svc.Bulk(ctx, owner, domain.BulkTodoRequest{Operations: operations})
+		if err != nil {
+			t.Fatalf("Bulk: %v", err)
+		}
+		if resp.Committed || resp.FailedIndex == nil || *resp.FailedIndex != 2 {
+			t.Fatalf("got committed=%v failed_index=%v, want rollback at 2", resp.Committed, resp.FailedIndex)
+		}
+		if !errors.Is(resp.Results[2].Err, domain.ErrTodoNotFound) || !errors.Is(resp.Results[0].Err, domain.ErrBulkAborted) {
+			t.Fatalf("unexpected result errors: %v, %v", resp.Results[0].Err, resp.Results[2].Err)
+		}
+		if len(repo.todos) != 1 || !reflect.DeepEqual(repo.todos[todo.ID], todo) {
+			t.Fatal("rolled back batch changed the stored todos")
+		}
+	})
+
+	t.Run("best effort applies what it can", func(t *testing.T) {
+		repo := newFakeTodoRepository(todo)
+		svc := NewTodoService(repo, cursor.NewCodec("test-secret"), 10)
+
+		resp, err := svc.Bulk(ctx, owner, domain.BulkTodoRequest{Mode: domain.BulkModeBestEffort, Operations: operations})
+		if err != nil {
+			t.Fatalf("Bulk: %v", err)
+		}
+		var succeeded []bool
+		for _, result := range resp.Results {
+			succeeded = append(succeeded, result.Success)
+		}
+		if !resp.Committed || fmt.Sprint(succeeded) != "[true true false]" {
+			t.Fatalf("got committed=%v succeeded=%v", resp.Committed, succeeded)
+		}
+		if len(repo.todos) != 2 || !repo.todos[todo.ID].Completed {
+			t.Fatal("successful operations were not applied")
+		}
+	})
+
+	t.Run("batch size limit", func(t *testing.T) {
+		svc := NewTodoService(newFakeTodoRepository(), cursor.NewCodec("test-secret"), 2)
+
+		_, err := svc.Bulk(ctx, owner, domain.BulkTodoRequest{Operations: operations})
+		if !errors.Is(err, domain.ErrValidation) {
+			t.Fatalf("got error %v, want validation error", err)
+		}
+	})
+}
+
+func TestTodoServiceRecurrence(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	ctx := context.Background()
+	// Far enough ahead that no occurrence is skipped as missed
+	dueAt := time.Date(2100, 1, 4, 9, 0, 0, 0, time.UTC)
+
+	newTodo := func(recurrence string) domain.Todo {
+		return domain.Todo{ID: uuid.New(), Title: "Water plants", UserID: owner.UserID, DueAt: &dueAt, Recurrence: recurrence, Version: 1}
+	}
+	complete := domain.UpdateTodoRequest{Completed: domain.PatchValue(true)}
+
+	t.Run("completing creates the next occurrence", func(t *testing.T) {
+		todo := newTodo("FREQ=WEEKLY;COUNT=3")
+		repo := newFakeTodoRepository(todo)
+		svc := NewTodoService(repo, cursor.NewCodec("test-secret"), 10)
+
+		completed, err := svc.Update(ctx, owner, todo.ID, complete, 0)
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if completed.Recurrence != "" {
+			t.Fatalf("completed todo kept recurrence %q", completed.Recurrence)
+		}
+		if len(repo.todos) != 2 {
+			t.Fatalf("got %d todos, want 2", len(repo.todos))
+		}
+
+		for _, next := range repo.todos {
+			if next.ID == todo.ID {
+				continue
+			}
+			if next.Completed || next.Title != todo.Title || !next.DueAt.Equal(dueAt.AddDate(0, 0, 7)) {
+				t.Fatalf("unexpected next occurrence %+v", next)
+			}
+			if next.Recurrence != "FREQ=WEEKLY;COUNT=2" {
+				t.Fatalf("next occurrence has recurrence %q", next.Recurrence)
+			}
+		}
+
+		// Completing again is not a new completion
+		if _, err := svc.Update(ctx, owner, todo.ID, complete, 0); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if len(repo.todos) != 2 {
+			t.Fatalf("got %d todos after completing twice, want 2", len(repo.todos))
+		}
+	})
+
+	t.Run("clearing the rule stops the series", func(t *testing.T) {
+		todo := newTodo("FREQ=DAILY")
+		repo := newFakeTodoRepository(todo)
+		svc := NewTodoService(repo, cursor.NewCodec("test-secret"), 10)
+
+		stop := complete
+		stop.Recurrence = domain.PatchNull[string]()
+		if _, err := svc.Update(ctx, owner, todo.ID, stop, 0); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if len(repo.todos) != 1 {
+			t.Fatalf("got %d todos, want 1", len(repo.todos))
+		}
+	})
+
+	t.Run("last occurrence ends the series", func(t *testing.T) {
+		todo := newTodo("FREQ=DAILY;COUNT=1")
+		repo := newFakeTodoRepository(todo)
+		svc := NewTodoService(repo, cursor.NewCodec("test-secret"), 10)
+
+		if _, err := svc.Update(ctx, owner, todo.ID, complete, 0); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if len(repo.todos) != 1 {
+			t.Fatalf("got %d todos, want 1", len(repo.todos))
+		}
+	})
+
+	t.Run("occurrences", func(t *testing.T) {
+		todo := newTodo("FREQ=WEEKLY;BYDAY=MO,TH")
+		svc := NewTodoService(newFakeTodoRepository(todo), cursor.NewCodec("test-secret"), 10)
+
+		occurrences, err := svc.GetOccurrences(ctx, owner, todo.ID, domain.OccurrenceQuery{Until: dueAt.AddDate(0, 0, 7), Limit: 10})
+		if err != nil {
+			t.Fatalf("GetOccurrences: %v", err)
+		}
+		want := []time.Time{dueAt.AddDate(0, 0, 3), dueAt.AddDate(0, 0, 7)}
+		if !reflect.DeepEqual(occurrences, want) {
+			t.Fatalf("got %v, want %v", occurrences, want)
+		}
+	})
+
+	t.Run("occurrences of an old series", func(t *testing.T) {
+		longAgo := time.Date(2000, 1, 1, 9, 0, 0, 0, time.UTC)
+		todo := newTodo("FREQ=DAILY")
+		todo.DueAt = &longAgo
+		svc := NewTodoService(newFakeTodoRepository(todo), cursor.NewCodec("test-secret"), 10)
+
+		now := time.Now()
+		occurrences, err := svc.GetOccurrences(ctx, owner, todo.ID, domain.OccurrenceQuery{Until: now.Add(72 * time.Hour), Limit: 10})
+		if err != nil {
+			t.Fatalf("GetOccurrences: %v", err)
+		}
+		if len(occurrences) != 3 {
+			t.Fatalf("got %d occurrences in the next three days, want 3", len(occurrences))
+		}
+		for _, occurrence := range occurrences {
+			if !occurrence.After(now) || occurrence.Hour() != 9 {
+				t.Fatalf("got occurrence %s, want a future one at 09:00", occurrence)
+			}
+		}
+	})
+
+	t.Run("rules follow the todo's time zone", func(t *testing.T) {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		if err != nil {
+			t.Skipf("time zone database unavailable: %v", err)
+		}
+		// Monday 00:30 in Berlin is still Sunday in UTC
+		mondayNight := time.Date(2100, 1, 4, 0, 30, 0, 0, berlin)
+		repo := newFakeTodoRepository()
+		svc := NewTodoService(repo, cursor.NewCodec("test-secret"), 10)
+
+		todo, err := svc.Create(ctx, domain.CreateTodoRequest{
+			Title: "Weekly review", UserID: owner.UserID, DueAt: &mondayNight, Recurrence: "FREQ=WEEKLY;BYDAY=MO", Timezone: "Europe/Berlin",
+		})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		occurrences, err := svc.GetOccurrences(ctx, owner, todo.ID, domain.OccurrenceQuery{Until: mondayNight.AddDate(0, 0, 7), Limit: 10})
+		if err != nil {
+			t.Fatalf("GetOccurrences: %v", err)
+		}
+		if len(occurrences) != 1 || !occurrences[0].Equal(mondayNight.AddDate(0, 0, 7)) {
+			t.Fatalf("got %v, want the next Monday 00:30 in Berlin", occurrences)
+		}
+
+		if _, err := svc.Update(ctx, owner, todo.ID, complete, 0); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		for _, next := range repo.todos {
+			if next.ID != todo.ID && (!next.DueAt.Equal(mondayNight.AddDate(0, 0, 7)) || next.Timezone != "Europe/Berlin") {
+				t.Fatalf("next occurrence is due %s in %q, want the next Monday 00:30 in Berlin", next.DueAt, next.Timezone)
+			}
+		}
+	})
+
+	t.Run("recurring subtasks stay open", func(t *testing.T) {
+		repo := newFakeTodoRepository()
+		svc := NewTodoService(repo, cursor.NewCodec("test-secret"), 10)
+
+		parent, _ := svc.Create(ctx, domain.CreateTodoRequest{Title: "Garden", UserID: owner.UserID})
+		once, _ := svc.Create(ctx, domain.CreateTodoRequest{Title: "Plant", UserID: owner.UserID, ParentID: &parent.ID})
+		recurring, _ := svc.Create(ctx, domain.CreateTodoRequest{Title: "Water", UserID: owner.UserID, ParentID: &parent.ID, DueAt: &dueAt, Recurrence: "FREQ=DAILY"})
+
+		if _, err := svc.Update(ctx, owner, parent.ID, complete, 0); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if !repo.todos[once.ID].Completed {
+			t.Fatal("subtask was not completed with its parent")
+		}
+		// Completing it in the cascade would have ended its series
+		if after := repo.todos[recurring.ID]; after.Completed || after.Recurrence == "" {
+			t.Fatalf("recurring subtask was completed with its parent: %+v", after)
+		}
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		svc := NewTodoService(newFakeTodoRepository(), cursor.NewCodec("test-secret"), 10)
+
+		for _, req := range []domain.CreateTodoRequest{
+			{Title: "No due date", UserID: owner.UserID, Recurrence: "FREQ=DAILY"},
+			{Title: "Bad rule", UserID: owner.UserID, DueAt: &dueAt, Recurrence: "FREQ=HOURLY"},
+			{Title: "Bad time zone", UserID: owner.UserID, DueAt: &dueAt, Recurrence: "FREQ=DAILY", Timezone: "Mars/Olympus"},
+		} {
+			if _, err := svc.Create(ctx, req); !errors.Is(err, domain.ErrValidation) {
+				t.Fatalf("Create(%q): got error %v, want validation error", req.Title, err)
+			}
+		}
+
+		todo, err := svc.Create(ctx, domain.CreateTodoRequest{Title: "No time zone", UserID: owner.UserID})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if todo.Timezone != "UTC" {
+			t.Fatalf("got time zone %q, want UTC by default", todo.Timezone)
+		}
+		if _, err := svc.Update(ctx, owner, todo.ID, domain.UpdateTodoRequest{Timezone: domain.PatchValue("Mars/Olympus")}, 0); validationRule(err) != "timezone" {
+			t.Fatalf("Update with unknown time zone: got error %v, want a timezone validation error", err)
+		}
+	})
+}
+
+func TestValidateTodoPatch(t *testing.T) {
+	tooManyTags := make([]uuid.UUID, maxTodoTags+1)
+
+	tests := []struct {
+		name     string
+		req      domain.UpdateTodoRequest
+		wantRule string
+	}{
+		{name: "empty patch"},
+		{name: "new title", req: domain.UpdateTodoRequest{Title: domain.PatchValue("Ship it")}},
+		{name: "null title", req: domain.UpdateTodoRequest{Title: domain.PatchNull[string]()}, wantRule: "required"},
+		{name: "blank title", req: domain.UpdateTodoRequest{Title: domain.PatchValue("   ")}, wantRule: "required"},
+		{name: "title of max length", req: domain.UpdateTodoRequest{Title: domain.PatchValue(strings.Repeat("é", maxTodoTitleLength))}},
+		{name: "title too long", req: domain.UpdateTodoRequest{Title: domain.PatchValue(strings.Repeat("é", maxTodoTitleLength+1))}, wantRule: "max"},
+		{name: "null priority resets it", req: domain.UpdateTodoRequest{Priority: domain.PatchNull[domain.Priority]()}},
+		{name: "valid priority", req: domain.UpdateTodoRequest{Priority: domain.PatchValue(domain.PriorityHigh)}},
+		{name: "unknown priority", req: domain.UpdateTodoRequest{Priority: domain.PatchValue(domain.Priority("urgent"))}, wantRule: "oneof"},
+		{name: "too many tags", req: domain.UpdateTodoRequest{TagIDs: domain.PatchValue(tooManyTags)}, wantRule: "max_items"},
+		{name: "null description", req: domain.UpdateTodoRequest{Description: domain.PatchNull[string]()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTodoPatch(tt.req)
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Fatalf("got error %v, want none", err)
+				}
+				return
+			}
+
+			var domainErr *domain.Error
+			if !errors.As(err, &domainErr) || len(domainErr.Details) != 1 || domainErr.Details[0].Rule != tt.wantRule {
+				t.Fatalf("got error %v, want a %s validation error", err, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestTodoServicePriority(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	ctx := context.Background()
+	svc := NewTodoService(newFakeTodoRepository(), cursor.NewCodec("test-secret"), 10)
+
+	todo, err := svc.Create(ctx, domain.CreateTodoRequest{Title: "Write tests", UserID: owner.UserID})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if todo.Priority != domain.PriorityMedium {
+		t.Fatalf("got default priority %q, want %q", todo.Priority, domain.PriorityMedium)
+	}
+
+	_, err = svc.Create(ctx, domain.CreateTodoRequest{Title: "Write tests", Priority: "urgent", UserID: owner.UserID})
+	if !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("Create with unknown priority: got error %v, want validation error", err)
+	}
+
+	todo, err = svc.Update(ctx, owner, todo.ID, domain.UpdateTodoRequest{Priority: domain.PatchValue(domain.PriorityHigh)}, 0)
+	if err != nil || todo.Priority != domain.PriorityHigh {
+		t.Fatalf("Update to high: got %v, %v", todo, err)
+	}
+
+	// null resets the priority to the default
+	todo, err = svc.Update(ctx, owner, todo.ID, domain.UpdateTodoRequest{Priority: domain.PatchNull[domain.Priority]()}, 0)
+	if err != nil || todo.Priority != domain.PriorityMedium {
+		t.Fatalf("Update to null: got %v, %v", todo, err)
+	}
+}
+
+func TestTodoServiceCompletedAt(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), Role: domain.RoleUser}
+	ctx := context.Background()
+	todo := domain.Todo{ID: uuid.New(), Title: "Write tests", UserID: owner.UserID, Version: 1}
+	svc := NewTodoService(newFakeTodoRepository(todo), cursor.NewCodec("test-secret"), 10)
+
+	complete := func(completed bool) *domain.Todo {
+		t.Helper()
+		updated, err := svc.Update(ctx, owner, todo.ID, domain.UpdateTodoRequest{Completed: domain.PatchValue(completed)}, 0)
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		return updated
+	}
+
+	completed := complete(true)
+	if completed.CompletedAt == nil {
+		t.Fatal("completing did not set completed_at")
+	}
+	first := *completed.CompletedAt
+
+	if again := complete(true); again.CompletedAt == nil || !again.CompletedAt.Equal(first) {
+		t.Fatalf("completing again changed completed_at from %v to %v", first, again.CompletedAt)
+	}
+	if reopened := complete(false); reopened.CompletedAt != nil {
+		t.Fatalf("reopening kept completed_at %v", reopened.CompletedAt)
+	}
+}
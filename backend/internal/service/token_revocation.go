@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"template-fullstack/backend/internal/pkg/cache"
+	"template-fullstack/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// notRevokedTTL bounds how long a "not revoked" answer is trusted. Revocations
+// made by this instance are visible immediately; with several instances a
+// logout made elsewhere takes effect here within this window.
+const notRevokedTTL = 30 * time.Second
+
+// tokenVersionTTL bounds how long a user's token version is trusted, on the
+// same terms as notRevokedTTL.
+const tokenVersionTTL = 30 * time.Second
+
+// TokenRevocationStore records access tokens (by jti) that were invalidated
+// before their expiry, and the token version that a user's access tokens must
+// carry to still be valid.
+type TokenRevocationStore interface {
+	Revoke(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	TokenVersion(ctx context.Context, userID uuid.UUID) (int, error)
+	// ForgetTokenVersion must be called after the user's token version
+	// changed or the user was deleted.
+	ForgetTokenVersion(userID uuid.UUID)
+}
+
+type tokenRevocationStore struct {
+	repo     repository.RevokedTokenRepository
+	users    repository.UserRepository
+	cache    *cache.LRU[string, bool]
+	versions *cache.LRU[uuid.UUID, int]
+}
+
+// NewTokenRevocationStore returns a Postgres-backed revocation store fronted
+// by in-memory LRU caches of cacheSize entries each.
+func NewTokenRevocationStore(repo repository.RevokedTokenRepository, users repository.UserRepository, cacheSize int) TokenRevocationStore {
+	return &tokenRevocationStore{
+		repo:     repo,
+		users:    users,
+		cache:    cache.NewLRU[string, bool](cacheSize),
+		versions: cache.NewLRU[uuid.UUID, int](cacheSize),
+	}
+}
+
+func (s *tokenRevocationStore) Revoke(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error {
+	if err := s.repo.Revoke(ctx, jti, userID, expiresAt); err != nil {
+		return err
+	}
+	s.cache.Set(jti, true, time.Until(expiresAt))
+
+	return nil
+}
+
+func (s *tokenRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if revoked, ok := s.cache.Get(jti); ok {
+		return revoked, nil
+	}
+
+	revoked, err := s.repo.IsRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+
+	// A revocation never goes away, so only the negative answer needs a TTL
+	ttl := notRevokedTTL
+	if revoked {
+		ttl = 0
+	}
+	s.cache.Set(jti, revoked, ttl)
+
+	return revoked, nil
+}
+
+func (s *tokenRevocationStore) TokenVersion(ctx context.Context, userID uuid.UUID) (int, error) {
+	if version, ok := s.versions.Get(userID); ok {
+		return version, nil
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	s.versions.Set(userID, user.TokenVersion, tokenVersionTTL)
+
+	return user.TokenVersion, nil
+}
+
+func (s *tokenRevocationStore) ForgetTokenVersion(userID uuid.UUID) {
+	s.versions.Delete(userID)
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// fakeRevokedTokenRepository is an in-memory repository.RevokedTokenRepository
+type fakeRevokedTokenRepository struct {
+	expiries map[string]time.Time
+	// lookups counts IsRevoked calls
+	lookups int
+}
+
+func newFakeRevokedTokenRepository() *fakeRevokedTokenRepository {
+	return &fakeRevokedTokenRepository{expiries: make(map[string]time.Time)}
+}
+
+func (r *fakeRevokedTokenRepository) Revoke(ctx context.Context, jti string, userID uuid.UUID, expiresAt time.Time) error {
+	r.expiries[jti] = expiresAt
+	return nil
+}
+
+func (r *fakeRevokedTokenRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	r.lookups++
+	_, ok := r.expiries[jti]
+	return ok, nil
+}
+
+func (r *fakeRevokedTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	var deleted int64
+	for jti, expiresAt := range r.expiries {
+		if expiresAt.Before(time.Now()) {
+			delete(r.expiries, jti)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// countingUserRepository counts the users it loads by ID
+type countingUserRepository struct {
+	*fakeUserRepository
+	lookups int
+}
+
+func (r *countingUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	r.lookups++
+	return r.fakeUserRepository.GetByID(ctx, id)
+}
+
+func TestTokenRevocationStoreRevoke(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRevokedTokenRepository()
+	store := NewTokenRevocationStore(repo, nil, 10)
+
+	// A negative answer is cached
+	for i := 0; i < 2; i++ {
+		if revoked, err := store.IsRevoked(ctx, "jti-1"); err != nil || revoked {
+			t.Fatalf("IsRevoked before logout: got %v, %v", revoked, err)
+		}
+	}
+	if repo.lookups != 1 {
+		t.Fatalf("got %d lookups, want the second answer from the cache", repo.lookups)
+	}
+
+	// Revoking through the store replaces the cached negative answer
+	if err := store.Revoke(ctx, "jti-1", uuid.New(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if revoked, err := store.IsRevoked(ctx, "jti-1"); err != nil || !revoked {
+		t.Fatalf("IsRevoked after logout: got %v, %v", revoked, err)
+	}
+	if repo.lookups != 1 {
+		t.Fatalf("got %d lookups, want the revocation answered from the cache", repo.lookups)
+	}
+	if _, ok := repo.expiries["jti-1"]; !ok {
+		t.Fatal("the revocation was not stored")
+	}
+
+	// A revocation made elsewhere is cached once it is found
+	repo.expiries["jti-2"] = time.Now().Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if revoked, err := store.IsRevoked(ctx, "jti-2"); err != nil || !revoked {
+			t.Fatalf("IsRevoked of a stored revocation: got %v, %v", revoked, err)
+		}
+	}
+	if repo.lookups != 2 {
+		t.Fatalf("got %d lookups, want 2", repo.lookups)
+	}
+
+	// Logging out leaves pruning to the background purger
+	repo.expiries["jti-expired"] = time.Now().Add(-time.Minute)
+	if err := store.Revoke(ctx, "jti-3", uuid.New(), time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, ok := repo.expiries["jti-expired"]; !ok {
+		t.Fatal("Revoke pruned expired revocations")
+	}
+}
+
+func TestTokenRevocationStoreTokenVersion(t *testing.T) {
+	ctx := context.Background()
+	users := &countingUserRepository{fakeUserRepository: &fakeUserRepository{users: make(map[uuid.UUID]domain.User)}}
+	user := domain.User{ID: uuid.New(), Email: "ada@example.com", TokenVersion: 3}
+	users.users[user.ID] = user
+	store := NewTokenRevocationStore(newFakeRevokedTokenRepository(), users, 10)
+
+	for i := 0; i < 2; i++ {
+		if version, err := store.TokenVersion(ctx, user.ID); err != nil || version != 3 {
+			t.Fatalf("TokenVersion: got %d, %v, want 3", version, err)
+		}
+	}
+	if users.lookups != 1 {
+		t.Fatalf("got %d user lookups, want the second answer from the cache", users.lookups)
+	}
+
+	// A changed version is only seen once the cached one is forgotten
+	if err := users.IncrementTokenVersion(ctx, user.ID); err != nil {
+		t.Fatalf("IncrementTokenVersion: %v", err)
+	}
+	if version, _ := store.TokenVersion(ctx, user.ID); version != 3 {
+		t.Fatalf("TokenVersion before forgetting: got %d, want the cached 3", version)
+	}
+	store.ForgetTokenVersion(user.ID)
+	if version, err := store.TokenVersion(ctx, user.ID); err != nil || version != 4 {
+		t.Fatalf("TokenVersion after the change: got %d, %v, want 4", version, err)
+	}
+
+	// Unknown users are not cached, so a user created later is found
+	unknown := uuid.New()
+	if _, err := store.TokenVersion(ctx, unknown); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("TokenVersion of an unknown user: got error %v, want %v", err, domain.ErrUserNotFound)
+	}
+	users.users[unknown] = domain.User{ID: unknown}
+	if _, err := store.TokenVersion(ctx, unknown); err != nil {
+		t.Fatalf("TokenVersion of a new user: %v", err)
+	}
+}
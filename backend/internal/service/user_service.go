@@ -2,7 +2,6 @@ package service
 
 import (
 	"context"
-	"math"
 
 	"template-fullstack/backend/internal/domain"
 	"template-fullstack/backend/internal/repository"
@@ -11,16 +10,46 @@ import (
 )
 
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo    repository.UserRepository
+	hasher      PasswordHasher
+	revocations TokenRevocationStore
 }
 
-func NewUserService(userRepo repository.UserRepository) UserService {
-	return &userService{userRepo: userRepo}
+func NewUserService(userRepo repository.UserRepository, hasher PasswordHasher, revocations TokenRevocationStore) UserService {
+	return &userService{
+		userRepo:    userRepo,
+		hasher:      hasher,
+		revocations: revocations,
+	}
 }
 
+// Create registers a user. Without a signed-in actor, as on sign-up, the
+// audit log attributes the change to the new user.
 func (s *userService) Create(ctx context.Context, req domain.CreateUserRequest) (*domain.User, error) {
-	// In a real application, hash the password here
-	return s.userRepo.Create(ctx, req)
+	passwordHash, err := s.hasher.Hash(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *domain.User
+	err = s.userRepo.Transaction(ctx, func(repo repository.UserRepository) error {
+		user, err = repo.Create(ctx, req, passwordHash)
+		if err != nil {
+			return err
+		}
+
+		if info := domain.RequestInfoFromContext(ctx); info.ActorID == nil {
+			info.ActorID = &user.ID
+			ctx = domain.WithRequestInfo(ctx, info)
+		}
+		return recordUserAudit(ctx, repo, domain.AuditActionCreate, nil, user)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
 }
 
 func (s *userService) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
@@ -28,11 +57,68 @@ func (s *userService) GetByID(ctx context.Context, id uuid.UUID) (*domain.User,
 }
 
 func (s *userService) Update(ctx context.Context, id uuid.UUID, req domain.UpdateUserRequest) (*domain.User, error) {
-	return s.userRepo.Update(ctx, id, req)
+	return s.change(ctx, id, func(repo repository.UserRepository) (*domain.User, error) {
+		return repo.Update(ctx, id, req)
+	})
+}
+
+// SetRole also invalidates the user's tokens, which carry the old role
+func (s *userService) SetRole(ctx context.Context, id uuid.UUID, role domain.Role) (*domain.User, error) {
+	user, err := s.change(ctx, id, func(repo repository.UserRepository) (*domain.User, error) {
+		return repo.UpdateRole(ctx, id, role)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.revocations.ForgetTokenVersion(id)
+
+	return user, nil
 }
 
 func (s *userService) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.userRepo.Delete(ctx, id)
+	err := s.userRepo.Transaction(ctx, func(repo repository.UserRepository) error {
+		user, err := repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return recordUserAudit(ctx, repo, domain.AuditActionDelete, user, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The deleted user's tokens must stop working
+	s.revocations.ForgetTokenVersion(id)
+
+	return nil
+}
+
+// change runs update on user id and records the difference in the audit log,
+// in one transaction
+func (s *userService) change(ctx context.Context, id uuid.UUID, update func(repo repository.UserRepository) (*domain.User, error)) (*domain.User, error) {
+	var updated *domain.User
+	err := s.userRepo.Transaction(ctx, func(repo repository.UserRepository) error {
+		user, err := repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		updated, err = update(repo)
+		if err != nil {
+			return err
+		}
+		return recordUserAudit(ctx, repo, domain.AuditActionUpdate, user, updated)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
 }
 
 func (s *userService) List(ctx context.Context, pagination domain.PaginationQuery) (*domain.PaginatedResponse, error) {
@@ -41,15 +127,5 @@ func (s *userService) List(ctx context.Context, pagination domain.PaginationQuer
 		return nil, err
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(pagination.PageSize)))
-
-	return &domain.PaginatedResponse{
-		Data: users,
-		Pagination: domain.Pagination{
-			Page:       pagination.Page,
-			PageSize:   pagination.PageSize,
-			Total:      total,
-			TotalPages: totalPages,
-		},
-	}, nil
+	return domain.NewPaginatedResponse(users, total, pagination), nil
 }
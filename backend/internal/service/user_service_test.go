@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"template-fullstack/backend/internal/domain"
+	"template-fullstack/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// fakeUserRepository is an in-memory repository.UserRepository
+type fakeUserRepository struct {
+	users map[uuid.UUID]domain.User
+	audit []domain.AuditEvent
+}
+
+func (r *fakeUserRepository) Create(ctx context.Context, req domain.CreateUserRequest, passwordHash string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.Email == req.Email {
+			return nil, domain.ErrEmailExists
+		}
+	}
+	user := domain.User{ID: uuid.New(), Email: req.Email, Name: req.Name, Role: domain.RoleUser, Password: passwordHash,
+		CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	r.users[user.ID] = user
+	return &user, nil
+}
+
+func (r *fakeUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (r *fakeUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *fakeUserRepository) Update(ctx context.Context, id uuid.UUID, req domain.UpdateUserRequest) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	if req.Name != "" {
+		user.Name = req.Name
+	}
+	if req.Email != "" {
+		user.Email = req.Email
+	}
+	user.UpdatedAt = time.Now()
+	r.users[id] = user
+	return &user, nil
+}
+
+func (r *fakeUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return nil
+}
+
+func (r *fakeUserRepository) IncrementTokenVersion(ctx context.Context, id uuid.UUID) error {
+	user, ok := r.users[id]
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	user.TokenVersion++
+	r.users[id] = user
+	return nil
+}
+
+func (r *fakeUserRepository) UpdateRole(ctx context.Context, id uuid.UUID, role domain.Role) (*domain.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	user.Role = role
+	user.TokenVersion++
+	r.users[id] = user
+	return &user, nil
+}
+
+func (r *fakeUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, ok := r.users[id]; !ok {
+		return domain.ErrUserNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+func (r *fakeUserRepository) List(ctx context.Context, pagination domain.PaginationQuery) ([]domain.User, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeUserRepository) RecordAudit(ctx context.Context, event domain.AuditEvent) error {
+	r.audit = append(r.audit, event)
+	return nil
+}
+
+// Transaction drops the audit events of a failed fn, like a rollback
+func (r *fakeUserRepository) Transaction(ctx context.Context, fn func(repo repository.UserRepository) error) error {
+	audited := len(r.audit)
+	if err := fn(r); err != nil {
+		r.audit = r.audit[:audited]
+		return err
+	}
+	return nil
+}
+
+// plainHasher stores passwords as they are, to keep tests fast
+type plainHasher struct{}
+
+func (plainHasher) Hash(password string) (string, error)       { return password, nil }
+func (plainHasher) Verify(hash, password string) (bool, error) { return hash == password, nil }
+func (plainHasher) NeedsRehash(hash string) bool               { return false }
+
+func TestUserServiceAudit(t *testing.T) {
+	repo := &fakeUserRepository{users: make(map[uuid.UUID]domain.User)}
+	svc := NewUserService(repo, plainHasher{}, NewTokenRevocationStore(newFakeRevokedTokenRepository(), repo, 10))
+	ctx := domain.WithRequestInfo(context.Background(), domain.RequestInfo{RequestID: "req-1", IP: "192.0.2.1"})
+
+	// Signing up is attributed to the new user
+	user, err := svc.Create(ctx, domain.CreateUserRequest{Email: "ada@example.com", Name: "Ada", Password: "secret123"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	before, after := auditChanges(t, repo.audit[0])
+	if repo.audit[0].ActorID == nil || *repo.audit[0].ActorID != user.ID || before != nil || after["email"] != "ada@example.com" {
+		t.Fatalf("create: got actor %v, before %v, after %v", repo.audit[0].ActorID, before, after)
+	}
+	if _, ok := after["password"]; ok {
+		t.Fatal("the audit log contains the password")
+	}
+
+	admin := uuid.New()
+	adminCtx := domain.WithRequestInfo(ctx, domain.RequestInfo{ActorID: &admin, RequestID: "req-2"})
+	if _, err := svc.SetRole(adminCtx, user.ID, domain.RoleAdmin); err != nil {
+		t.Fatalf("SetRole: %v", err)
+	}
+	before, after = auditChanges(t, repo.audit[1])
+	if *repo.audit[1].ActorID != admin || len(before) != 1 || before["role"] != "user" || after["role"] != "admin" {
+		t.Fatalf("set role: got actor %v, before %v, after %v", repo.audit[1].ActorID, before, after)
+	}
+
+	if _, err := svc.Create(ctx, domain.CreateUserRequest{Email: "ada@example.com", Name: "Ada", Password: "secret123"}); err == nil {
+		t.Fatal("Create accepted a duplicate email")
+	}
+	if err := svc.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if len(repo.audit) != 3 || repo.audit[2].Action != domain.AuditActionDelete || repo.audit[2].After != nil {
+		t.Fatalf("got %d audit events, want create, update and delete", len(repo.audit))
+	}
+}
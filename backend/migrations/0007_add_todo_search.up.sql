@@ -0,0 +1,8 @@
+-- 'simple' avoids language-specific stemming, since todos are written in
+-- any of the supported UI languages
+ALTER TABLE todos ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX idx_todos_search_vector ON todos USING GIN (search_vector);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_todos_created_at_id;
+DROP INDEX IF EXISTS idx_todos_user_id_created_at_id;
@@ -0,0 +1,3 @@
+-- Support keyset pagination over (created_at, id), newest first
+CREATE INDEX idx_todos_user_id_created_at_id ON todos(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_todos_created_at_id ON todos(created_at DESC, id DESC);
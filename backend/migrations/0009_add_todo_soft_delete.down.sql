@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_todos_deleted_at;
+DELETE FROM todos WHERE deleted_at IS NOT NULL;
+ALTER TABLE todos DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,4 @@
+ALTER TABLE todos ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Only trashed rows are indexed; the trash listing and the purger read them
+CREATE INDEX idx_todos_deleted_at ON todos(deleted_at) WHERE deleted_at IS NOT NULL;
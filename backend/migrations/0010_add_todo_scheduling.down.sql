@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_todos_reminders;
+DROP INDEX IF EXISTS idx_todos_user_id_due_at;
+ALTER TABLE todos DROP COLUMN IF EXISTS reminded_at;
+ALTER TABLE todos DROP COLUMN IF EXISTS completed_at;
+ALTER TABLE todos DROP COLUMN IF EXISTS due_at;
+ALTER TABLE todos DROP COLUMN IF EXISTS priority;
+DROP TYPE IF EXISTS todo_priority;
@@ -0,0 +1,16 @@
+-- Declared from least to most important so that ORDER BY priority sorts by importance
+CREATE TYPE todo_priority AS ENUM ('low', 'medium', 'high');
+
+ALTER TABLE todos ADD COLUMN priority todo_priority NOT NULL DEFAULT 'medium';
+ALTER TABLE todos ADD COLUMN due_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE todos ADD COLUMN completed_at TIMESTAMP WITH TIME ZONE;
+-- When the reminder for the current due_at was sent
+ALTER TABLE todos ADD COLUMN reminded_at TIMESTAMP WITH TIME ZONE;
+
+-- Best guess for todos completed before completed_at existed
+UPDATE todos SET completed_at = updated_at WHERE completed;
+
+CREATE INDEX idx_todos_user_id_due_at ON todos(user_id, due_at) WHERE due_at IS NOT NULL;
+-- Pending reminders, scanned by the reminder scheduler
+CREATE INDEX idx_todos_reminders ON todos(due_at)
+    WHERE reminded_at IS NULL AND NOT completed AND deleted_at IS NULL AND due_at IS NOT NULL;
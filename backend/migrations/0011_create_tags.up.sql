@@ -0,0 +1,23 @@
+CREATE TABLE tags (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Tag names are unique per user, ignoring case
+CREATE UNIQUE INDEX tags_user_id_name_key ON tags(user_id, lower(name));
+
+CREATE TABLE todo_tags (
+    todo_id UUID NOT NULL,
+    tag_id UUID NOT NULL,
+    PRIMARY KEY (todo_id, tag_id),
+    FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+-- The primary key serves lookups by todo; tag filters go the other way
+CREATE INDEX idx_todo_tags_tag_id ON todo_tags(tag_id);
@@ -0,0 +1,4 @@
+-- Subtasks are removed together with their parent when it is purged
+ALTER TABLE todos ADD COLUMN parent_id UUID REFERENCES todos(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_todos_parent_id ON todos(parent_id) WHERE parent_id IS NOT NULL;
@@ -0,0 +1,16 @@
+-- Fractional index keys (see internal/pkg/rank), compared bytewise
+ALTER TABLE todos ADD COLUMN position TEXT COLLATE "C";
+
+-- Keep the current newest-first order. The suffix stops keys from ending in
+-- '0', which rank keys never do.
+UPDATE todos t
+SET position = lpad(o.n::text, 10, '0') || 'V'
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY user_id ORDER BY created_at DESC, id) AS n
+    FROM todos
+) o
+WHERE t.id = o.id;
+
+ALTER TABLE todos ALTER COLUMN position SET NOT NULL;
+
+CREATE INDEX idx_todos_user_id_position ON todos(user_id, position, id);
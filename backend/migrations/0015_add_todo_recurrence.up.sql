@@ -0,0 +1,3 @@
+-- An RFC 5545 RRULE, empty for todos that don't recur. Only the open
+-- occurrence of a series carries the rule.
+ALTER TABLE todos ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
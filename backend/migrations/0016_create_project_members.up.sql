@@ -0,0 +1,35 @@
+CREATE TABLE project_members (
+    project_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (project_id, user_id),
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT project_members_role_check CHECK (role IN ('viewer', 'editor', 'owner'))
+);
+
+CREATE INDEX idx_project_members_user_id ON project_members(user_id);
+
+-- The creator of a project is its first owner
+INSERT INTO project_members (project_id, user_id, role, created_at, updated_at)
+SELECT id, user_id, 'owner', created_at, created_at FROM projects;
+
+-- Pending invitations; accepting or declining one deletes it
+CREATE TABLE project_invitations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    project_id UUID NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    invited_by UUID,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT project_invitations_role_check CHECK (role IN ('viewer', 'editor', 'owner'))
+);
+
+-- One pending invitation per project and address; inviting again renews it
+CREATE UNIQUE INDEX project_invitations_project_id_email_key ON project_invitations(project_id, lower(email));
+CREATE INDEX idx_project_invitations_email ON project_invitations(lower(email));
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS todo_attachments;
+DROP FUNCTION IF EXISTS queue_orphaned_blob();
+DROP TABLE IF EXISTS orphaned_blobs;
@@ -0,0 +1,34 @@
+CREATE TABLE todo_attachments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    todo_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    -- Hex encoded SHA-256 of the contents
+    checksum CHAR(64) NOT NULL,
+    storage_key TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_todo_attachments_todo_id ON todo_attachments(todo_id, created_at);
+
+-- Blobs whose attachment is gone, also through a purged todo or deleted
+-- user, wait here until they are deleted from storage
+CREATE TABLE orphaned_blobs (
+    storage_key TEXT PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE FUNCTION queue_orphaned_blob() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO orphaned_blobs (storage_key) VALUES (OLD.storage_key) ON CONFLICT DO NOTHING;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER todo_attachments_queue_orphaned_blob
+    AFTER DELETE ON todo_attachments
+    FOR EACH ROW EXECUTE FUNCTION queue_orphaned_blob();
@@ -0,0 +1,21 @@
+-- audit_events is an append-only history of changes. It has no foreign keys,
+-- so history outlives the todos and users it describes.
+CREATE TABLE audit_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    -- actor_id is NULL for changes made without a signed-in user
+    actor_id UUID,
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id UUID NOT NULL,
+    action VARCHAR(32) NOT NULL,
+    -- before and after hold only the fields that changed: after alone for a
+    -- create, before alone for a delete
+    before JSONB,
+    after JSONB,
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_events_entity ON audit_events(entity_type, entity_id, created_at DESC);
+CREATE INDEX idx_audit_events_actor_id ON audit_events(actor_id, created_at DESC);
+CREATE INDEX idx_audit_events_created_at ON audit_events(created_at DESC);
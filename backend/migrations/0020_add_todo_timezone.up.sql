@@ -0,0 +1,2 @@
+-- The IANA time zone a todo's recurrence rule is evaluated in
+ALTER TABLE todos ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
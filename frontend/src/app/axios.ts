@@ -1,4 +1,5 @@
 import axios, { AxiosError, AxiosResponse, InternalAxiosRequestConfig } from 'axios'
+import i18n from './i18n'
 
 const API_BASE_URL = import.meta.env.VITE_API_URL || '/api/v1'
 
@@ -18,6 +19,8 @@ api.interceptors.request.use(
     if (token) {
       config.headers.Authorization = `Bearer ${token}`
     }
+    // Lets the backend translate validation messages
+    config.headers['Accept-Language'] = i18n.language
     return config
   },
   (error: AxiosError) => {
@@ -25,6 +28,42 @@ api.interceptors.request.use(
   }
 )
 
+const clearSession = () => {
+  localStorage.removeItem('authToken')
+  localStorage.removeItem('refreshToken')
+  localStorage.removeItem('user')
+}
+
+// Refresh tokens are single-use, so concurrent 401s must share one refresh call
+let refreshPromise: Promise<string> | null = null
+
+const refreshAccessToken = (): Promise<string> => {
+  if (!refreshPromise) {
+    const refreshToken = localStorage.getItem('refreshToken')
+    refreshPromise = (
+      refreshToken
+        ? axios
+            .post<ApiResponse<{ token: string; refresh_token: string }>>(
+              `${API_BASE_URL}/auth/refresh`,
+              { refresh_token: refreshToken }
+            )
+            .then((response) => {
+              const data = response.data.data
+              if (!response.data.success || !data) {
+                throw new Error('Refresh failed')
+              }
+              localStorage.setItem('authToken', data.token)
+              localStorage.setItem('refreshToken', data.refresh_token)
+              return data.token
+            })
+        : Promise.reject(new Error('No refresh token'))
+    ).finally(() => {
+      refreshPromise = null
+    })
+  }
+  return refreshPromise
+}
+
 // Response interceptor for error handling and retry logic
 api.interceptors.response.use(
   (response: AxiosResponse) => {
@@ -33,13 +72,26 @@ api.interceptors.response.use(
   async (error: AxiosError) => {
     const originalRequest = error.config as InternalAxiosRequestConfig & {
       headers: Record<string, string>
+      _retry?: boolean
     }
 
-    // Handle 401 errors (unauthorized)
+    // Handle 401 errors (unauthorized): try a refresh once, then log out
     if (error.response?.status === 401) {
-      localStorage.removeItem('authToken')
-      localStorage.removeItem('user')
-      window.location.href = '/login'
+      const isAuthCall = originalRequest?.url?.startsWith('/auth/')
+      if (originalRequest && !originalRequest._retry && !isAuthCall) {
+        originalRequest._retry = true
+        try {
+          const token = await refreshAccessToken()
+          originalRequest.headers.Authorization = `Bearer ${token}`
+          return api(originalRequest)
+        } catch {
+          // fall through to logout
+        }
+      }
+      if (!isAuthCall) {
+        clearSession()
+        window.location.href = '/login'
+      }
       return Promise.reject(error)
     }
 
@@ -66,9 +118,17 @@ export interface ApiResponse<T = any> {
   error?: {
     code: string
     message: string
+    details?: FieldError[]
   }
 }
 
+export interface FieldError {
+  field: string
+  rule: string
+  param?: string
+  message: string
+}
+
 export interface PaginatedResponse<T = any> {
   data: T[]
   pagination: {
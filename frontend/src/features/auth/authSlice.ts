@@ -5,6 +5,7 @@ export interface User {
   id: string
   email: string
   name: string
+  role: 'user' | 'admin'
   created_at: string
   updated_at: string
 }
@@ -24,6 +25,9 @@ interface LoginRequest {
 
 interface LoginResponse {
   token: string
+  expires_at: string
+  refresh_token: string
+  refresh_expires_at: string
   user: User
 }
 
@@ -45,8 +49,9 @@ export const loginUser = createAsyncThunk<
     const response = await api.post<ApiResponse<LoginResponse>>('/auth/login', credentials)
     
     if (response.data.success && response.data.data) {
-      const { token, user } = response.data.data
+      const { token, refresh_token, user } = response.data.data
       localStorage.setItem('authToken', token)
+      localStorage.setItem('refreshToken', refresh_token)
       localStorage.setItem('user', JSON.stringify(user))
       return response.data.data
     } else {
@@ -60,7 +65,15 @@ export const loginUser = createAsyncThunk<
 })
 
 export const logoutUser = createAsyncThunk('auth/logout', async () => {
+  try {
+    await api.post('/auth/logout', {
+      refresh_token: localStorage.getItem('refreshToken') || '',
+    })
+  } catch {
+    // Server-side revocation is best-effort; the local session is cleared anyway
+  }
   localStorage.removeItem('authToken')
+  localStorage.removeItem('refreshToken')
   localStorage.removeItem('user')
 })
 
@@ -1,14 +1,38 @@
 import { createSlice, createAsyncThunk, PayloadAction } from '@reduxjs/toolkit'
 import api, { ApiResponse, PaginatedResponse } from '../../app/axios'
 
+export type TodoPriority = 'low' | 'medium' | 'high'
+
+export interface Tag {
+  id: string
+  user_id: string
+  name: string
+  color: string
+  created_at: string
+  updated_at: string
+}
+
 export interface Todo {
   id: string
   title: string
   description: string
   completed: boolean
   user_id: string
+  project_id: string | null
+  parent_id: string | null
+  priority: TodoPriority
+  due_at: string | null
+  // RFC 5545 RRULE, empty for one-off todos
+  recurrence: string
+  // IANA time zone the recurrence is evaluated in
+  timezone: string
+  completed_at: string | null
+  position: string
+  version: number
   created_at: string
   updated_at: string
+  tags: Tag[]
+  progress: { completed: number; total: number }
 }
 
 export interface TodoState {
@@ -27,12 +51,28 @@ export interface TodoState {
 interface CreateTodoRequest {
   title: string
   description: string
+  priority?: TodoPriority
+  due_at?: string | null
+  recurrence?: string
+  timezone?: string
+  tag_ids?: string[]
+  project_id?: string | null
+  parent_id?: string | null
 }
 
+// JSON Merge Patch: omit a field to keep it, send null to clear it
 interface UpdateTodoRequest {
   title?: string
-  description?: string
+  description?: string | null
   completed?: boolean
+  priority?: TodoPriority | null
+  due_at?: string | null
+  // Clearing it together with completed: true stops the series
+  recurrence?: string | null
+  timezone?: string | null
+  tag_ids?: string[] | null
+  project_id?: string | null
+  parent_id?: string | null
 }
 
 interface FetchTodosParams {
@@ -98,11 +138,17 @@ export const createTodo = createAsyncThunk<
 
 export const updateTodo = createAsyncThunk<
   Todo,
-  { id: string; data: UpdateTodoRequest },
+  { id: string; data: UpdateTodoRequest; version?: number },
   { rejectValue: string }
->('todos/updateTodo', async ({ id, data }, { rejectWithValue }) => {
+>('todos/updateTodo', async ({ id, data, version }, { rejectWithValue }) => {
   try {
-    const response = await api.put<ApiResponse<Todo>>(`/todos/${id}`, data)
+    // Sending the version we last saw makes the server reject the update
+    // with 412 if the todo was changed elsewhere in the meantime
+    const headers: Record<string, string> = { 'Content-Type': 'application/merge-patch+json' }
+    if (version !== undefined) {
+      headers['If-Match'] = `"${version}"`
+    }
+    const response = await api.patch<ApiResponse<Todo>>(`/todos/${id}`, data, { headers })
     
     if (response.data.success && response.data.data) {
       return response.data.data
@@ -37,7 +37,8 @@ const TodosPage: React.FC = () => {
   }
 
   const handleUpdateTodo = async (id: string, data: Partial<Todo>) => {
-    await dispatch(updateTodo({ id, data }))
+    const version = todos.find(todo => todo.id === id)?.version
+    await dispatch(updateTodo({ id, data, version }))
     setEditingTodo(null)
   }
 